@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// actualSnapshotPath returns the path that the actual data is written to when
+// the comparison against the snapshot file at p, with extension ext, fails.
+func actualSnapshotPath(p, ext string) string {
+	return strings.TrimSuffix(p, ext) + actualSuffix + ext
+}
+
+// writeActualOnFailure writes actual to the file at p in s if ok is false, or
+// removes any existing file at p if ok is true. The data is encoded as for
+// encodeSnapshot. Writing the file is logged to logf.
+func writeActualOnFailure(t testing.TB, logf Logger, s Store, p string, actual []byte, ok bool, encoding snapshotEncoding) {
+	if ok {
+		if err := removeFromStore(s, p); err != nil {
+			t.Errorf("failed to remove actual output file %v: %v", p, err.Error())
+		}
+		return
+	}
+	buf := new(bytes.Buffer)
+	enc := encodeSnapshot(buf, encoding)
+	_, _ = enc.Write(actual)
+	if err := enc.Close(); err != nil {
+		t.Errorf("failed to encode actual output file %v: %v", p, err.Error())
+		return
+	}
+	if err := writeStoreFile(s, p, buf.Bytes()); err != nil {
+		t.Errorf("failed to write actual output file %v: %v", p, err.Error())
+		return
+	}
+	logf("actual output written to %v", p)
+}
+
+// actualSuffix is inserted before the file extension of the snapshot file to
+// name the file that the actual data is written to on failure.
+const actualSuffix = ".actual"
+
+// expectedSnapshotPath returns the path of the snapshot file that the actual
+// data at p was compared against, or false if p is not an actual data file.
+func expectedSnapshotPath(p string) (string, bool) {
+	dir, name := filepath.Split(p)
+	if i := strings.LastIndex(name, actualSuffix+"."); i > 0 {
+		return filepath.Join(dir, name[:i]+name[i+len(actualSuffix):]), true
+	}
+	if strings.HasSuffix(name, actualSuffix) && len(name) > len(actualSuffix) {
+		return filepath.Join(dir, strings.TrimSuffix(name, actualSuffix)), true
+	}
+	return "", false
+}
+
+// AcceptSnapshots walks dir, typically a __snapshots__ directory, and renames
+// every <name>.actual.<ext> file written by WithWriteActualOnFailure over the
+// corresponding <name>.<ext> snapshot file, recomputing its checksum sidecar
+// file if it has one, as written by WithChecksum. The accepted snapshot paths
+// are returned. This completes a review loop of running the tests, inspecting
+// the .actual files and then accepting them, e.g. from TestMain:
+//
+//	var accept = flag.Bool("accept", false, "accept .actual snapshots")
+//
+//	func TestMain(m *testing.M) {
+//		flag.Parse()
+//		if *accept {
+//			if _, err := snapshot.AcceptSnapshots("__snapshots__"); err != nil {
+//				log.Fatal(err)
+//			}
+//		}
+//		os.Exit(m.Run())
+//	}
+func AcceptSnapshots(dir string) (accepted []string, err error) {
+	paths, err := findSnapshotFiles(dir, "*"+actualSuffix+"*")
+	if err != nil {
+		return
+	}
+	for _, p := range paths {
+		expected, ok := expectedSnapshotPath(p)
+		if !ok {
+			continue
+		}
+		if err = os.Rename(p, expected); err != nil {
+			return
+		}
+		if _, statErr := os.Stat(checksumPath(expected)); statErr == nil {
+			if err = writeChecksum(osStore{}, expected); err != nil {
+				return
+			}
+		}
+		accepted = append(accepted, expected)
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExpectedSnapshotPath(t *testing.T) {
+	tests := []struct {
+		p        string
+		expected string
+		ok       bool
+	}{
+		{"a/output.actual.txt", "a/output.txt", true},
+		{"a/output.v1.actual.txt", "a/output.v1.txt", true},
+		{"a/output.actual.txt.b64", "a/output.txt.b64", true},
+		{"a/output.actual", "a/output", true},
+		{"a/output.txt", "", false},
+		{"a/.actual.txt", "", false},
+		{"a/factual.txt", "", false},
+	}
+	for _, tt := range tests {
+		expected, ok := expectedSnapshotPath(filepath.FromSlash(tt.p))
+		if expected != filepath.FromSlash(tt.expected) || ok != tt.ok {
+			t.Errorf("%v: expected %q, %v, got %q, %v", tt.p, tt.expected, tt.ok, expected, ok)
+		}
+	}
+}
+
+func TestAcceptSnapshots(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	if ok, _ := Match(t, strings.NewReader("world"), WithWriteActualOnFailure(), dirOpt); ok {
+		t.Fatalf("expected match to fail")
+	}
+	accepted, err := AcceptSnapshots(filepath.Dir(filepath.Dir(outputP)))
+	if err != nil {
+		t.Fatalf("failed to accept snapshots: %v", err)
+	}
+	if diff := cmp.Diff([]string{outputP}, accepted); diff != "" {
+		t.Errorf("unexpected accepted snapshots: %v", diff)
+	}
+	if _, err := os.Stat(actualSnapshotPath(outputP, ".txt")); !os.IsNotExist(err) {
+		t.Errorf("expected actual file to be removed, got %v", err)
+	}
+	if ok, msg := Match(t, strings.NewReader("world"), WithNoAutoCreate(), dirOpt); !ok {
+		t.Errorf("expected accepted snapshot to match: %v", msg)
+	}
+}
@@ -0,0 +1,144 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// An archiveEntry is the logical content of a file within an archive.
+type archiveEntry struct {
+	size int64
+	hash string
+}
+
+// readArchiveManifest reads the regular files of the tar, gzip compressed tar
+// or zip archive b, which is detected from its content, keyed by name.
+func readArchiveManifest(b []byte) (map[string]archiveEntry, error) {
+	switch {
+	case bytes.HasPrefix(b, []byte("PK\x03\x04")) || bytes.HasPrefix(b, []byte("PK\x05\x06")):
+		return readZipManifest(b)
+	case bytes.HasPrefix(b, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		return readTarManifest(gz)
+	default:
+		return readTarManifest(bytes.NewReader(b))
+	}
+}
+
+// hashArchiveEntry returns the entry for the content of a file read from r.
+func hashArchiveEntry(r io.Reader) (archiveEntry, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, r)
+	return archiveEntry{size: n, hash: hex.EncodeToString(h.Sum(nil))}, err
+}
+
+func readZipManifest(b []byte) (map[string]archiveEntry, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	manifest := make(map[string]archiveEntry)
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		entry, err := hashArchiveEntry(rc)
+		_ = rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v: %w", f.Name, err)
+		}
+		manifest[f.Name] = entry
+	}
+	return manifest, nil
+}
+
+func readTarManifest(r io.Reader) (map[string]archiveEntry, error) {
+	tr := tar.NewReader(r)
+	manifest := make(map[string]archiveEntry)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return manifest, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		entry, err := hashArchiveEntry(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v: %w", hdr.Name, err)
+		}
+		manifest[hdr.Name] = entry
+	}
+}
+
+// ArchiveComparator reads expected and actual as tar, gzip compressed tar or
+// zip archives, which are detected from their content, and compares the
+// names, sizes and SHA-256 hashes of the regular files within them. The
+// ordering, timestamps and other metadata of the entries are ignored, so that
+// build artifacts which are not reproducible byte for byte can be snapshotted.
+// On failure the added, removed and changed files are reported.
+func ArchiveComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		msg = err.Error()
+		return
+	}
+	eManifest, err := readArchiveManifest(eBytes)
+	if err != nil {
+		msg = "failed to read expected archive: " + err.Error()
+		return
+	}
+	aManifest, err := readArchiveManifest(aBytes)
+	if err != nil {
+		msg = "failed to read actual archive: " + err.Error()
+		return
+	}
+	type difference struct {
+		name string
+		msg  string
+	}
+	var diffs []difference
+	for name, e := range eManifest {
+		a, found := aManifest[name]
+		switch {
+		case !found:
+			diffs = append(diffs, difference{name, "removed " + name})
+		case a != e:
+			diffs = append(diffs, difference{name, fmt.Sprintf("changed %v: size %d -> %d, sha256 %.12s -> %.12s", name, e.size, a.size, e.hash, a.hash)})
+		}
+	}
+	for name, a := range aManifest {
+		if _, found := eManifest[name]; !found {
+			diffs = append(diffs, difference{name, fmt.Sprintf("added %v: size %d", name, a.size)})
+		}
+	}
+	ok = len(diffs) == 0
+	if !ok {
+		sort.Slice(diffs, func(i, j int) bool { return diffs[i].name < diffs[j].name })
+		buf := new(strings.Builder)
+		buf.WriteString("archive contents differ:")
+		for _, d := range diffs {
+			buf.WriteString("\n" + d.msg)
+		}
+		msg = buf.String()
+	}
+	return
+}
@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+)
+
+type archiveFile struct {
+	name    string
+	content string
+}
+
+func mkTar(t *testing.T, files ...archiveFile) []byte {
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	if err := tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0750}); err != nil {
+		t.Fatalf("failed to write tar header: %v", err)
+	}
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Mode: 0600, Size: int64(len(f.content)), ModTime: time.Now()}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("failed to write tar header: %v", err)
+		}
+		if _, err := io.WriteString(tw, f.content); err != nil {
+			t.Fatalf("failed to write tar content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("failed to close tar: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func mkTarGz(t *testing.T, files ...archiveFile) []byte {
+	buf := new(bytes.Buffer)
+	gw := gzip.NewWriter(buf)
+	if _, err := gw.Write(mkTar(t, files...)); err != nil {
+		t.Fatalf("failed to write gzip: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func mkZip(t *testing.T, files ...archiveFile) []byte {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, f := range files {
+		w, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatalf("failed to create zip entry: %v", err)
+		}
+		if _, err := io.WriteString(w, f.content); err != nil {
+			t.Fatalf("failed to write zip content: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveComparator(t *testing.T) {
+	a := archiveFile{"dir/a.txt", "hello"}
+	b := archiveFile{"b.txt", "world"}
+	tests := []struct {
+		name     string
+		expected []byte
+		actual   []byte
+		ok       bool
+		msg      string
+	}{
+		{"reordered tar", mkTar(t, a, b), mkTar(t, b, a), true, ""},
+		{"zip and tar", mkZip(t, a, b), mkTarGz(t, b, a), true, ""},
+		{
+			name:     "differences",
+			expected: mkZip(t, a, archiveFile{"c.txt", "removed"}),
+			actual:   mkZip(t, archiveFile{"dir/a.txt", "hello!"}, b),
+			ok:       false,
+			msg: "archive contents differ:\n" +
+				"added b.txt: size 5\n" +
+				"removed c.txt\n" +
+				"changed dir/a.txt: size 5 -> 6, sha256 2cf24dba5fb0 -> ce06092fb948",
+		},
+		{"invalid archive", mkTar(t, a), []byte("not an archive"), false, "failed to read actual archive: unexpected EOF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := ArchiveComparator(bytes.NewReader(tt.expected), bytes.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Errorf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
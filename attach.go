@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"mime"
+)
+
+// An AttachmentSink receives the artifacts of a failed comparison, such as
+// the expected and actual data, so that they can be attached to the test by
+// a reporting tool. name is a file name for the artifact, and mimeType its
+// media type, e.g. "text/plain; charset=utf-8".
+type AttachmentSink func(name string, content []byte, mimeType string)
+
+// WithAttachmentSink calls sink with the artifacts of the comparison when
+// Match fails, to integrate snapshot failures with test reporters which can
+// attach files to a test. The artifacts are the expected and actual data,
+// named <name>.expected<ext> and <name>.actual<ext> after the snapshot file,
+// e.g. "output.expected.txt", and the failure message, named
+// <name>.diff.txt. The expected and actual data are the buffered bytes that
+// were compared, so they are omitted with WithStreaming, or if the comparison
+// failed before they were read.
+func WithAttachmentSink(sink AttachmentSink) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.AttachmentSink = sink })
+}
+
+// attachFailure passes the expected and actual data and the failure message
+// msg of a failed comparison to sink, as for WithAttachmentSink.
+func attachFailure(sink AttachmentSink, po pathOptions, ext string, eBytes, aBytes []byte, msg string) {
+	mimeType := mime.TypeByExtension(ext)
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	name := po.baseName()
+	if eBytes != nil {
+		sink(name+".expected"+ext, eBytes, mimeType)
+	}
+	if aBytes != nil {
+		sink(name+".actual"+ext, aBytes, mimeType)
+	}
+	sink(name+".diff.txt", []byte(msg), "text/plain; charset=utf-8")
+}
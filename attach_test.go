@@ -0,0 +1,33 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithAttachmentSink(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	var attachments []string
+	sink := WithAttachmentSink(func(name string, content []byte, mimeType string) {
+		attachments = append(attachments, name+"|"+string(content)+"|"+mimeType)
+	})
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	if ok, msg := Match(t, strings.NewReader("hello"), sink, dirOpt); !ok || len(attachments) != 0 {
+		t.Errorf("expected no attachments when matching, got %v, %q, %q", ok, msg, attachments)
+	}
+	ok, msg := Match(t, strings.NewReader("world"), sink, dirOpt)
+	expected := []string{
+		"output.expected.txt|hello|text/plain; charset=utf-8",
+		"output.actual.txt|world|text/plain; charset=utf-8",
+		"output.diff.txt|" + msg + "|text/plain; charset=utf-8",
+	}
+	if ok || strings.Join(attachments, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected attachments %q, got %q", expected, attachments)
+	}
+
+	attachments = nil
+	Match(t, strings.NewReader("world"), sink, WithStreaming(), WithComparator(StreamComparator), dirOpt)
+	if len(attachments) != 1 || !strings.HasPrefix(attachments[0], "output.diff.txt|") {
+		t.Errorf("expected only the diff attachment when streaming, got %q", attachments)
+	}
+}
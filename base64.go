@@ -0,0 +1,153 @@
+package snapshot
+
+import (
+	"encoding/base64"
+	"io"
+)
+
+// base64Extension is appended to the file extension of snapshots stored with
+// WithBase64Encoding.
+const base64Extension = ".b64"
+
+// base64LineLength is the length of the lines of base64 encoded snapshots, as
+// used by MIME, which keeps diffs of the snapshot files readable.
+const base64LineLength = 76
+
+// WithBase64Encoding stores the snapshot file base64 encoded, with ".b64"
+// appended to the file extension, e.g. "output.png.b64". The data is decoded
+// when the snapshot is read, so that comparators and the test see the raw
+// bytes. This keeps binary snapshots as text, for repositories that do not
+// permit binary files.
+func WithBase64Encoding() SnapshotOption {
+	return withBase64Encoding{}
+}
+
+type withBase64Encoding struct{}
+
+func (withBase64Encoding) ApplyInputOption(o *GetTestInputOptions) {
+	o.Base64Encoding = true
+}
+
+func (withBase64Encoding) ApplyMatchOption(o *MatchOptions) {
+	o.Base64Encoding = true
+}
+
+// snapshotEncoding describes how the data is encoded in a snapshot file.
+type snapshotEncoding struct {
+	// base64 is true if the data is base64 encoded, as for
+	// WithBase64Encoding.
+	base64 bool
+	// key is the key the data is encrypted with, as for WithEncryption, or
+	// nil if it is not encrypted.
+	key []byte
+}
+
+func (o GetTestInputOptions) encoding() snapshotEncoding {
+	return snapshotEncoding{base64: o.Base64Encoding, key: o.EncryptionKey}
+}
+
+func (o MatchOptions) encoding() snapshotEncoding {
+	return snapshotEncoding{base64: o.Base64Encoding, key: o.EncryptionKey}
+}
+
+// snapshotExtension returns the file extension of the snapshot file for data
+// with the extension ext, which has ".enc" appended if it is encrypted and
+// ".b64" appended if it is base64 encoded.
+func snapshotExtension(ext string, enc snapshotEncoding) string {
+	ext = normaliseExtension(ext)
+	if enc.key != nil {
+		ext += encryptedExtension
+	}
+	if enc.base64 {
+		ext += base64Extension
+	}
+	return ext
+}
+
+// encodeSnapshot returns a writer which writes the data to w, encrypted if
+// enc has a key, then base64 encoded and wrapped at base64LineLength if
+// enc.base64 is true. The writer must be closed to flush the encoded data.
+func encodeSnapshot(w io.Writer, enc snapshotEncoding) io.WriteCloser {
+	var out io.WriteCloser = nopWriteCloser{w}
+	if enc.base64 {
+		lw := &lineWrapWriter{w: w}
+		out = base64Encoder{base64.NewEncoder(base64.StdEncoding, lw), lw}
+	}
+	if enc.key != nil {
+		out = &encryptWriter{w: out, key: enc.key}
+	}
+	return out
+}
+
+// decodeSnapshot returns a reader which decodes the data from r, as encoded
+// by encodeSnapshot.
+func decodeSnapshot(r io.Reader, enc snapshotEncoding) io.Reader {
+	if enc.base64 {
+		r = base64.NewDecoder(base64.StdEncoding, r)
+	}
+	if enc.key != nil {
+		r = &decryptReader{r: r, key: enc.key}
+	}
+	return r
+}
+
+// nopWriteCloser adds a no-op Close method to an io.Writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// base64Encoder flushes the encoder and terminates the final line on Close.
+type base64Encoder struct {
+	io.WriteCloser
+	lw *lineWrapWriter
+}
+
+func (e base64Encoder) Close() error {
+	if err := e.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return e.lw.Close()
+}
+
+// lineWrapWriter writes to w, inserting a newline every base64LineLength
+// bytes.
+type lineWrapWriter struct {
+	w   io.Writer
+	col int
+}
+
+func (lw *lineWrapWriter) Write(p []byte) (n int, err error) {
+	for len(p) > 0 {
+		if lw.col == base64LineLength {
+			if _, err = lw.w.Write([]byte{'\n'}); err != nil {
+				return
+			}
+			lw.col = 0
+		}
+		chunk := p
+		if len(chunk) > base64LineLength-lw.col {
+			chunk = chunk[:base64LineLength-lw.col]
+		}
+		var m int
+		m, err = lw.w.Write(chunk)
+		n += m
+		lw.col += m
+		if err != nil {
+			return
+		}
+		p = p[m:]
+	}
+	return
+}
+
+// Close terminates the final line, if any data was written.
+func (lw *lineWrapWriter) Close() error {
+	if lw.col == 0 {
+		return nil
+	}
+	lw.col = 0
+	_, err := lw.w.Write([]byte{'\n'})
+	return err
+}
@@ -0,0 +1,36 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestBase64Encoding(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	data := bytes.Repeat([]byte{0x00, 0xff, 0x10, 0x80}, 40)
+	input := GetTestInput(t, WithDefaultInputBytes(data), WithBase64Encoding(), dirOpt)
+	if str := readAllUnchecked(input); str != string(data) {
+		t.Errorf("expected %q, got %q", data, str)
+	}
+	if ok, msg := Match(t, bytes.NewReader(data), WithBase64Encoding(), dirOpt); !ok {
+		t.Fatalf("expected newly created snapshot to match: %v", msg)
+	}
+	encoded := base64.StdEncoding.EncodeToString(data)
+	expected := encoded[:76] + "\n" + encoded[76:152] + "\n" + encoded[152:] + "\n"
+	for _, p := range []string{inputP + ".b64", outputP + ".b64"} {
+		if str := readAllUnchecked(openUnchecked(t, p)); str != expected {
+			t.Errorf("unexpected snapshot %v. expected %q, got %q", p, expected, str)
+		}
+	}
+	if str := readAllUnchecked(GetTestInput(t, WithBase64Encoding(), dirOpt)); str != string(data) {
+		t.Errorf("expected existing snapshot %q, got %q", data, str)
+	}
+	if ok, msg := Match(t, bytes.NewReader(data), WithBase64Encoding(), dirOpt); !ok {
+		t.Errorf("expected existing snapshot to match: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader("hello"), WithBase64Encoding(), dirOpt); ok {
+		t.Errorf("expected different data not to match")
+	}
+}
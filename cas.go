@@ -0,0 +1,138 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// casPointerPrefix is the first field of the pointer files written in place
+// of snapshots by WithContentAddressedStore.
+const casPointerPrefix = "content-addressed-snapshot"
+
+// WithContentAddressedStore stores the content of snapshots in dir, in a file
+// named after the SHA-256 hash of the content and the snapshot's file
+// extension, e.g. "<dir>/<hash>.txt". The snapshot file of each test is then a
+// small pointer file which records the hash and extension, so that identical
+// snapshots are only stored once. dir is typically shared between tests, e.g.
+// "testdata/cas".
+func WithContentAddressedStore(dir string) SnapshotOption {
+	return withContentAddressedStore{dir}
+}
+
+type withContentAddressedStore struct {
+	dir string
+}
+
+func (wo withContentAddressedStore) ApplyInputOption(o *GetTestInputOptions) {
+	o.ContentStore = wo.dir
+}
+
+func (wo withContentAddressedStore) ApplyMatchOption(o *MatchOptions) {
+	o.ContentStore = wo.dir
+}
+
+// openSnapshotFile opens the snapshot file at p in s for reading. If dir is
+// not empty, p is a pointer file and the content that it refers to in the
+// content store dir is opened instead.
+func openSnapshotFile(s Store, p, dir string) (io.ReadCloser, error) {
+	if dir == "" {
+		return s.Open(p)
+	}
+	b, err := readStoreFile(s, p)
+	if err != nil {
+		return nil, err
+	}
+	// The extension is the last field, which is empty for snapshots without
+	// an extension, so the pointer is split on single spaces.
+	fields := strings.SplitN(strings.TrimSuffix(string(b), "\n"), " ", 3)
+	if len(fields) != 3 || fields[0] != casPointerPrefix || !strings.HasPrefix(fields[1], "sha256:") {
+		return nil, fmt.Errorf("invalid content addressed snapshot pointer %v", p)
+	}
+	file, err := s.Open(filepath.Join(dir, strings.TrimPrefix(fields[1], "sha256:")+fields[2]))
+	if errors.Is(err, fs.ErrNotExist) {
+		// The error does not wrap err, so that the snapshot is not mistaken
+		// for a missing snapshot and recreated.
+		err = fmt.Errorf("content addressed snapshot for pointer %v is missing from the store: %v", p, err)
+	}
+	return file, err
+}
+
+// readStoreFile reads the file at p in s.
+func readStoreFile(s Store, p string) ([]byte, error) {
+	r, err := s.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// internSnapshot moves the content of the snapshot file at p in s, with
+// extension ext, into the content store dir, unless identical content is
+// already stored, and replaces p with a pointer to it.
+func internSnapshot(s Store, p, dir, ext string) error {
+	b, err := readStoreFile(s, p)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(b)
+	hash := hex.EncodeToString(sum[:])
+	stored := filepath.Join(dir, hash+ext)
+	if !s.Exists(stored) {
+		if _, ok := s.(osStore); ok {
+			err = writeFileAtomic(dir, hash, stored, b)
+		} else {
+			// Other stores, such as object storage, are expected to
+			// replace files atomically.
+			err = writeStoreFile(s, stored, b)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	pointer := fmt.Sprintf("%v sha256:%v %v\n", casPointerPrefix, hash, ext)
+	return writeStoreFile(s, p, []byte(pointer))
+}
+
+// writeFileAtomic writes b to the file at p in dir on the local filesystem.
+// The data is written to a temporary file prefixed by name first, so that
+// concurrent tests never read partially written content.
+func writeFileAtomic(dir, name, p string, b []byte) error {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// internSnapshots interns the newly created snapshot file at p, along with its
+// latest copy if it is versioned, as for internSnapshot.
+func internSnapshots(p string, po pathOptions, dir string) error {
+	if err := internSnapshot(po.store, p, dir, po.ext); err != nil {
+		return err
+	}
+	if po.version != "" {
+		return internSnapshot(po.store, po.latestPath(p), dir, po.ext)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentAddressedStore(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	store := filepath.Join(t.TempDir(), "cas")
+	storeOpt := WithContentAddressedStore(store)
+	sum := sha256.Sum256([]byte("hello"))
+	hash := hex.EncodeToString(sum[:])
+
+	if str := readAllUnchecked(GetTestInput(t, WithDefaultInput("hello"), storeOpt, dirOpt)); str != "hello" {
+		t.Errorf("expected %q, got %q", "hello", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), storeOpt, dirOpt); !ok {
+		t.Fatalf("expected newly created snapshot to match: %v", msg)
+	}
+	pointer := "content-addressed-snapshot sha256:" + hash + " .txt\n"
+	for _, p := range []string{inputP, outputP} {
+		if str := readAllUnchecked(openUnchecked(t, p)); str != pointer {
+			t.Errorf("unexpected pointer file %v. expected %q, got %q", p, pointer, str)
+		}
+	}
+	entries, err := os.ReadDir(store)
+	if err != nil || len(entries) != 1 || entries[0].Name() != hash+".txt" {
+		t.Fatalf("expected a single stored snapshot, got %v, %v", entries, err)
+	}
+
+	if str := readAllUnchecked(GetTestInput(t, storeOpt, dirOpt)); str != "hello" {
+		t.Errorf("expected existing snapshot %q, got %q", "hello", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), storeOpt, dirOpt); !ok {
+		t.Errorf("expected existing snapshot to match: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader("world"), storeOpt, dirOpt); ok {
+		t.Errorf("expected different data not to match")
+	}
+
+	if err := os.Remove(filepath.Join(store, hash+".txt")); err != nil {
+		t.Fatalf("failed to remove stored snapshot: %v", err)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), storeOpt, dirOpt); ok || !strings.Contains(msg, "is missing from the store") {
+		t.Errorf("expected missing stored snapshot to fail, got %v, %q", ok, msg)
+	}
+}
+
+func TestContentAddressedStoreNoExtension(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	store := filepath.Join(t.TempDir(), "cas")
+	opts := []MatchOption{WithContentAddressedStore(store), WithSnapshotFileExtension(""), dirOpt}
+	sum := sha256.Sum256([]byte("hello"))
+	hash := hex.EncodeToString(sum[:])
+
+	if ok, msg := Match(t, strings.NewReader("hello"), opts...); !ok {
+		t.Fatalf("expected newly created snapshot to match: %v", msg)
+	}
+	p := strings.TrimSuffix(outputP, ".txt")
+	pointer := "content-addressed-snapshot sha256:" + hash + " \n"
+	if str := readAllUnchecked(openUnchecked(t, p)); str != pointer {
+		t.Errorf("unexpected pointer file %v. expected %q, got %q", p, pointer, str)
+	}
+	if str := readAllUnchecked(openUnchecked(t, filepath.Join(store, hash))); str != "hello" {
+		t.Errorf("expected stored snapshot %q, got %q", "hello", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), opts...); !ok {
+		t.Errorf("expected existing snapshot to match: %v", msg)
+	}
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sanitizeCaseName replaces any character of name which is not an ASCII
+// letter, digit, "-", "." or "_" with "_", so that it is safe to use in a
+// file name. Spaces are replaced as by t.Run.
+func sanitizeCaseName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// MatchCase behaves as Match, but appends caseName to the snapshot name, as
+// <name>_<caseName>, so that each case of a table-driven test has its own
+// snapshot without using t.Run. caseName is sanitised for use in a file name,
+// by replacing any character other than ASCII letters, digits, "-", "." and
+// "_" with "_". For example:
+//
+//	for _, tc := range cases {
+//		ok, msg := snapshot.MatchCase(t, tc.name, render(tc.input))
+//		...
+//	}
+func MatchCase(t *testing.T, caseName string, actual io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	optFns = append(optFns, MatchOptionFunc(func(o *MatchOptions) {
+		o.SnapshotName += "_" + sanitizeCaseName(caseName)
+	}))
+	return match(t, 1, actual, optFns...)
+}
+
+// MatchAll matches each of the named outputs against its own output snapshot,
+// as Match, for tests which produce several artifacts. The snapshot of each
+// output is named after its key, sanitised as for MatchCase, e.g. the key
+// "report" with WithSnapshotFileExtension(".html") is stored as
+// "report.html". The outputs are matched in the order of their keys, and
+// every output is matched even if an earlier one fails, so that all of the
+// mismatches are reported at once in msg, one per line, prefixed by the key.
+// Keys which sanitise to the same snapshot name are reported as a failure.
+func MatchAll(t *testing.T, outputs map[string]io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	keys := make([]string, 0, len(outputs))
+	for key := range outputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	names := make(map[string]string, len(keys))
+	var failures []string
+	for _, key := range keys {
+		name := sanitizeCaseName(key)
+		if other, found := names[name]; found {
+			failures = append(failures, fmt.Sprintf("%v: snapshot name %q is also used by %q", key, name, other))
+			continue
+		}
+		names[name] = key
+		keyOk, keyMsg := match(t, 1, outputs[key], append(optFns, WithSnapshotName(name))...)
+		if !keyOk {
+			failures = append(failures, key+": "+keyMsg)
+		}
+	}
+	ok = len(failures) == 0
+	msg = strings.Join(failures, "\n")
+	return
+}
@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeCaseName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"simple", "simple"},
+		{"with spaces", "with_spaces"},
+		{"a/b\\c", "a_b_c"},
+		{"v1.2-rc_1", "v1.2-rc_1"},
+		{"héllo", "h_llo"},
+		{"", "_"},
+	}
+	for _, tt := range tests {
+		if str := sanitizeCaseName(tt.name); str != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, str)
+		}
+	}
+}
+
+func TestMatchCase(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	dir := filepath.Dir(outputP)
+	for _, tc := range []struct{ name, output string }{
+		{"first case", "hello"},
+		{"second/case", "world"},
+	} {
+		if ok, msg := MatchCase(t, tc.name, strings.NewReader(tc.output), dirOpt); !ok {
+			t.Fatalf("%v: expected match to succeed: %v", tc.name, msg)
+		}
+	}
+	for name, expected := range map[string]string{
+		"output_first_case.txt":  "hello",
+		"output_second_case.txt": "world",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read %v: %v", name, err)
+		}
+		if string(b) != expected {
+			t.Errorf("unexpected %v contents. expected %q, got %q", name, expected, string(b))
+		}
+	}
+	if ok, _ := MatchCase(t, "first case", strings.NewReader("world"), dirOpt); ok {
+		t.Errorf("expected changed case output not to match")
+	}
+}
+
+func TestMatchAll(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	outputs := func(a, b string) map[string]io.Reader {
+		return map[string]io.Reader{"a.txt": strings.NewReader(a), "b/c": strings.NewReader(b)}
+	}
+	if ok, msg := MatchAll(t, outputs("a", "b"), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	dir := filepath.Dir(outputP)
+	for name, expected := range map[string]string{"a.txt.txt": "a", "b_c.txt": "b"} {
+		if str := readAllUnchecked(openUnchecked(t, filepath.Join(dir, name))); str != expected {
+			t.Errorf("unexpected snapshot %v. expected %q, got %q", name, expected, str)
+		}
+	}
+	expectedMsg := "a.txt: expected \"a\", got \"x\"\nb/c: expected \"b\", got \"y\""
+	if ok, msg := MatchAll(t, outputs("x", "y"), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+	collision := map[string]io.Reader{"b/c": strings.NewReader("b"), "b_c": strings.NewReader("b")}
+	expectedMsg = `b_c: snapshot name "b_c" is also used by "b/c"`
+	if ok, msg := MatchAll(t, collision, dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
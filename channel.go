@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"testing"
+	"time"
+)
+
+// drainChannel receives values from ch until it is closed or, if timeout is
+// positive, until timeout elapses. It returns the values received, which is
+// non-nil so that no values are serialised as an empty JSON array, and
+// whether the channel was closed.
+func drainChannel[T any](ch <-chan T, timeout time.Duration) (values []T, closed bool) {
+	values = []T{}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return values, true
+			}
+			values = append(values, v)
+		case <-expired:
+			return values, false
+		}
+	}
+}
+
+// MatchChannel drains ch, receiving every value until it is closed, and
+// matches the values received, in order, as a JSON array as MatchJSONAs, so
+// that everything a producer emits can be snapshotted without collecting it
+// by hand. With WithDrainTimeout, draining stops after the timeout, even if
+// the channel is still open, and the values received until then are matched.
+// The JSON encoding and comparison may be overridden by optFns, as for
+// MatchJSONAs. For example:
+//
+//	events := make(chan Event)
+//	go produce(events)
+//	ok, msg := snapshot.MatchChannel(t, events, snapshot.WithDrainTimeout(5*time.Second))
+func MatchChannel[T any](t *testing.T, ch <-chan T, optFns ...MatchOption) (ok bool, msg string) {
+	opts := newMatchOptions(optFns)
+	values, closed := drainChannel(ch, opts.DrainTimeout)
+	if !closed {
+		resolveLogger(t, opts.Logger)("channel was not closed within %v, matching the %d values received", opts.DrainTimeout, len(values))
+	}
+	return matchJSONAs(t, 2, values, optFns...)
+}
+
+// WithDrainTimeout stops MatchChannel draining the channel after d, if it has
+// not been closed, so that a channel which is never closed does not hang the
+// test.
+func WithDrainTimeout(d time.Duration) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.DrainTimeout = d })
+}
@@ -0,0 +1,53 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchChannel(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	produce := func(values ...int) <-chan int {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for _, v := range values {
+				ch <- v
+			}
+		}()
+		return ch
+	}
+	if ok, msg := MatchChannel(t, produce(1, 2, 3), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	jsonP := strings.TrimSuffix(outputP, ".txt") + ".json"
+	if str := readAllUnchecked(openUnchecked(t, jsonP)); str != "[\n  1,\n  2,\n  3\n]\n" {
+		t.Fatalf("unexpected snapshot contents: %q", str)
+	}
+	if ok, _ := MatchChannel(t, produce(1, 3, 2), dirOpt); ok {
+		t.Errorf("expected values in a different order to fail")
+	}
+}
+
+func TestMatchChannelDrainTimeout(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	ch := make(chan string, 2)
+	ch <- "a"
+	ch <- "b"
+	if ok, msg := MatchChannel(t, ch, dirOpt, WithDrainTimeout(10*time.Millisecond)); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	empty := make(chan string)
+	if ok, _ := MatchChannel(t, empty, dirOpt, WithDrainTimeout(10*time.Millisecond)); ok {
+		t.Errorf("expected no values to fail")
+	}
+}
+
+func TestDrainChannelEmpty(t *testing.T) {
+	ch := make(chan int)
+	close(ch)
+	if values, closed := drainChannel(ch, 0); values == nil || len(values) != 0 || !closed {
+		t.Errorf("expected an empty non-nil slice from a closed channel, got %#v, %v", values, closed)
+	}
+}
@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// checksumSuffix is appended to the path of a snapshot file to name its
+// checksum sidecar file.
+const checksumSuffix = ".sha256"
+
+// checksumPath returns the path of the checksum sidecar file of the snapshot
+// file at p.
+func checksumPath(p string) string {
+	return p + checksumSuffix
+}
+
+// WithChecksum records the SHA-256 checksum of the snapshot file in the
+// sidecar file <snapshot>.sha256, e.g. "output.txt.sha256", when the snapshot
+// is created, and verifies it whenever the snapshot is read, so that a
+// snapshot corrupted or edited by accident, e.g. by a bad merge, fails the
+// test rather than silently changing what is expected. The sidecar is in the
+// format of sha256sum, so it can also be checked with `sha256sum -c`. The
+// checksum is recomputed whenever the snapshot is legitimately recreated,
+// e.g. by Generate, -update-snapshots=all or AcceptSnapshots. A snapshot
+// without a sidecar also fails.
+func WithChecksum() SnapshotOption {
+	return withChecksum{}
+}
+
+type withChecksum struct{}
+
+func (withChecksum) ApplyInputOption(o *GetTestInputOptions) {
+	o.Checksum = true
+}
+
+func (withChecksum) ApplyMatchOption(o *MatchOptions) {
+	o.Checksum = true
+}
+
+// formatChecksum returns the content of the checksum sidecar file of the
+// snapshot file at p with content b.
+func formatChecksum(p string, b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]) + "  " + filepath.Base(p) + "\n"
+}
+
+// writeChecksum writes the checksum sidecar file of the snapshot file at p in
+// s.
+func writeChecksum(s Store, p string) error {
+	b, err := readStoreFile(s, p)
+	if err != nil {
+		return err
+	}
+	return writeStoreFile(s, checksumPath(p), []byte(formatChecksum(p, b)))
+}
+
+// verifyChecksum returns an error if the checksum of the snapshot file at p
+// in s does not match that recorded in its sidecar file, or the sidecar file
+// does not exist.
+func verifyChecksum(s Store, p string) error {
+	sidecar := checksumPath(p)
+	recorded, err := readStoreFile(s, sidecar)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("snapshot checksum file %v is missing, recreate the snapshot to record it", sidecar)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot checksum file %v: %v", sidecar, err.Error())
+	}
+	b, err := readStoreFile(s, p)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot file %v to verify its checksum: %v", p, err.Error())
+	}
+	expected, _, _ := strings.Cut(strings.TrimSpace(string(recorded)), " ")
+	actual, _, _ := strings.Cut(formatChecksum(p, b), " ")
+	if expected != actual {
+		return fmt.Errorf("snapshot file %v corrupted or modified outside of update: its SHA-256 checksum is %v, but %v is recorded in %v",
+			p, actual, expected, sidecar)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithChecksum(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	if ok, msg := Match(t, strings.NewReader("hello"), WithChecksum(), dirOpt); !ok {
+		t.Fatalf("expected created snapshot to match: %v", msg)
+	}
+	expected := "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824  output.txt\n"
+	if str := readAllUnchecked(openUnchecked(t, outputP+".sha256")); str != expected {
+		t.Errorf("expected checksum sidecar %q, got %q", expected, str)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithChecksum(), dirOpt); !ok {
+		t.Errorf("expected verified snapshot to match: %v", msg)
+	}
+
+	writeSnapshotFile(t, outputP, "hellp")
+	ok, msg := Match(t, strings.NewReader("hellp"), WithChecksum(), dirOpt)
+	if ok || !strings.Contains(msg, "snapshot file "+outputP+" corrupted or modified outside of update") {
+		t.Errorf("expected modified snapshot to fail, got %v, %q", ok, msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("hellp"), dirOpt); !ok {
+		t.Errorf("expected the checksum to be ignored without WithChecksum: %v", msg)
+	}
+
+	Generate(t, strings.NewReader("world"), WithChecksum(), dirOpt)
+	if ok, msg := Match(t, strings.NewReader("world"), WithChecksum(), dirOpt); !ok {
+		t.Errorf("expected the checksum to be recomputed on update: %v", msg)
+	}
+
+	if err := os.Remove(outputP + ".sha256"); err != nil {
+		t.Fatal(err)
+	}
+	ok, msg = Match(t, strings.NewReader("world"), WithChecksum(), dirOpt)
+	if ok || !strings.Contains(msg, "snapshot checksum file "+outputP+".sha256 is missing") {
+		t.Errorf("expected missing checksum to fail, got %v, %q", ok, msg)
+	}
+
+	input := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("input")), WithChecksum(), dirOpt)
+	if str := readAllUnchecked(input); str != "input" {
+		t.Errorf("expected %q, got %q", "input", str)
+	}
+	if str := readAllUnchecked(GetTestInput(t, WithChecksum(), dirOpt)); str != "input" {
+		t.Errorf("expected verified input %q, got %q", "input", str)
+	}
+	if _, err := os.Stat(inputP + ".sha256"); err != nil {
+		t.Errorf("expected input checksum sidecar: %v", err)
+	}
+}
+
+func TestAcceptSnapshotsChecksum(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	Generate(t, strings.NewReader("hello"), WithChecksum(), dirOpt)
+	if ok, _ := Match(t, strings.NewReader("world"), WithChecksum(), WithWriteActualOnFailure(), dirOpt); ok {
+		t.Fatalf("expected changed output to fail")
+	}
+	if _, err := AcceptSnapshots(filepath.Dir(outputP)); err != nil {
+		t.Fatal(err)
+	}
+	if ok, msg := Match(t, strings.NewReader("world"), WithChecksum(), dirOpt); !ok {
+		t.Errorf("expected accepted snapshot to have a recomputed checksum: %v", msg)
+	}
+}
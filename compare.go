@@ -0,0 +1,128 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Compare compares expected and actual using the ReaderNormaliser and
+// Comparator resolved from optFns, exactly as Match would, but without reading
+// or writing any snapshot files. This is useful to compare two in-memory
+// outputs, or to test comparators.
+func Compare(expected, actual io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	return compare(expected, actual, newMatchOptions(optFns))
+}
+
+// compare applies the ReaderNormaliser of opts to expected and actual before
+// comparing them with the StructuredComparator of opts, if set, or its
+// Comparator. If CaseInsensitive is set, both are also lowercased, after the
+// ReaderNormaliser.
+func compare(expected, actual io.Reader, opts MatchOptions) (ok bool, msg string) {
+	ok, msg, _ = compareDifferences(expected, actual, opts)
+	return
+}
+
+// compareDifferences implements compare, additionally returning the
+// differences found by the StructuredComparator of opts, if set.
+func compareDifferences(expected, actual io.Reader, opts MatchOptions) (ok bool, msg string, diffs []Difference) {
+	normalise := opts.ReaderNormaliser
+	if opts.CaseInsensitive {
+		normalise = func(r io.Reader) io.Reader { return LowercaseNormaliser(opts.ReaderNormaliser(r)) }
+	}
+	if opts.StructuredComparator != nil {
+		diffs = opts.StructuredComparator(normalise(expected), normalise(actual))
+		ok, msg = differencesResult(diffs)
+		return
+	}
+	ok, msg = opts.Comparator(
+		normalise(expected),
+		normalise(actual),
+	)
+	return
+}
+
+// runComparators reads expected and actual once and runs each of cmps against
+// its own copy of the data, returning the failure messages indexed by
+// comparator. A comparator which passes has an empty message, and passed
+// counts the comparators which passed.
+func runComparators(expected, actual io.Reader, cmps []Comparator) (passed int, msgs []string, err error) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		return
+	}
+	msgs = make([]string, len(cmps))
+	for i, cmp := range cmps {
+		ok, msg := cmp(bytes.NewReader(eBytes), bytes.NewReader(aBytes))
+		if ok {
+			passed++
+			continue
+		}
+		if msg == "" {
+			msg = "comparison failed"
+		}
+		msgs[i] = msg
+	}
+	return
+}
+
+// formatComparatorFailures formats the non-empty failure messages from
+// runComparators, one per line prefixed with the comparator's position.
+func formatComparatorFailures(summary string, msgs []string) string {
+	buf := new(strings.Builder)
+	buf.WriteString(summary)
+	for i, msg := range msgs {
+		if msg != "" {
+			fmt.Fprintf(buf, "\ncomparator %d of %d: %v", i+1, len(msgs), msg)
+		}
+	}
+	return buf.String()
+}
+
+// AllComparators creates a Comparator which passes only if every one of cmps
+// passes. Each comparator is given its own copy of the expected and actual
+// data. On failure the messages of all failing comparators are reported.
+func AllComparators(cmps ...Comparator) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		passed, msgs, err := runComparators(expected, actual, cmps)
+		if err != nil {
+			msg = err.Error()
+			return
+		}
+		ok = passed == len(cmps)
+		if !ok {
+			msg = formatComparatorFailures(fmt.Sprintf("%d of %d comparators failed:", len(cmps)-passed, len(cmps)), msgs)
+		}
+		return
+	}
+}
+
+// AnyComparator creates a Comparator which passes if at least one of cmps
+// passes. Each comparator is given its own copy of the expected and actual
+// data. On failure the messages of all comparators are reported.
+func AnyComparator(cmps ...Comparator) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		passed, msgs, err := runComparators(expected, actual, cmps)
+		if err != nil {
+			msg = err.Error()
+			return
+		}
+		ok = passed > 0
+		if !ok {
+			msg = formatComparatorFailures(fmt.Sprintf("none of %d comparators passed:", len(cmps)), msgs)
+		}
+		return
+	}
+}
+
+// LowercaseNormaliser is a ReaderNormaliser that converts the data to
+// lowercase, as for WithCaseInsensitive. If the data cannot be read, the
+// returned io.Reader fails with the error.
+func LowercaseNormaliser(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errReader{err}
+	}
+	return bytes.NewReader(bytes.ToLower(b))
+}
@@ -0,0 +1,123 @@
+package snapshot
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	upper := func(r io.Reader) io.Reader {
+		return strings.NewReader(strings.ToUpper(readAllUnchecked(r)))
+	}
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		opts     []MatchOption
+		ok       bool
+		msg      string
+	}{
+		{
+			name:     "default comparator",
+			expected: "hello",
+			actual:   "world",
+			msg:      `expected "hello", got "world"`,
+		},
+		{
+			name:     "normaliser applied to both",
+			expected: "hello",
+			actual:   "HELLO",
+			opts:     []MatchOption{WithReaderNormaliser(upper)},
+			ok:       true,
+		},
+		{
+			name:     "custom comparator",
+			expected: "hello",
+			actual:   "world",
+			opts: []MatchOption{WithComparator(func(expected, actual io.Reader) (bool, string) {
+				return true, ""
+			})},
+			ok: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := Compare(strings.NewReader(tt.expected), strings.NewReader(tt.actual), tt.opts...)
+			if ok != tt.ok || msg != tt.msg {
+				t.Fatalf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
+
+func TestComparatorCombinators(t *testing.T) {
+	pass := func(expected, actual io.Reader) (bool, string) {
+		return readAllUnchecked(expected) != "" && readAllUnchecked(actual) != "", ""
+	}
+	fail := func(expected, actual io.Reader) (bool, string) {
+		return false, ""
+	}
+	tests := []struct {
+		name       string
+		comparator Comparator
+		ok         bool
+		msg        string
+	}{
+		{
+			name:       "all pass",
+			comparator: AllComparators(pass, SetComparator),
+			ok:         true,
+		},
+		{
+			name:       "all with failure",
+			comparator: AllComparators(pass, StringComparator, SetComparator),
+			msg:        "1 of 3 comparators failed:\ncomparator 2 of 3: expected \"a\\nb\", got \"b\\na\"",
+		},
+		{
+			name:       "any with one passing",
+			comparator: AnyComparator(StringComparator, SetComparator),
+			ok:         true,
+		},
+		{
+			name:       "any with none passing",
+			comparator: AnyComparator(StringComparator, fail),
+			msg:        "none of 2 comparators passed:\ncomparator 1 of 2: expected \"a\\nb\", got \"b\\na\"\ncomparator 2 of 2: comparison failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := tt.comparator(strings.NewReader("a\nb"), strings.NewReader("b\na"))
+			if ok != tt.ok || msg != tt.msg {
+				t.Fatalf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
+
+func TestWithCaseInsensitive(t *testing.T) {
+	trim := WithReaderNormaliser(func(r io.Reader) io.Reader {
+		return strings.NewReader(strings.TrimSpace(readAllUnchecked(r)))
+	})
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		optFns   []MatchOption
+		ok       bool
+	}{
+		{"case sensitive by default", "DEADBEEF", "deadbeef", nil, false},
+		{"case insensitive", "DEADBEEF", "deadbeef", []MatchOption{WithCaseInsensitive()}, true},
+		{"composed after normaliser", "DEADBEEF\n", "deadbeef", []MatchOption{WithCaseInsensitive(), trim}, true},
+		{"composed before normaliser", "DEADBEEF\n", "deadbeef", []MatchOption{trim, WithCaseInsensitive()}, true},
+		{"other differences", "DEADBEEF", "deadbeee", []MatchOption{WithCaseInsensitive()}, false},
+	}
+	for _, tt := range tests {
+		ok, msg := Compare(strings.NewReader(tt.expected), strings.NewReader(tt.actual), tt.optFns...)
+		if ok != tt.ok {
+			t.Errorf("%v: expected %v, got %v, %q", tt.name, tt.ok, ok, msg)
+		}
+	}
+}
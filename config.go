@@ -0,0 +1,184 @@
+package snapshot
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// configFileName is the name of the project config file, which is read from
+// the root of the Go module.
+const configFileName = ".snapshotrc"
+
+// configComparators are the comparators which may be named by the comparator
+// key of the project config file.
+var configComparators = map[string]Comparator{
+	"string":      StringComparator,
+	"diff":        StringDiffComparator,
+	"inline-diff": InlineDiffComparator,
+	"github-diff": GitHubDiffComparator,
+	"position":    PositionComparator,
+	"hexdump":     HexDumpComparator,
+	"set":         SetComparator,
+	"json":        JSONComparator,
+	"jsonl":       JSONLComparator,
+	"stream":      StreamComparator,
+}
+
+// snapshotConfig holds the defaults set by the project config file.
+type snapshotConfig struct {
+	// snapshotDir is the default ModuleRelativeSnapshotDir.
+	snapshotDir string
+	// comparator is the default Comparator for file extensions without a
+	// comparator registered with SetComparatorForExtension.
+	comparator Comparator
+	// update is the default mode of the -update-snapshots flag.
+	update string
+	// noAutoCreate is the default NoAutoCreate, in addition to
+	// DefaultNoAutoCreate.
+	noAutoCreate bool
+}
+
+// projectConfig is the project config of the working directory, which is
+// loaded once by loadProjectConfig.
+var (
+	projectConfigOnce sync.Once
+	projectConfig     snapshotConfig
+	projectConfigErr  error
+)
+
+// loadProjectConfig returns the project config of the working directory,
+// which is loaded on first use by loadConfig.
+func loadProjectConfig() (snapshotConfig, error) {
+	projectConfigOnce.Do(func() {
+		wd, err := os.Getwd()
+		if err != nil {
+			projectConfigErr = fmt.Errorf("failed to determine the working directory: %w", err)
+			return
+		}
+		projectConfig, projectConfigErr = loadConfig(wd)
+	})
+	return projectConfig, projectConfigErr
+}
+
+// configDefaults returns the project config, or the zero config if it cannot
+// be loaded, which is reported by resolveSnapshotFilePath instead.
+func configDefaults() snapshotConfig {
+	cfg, _ := loadProjectConfig()
+	return cfg
+}
+
+// loadConfig reads the config file from the root of the Go module of dir. A
+// missing config file, or module, is not an error, and results in the zero
+// config.
+func loadConfig(dir string) (cfg snapshotConfig, err error) {
+	root, err := findModuleRoot(dir)
+	if errors.Is(err, errNoModuleRoot) {
+		return cfg, nil
+	} else if err != nil {
+		return
+	}
+	p := filepath.Join(root, configFileName)
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return
+	}
+	defer f.Close()
+	return parseConfig(f, p)
+}
+
+// parseConfig parses the config file read from r, which is named name in
+// errors. Each line is a "key = value" pair, in which the value may be
+// double quoted, and empty lines and lines starting with "#" are ignored, so
+// that the file is also valid TOML.
+func parseConfig(r io.Reader, name string) (cfg snapshotConfig, err error) {
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(text, "=")
+		if !found {
+			return cfg, fmt.Errorf("%v:%d: expected key = value, got %q", name, line, text)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			if value, err = strconv.Unquote(value); err != nil {
+				return cfg, fmt.Errorf("%v:%d: invalid quoted value for %v: %w", name, line, key, err)
+			}
+		}
+		if err = cfg.set(key, value); err != nil {
+			return cfg, fmt.Errorf("%v:%d: %w", name, line, err)
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// set sets the config key to value.
+func (cfg *snapshotConfig) set(key, value string) error {
+	switch key {
+	case "snapshot_dir":
+		cfg.snapshotDir = value
+	case "comparator":
+		cmp, ok := configComparators[value]
+		if !ok {
+			return fmt.Errorf("unknown comparator %q, expected one of %v", value, strings.Join(configComparatorNames(), ", "))
+		}
+		cfg.comparator = cmp
+	case "update":
+		switch value {
+		case updateOff, updateNew, updateAll:
+			cfg.update = value
+		default:
+			return fmt.Errorf("invalid update mode %q, expected off, new or all", value)
+		}
+	case "no_auto_create":
+		switch value {
+		case "true", "false":
+			cfg.noAutoCreate = value == "true"
+		case "ci":
+			// CI is set by most CI providers, such as GitHub Actions and
+			// GitLab CI.
+			cfg.noAutoCreate = os.Getenv("CI") != ""
+		default:
+			return fmt.Errorf("invalid no_auto_create value %q, expected true, false or ci", value)
+		}
+	default:
+		return fmt.Errorf("unknown key %q", key)
+	}
+	return nil
+}
+
+// configComparatorNames returns the sorted names of configComparators.
+func configComparatorNames() []string {
+	names := make([]string, 0, len(configComparators))
+	for name := range configComparators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// updateFlagSet reports whether the -update-snapshots flag was set, so that
+// it takes precedence over the update mode of the project config.
+func updateFlagSet() (set bool) {
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "update-snapshots" {
+			set = true
+		}
+	})
+	return
+}
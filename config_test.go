@@ -0,0 +1,136 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setProjectConfig replaces the project config for the duration of the test.
+func setProjectConfig(t *testing.T, cfg snapshotConfig) {
+	t.Helper()
+	_, _ = loadProjectConfig()
+	old, oldErr := projectConfig, projectConfigErr
+	projectConfig, projectConfigErr = cfg, nil
+	t.Cleanup(func() { projectConfig, projectConfigErr = old, oldErr })
+}
+
+func TestParseConfig(t *testing.T) {
+	t.Setenv("CI", "true")
+	cfg, err := parseConfig(strings.NewReader(`
+# defaults for the module
+snapshot_dir = "testdata/__snapshots__"
+comparator = diff
+update = "new"
+no_auto_create = ci
+`), configFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.snapshotDir != "testdata/__snapshots__" || cfg.comparator == nil || cfg.update != updateNew || !cfg.noAutoCreate {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"snapshot_dir", `.snapshotrc:1: expected key = value, got "snapshot_dir"`},
+		{"colour = red", `.snapshotrc:1: unknown key "colour"`},
+		{"\ncomparator = fuzzy", `.snapshotrc:2: unknown comparator "fuzzy", expected one of diff, github-diff, hexdump, inline-diff, json, jsonl, position, set, stream, string`},
+		{"update = some", `.snapshotrc:1: invalid update mode "some", expected off, new or all`},
+		{"no_auto_create = yes", `.snapshotrc:1: invalid no_auto_create value "yes", expected true, false or ci`},
+		{`snapshot_dir = "open`, `.snapshotrc:1: invalid quoted value for snapshot_dir: invalid syntax`},
+	}
+	for _, tt := range tests {
+		if _, err := parseConfig(strings.NewReader(tt.input), configFileName); err == nil || err.Error() != tt.expected {
+			t.Errorf("expected %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	pkg := filepath.Join(root, "pkg")
+	if err := os.MkdirAll(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := loadConfig(pkg); err != nil || cfg.update != "" || cfg.comparator != nil {
+		t.Errorf("expected a missing config file to give the zero config, got %+v, %v", cfg, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, configFileName), []byte("update = all\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := loadConfig(pkg); err != nil || cfg.update != updateAll {
+		t.Errorf("expected the config of the module root, got %+v, %v", cfg, err)
+	}
+}
+
+func TestProjectConfigDefaults(t *testing.T) {
+	setProjectConfig(t, snapshotConfig{snapshotDir: "testdata/snaps", comparator: SetComparator, noAutoCreate: true})
+	opts := newMatchOptions(nil)
+	if opts.ModuleRelativeSnapshotDir != "testdata/snaps" || !opts.NoAutoCreate {
+		t.Errorf("expected config defaults, got %+v", opts)
+	}
+	if ok, msg := opts.Comparator(strings.NewReader("a\nb\n"), strings.NewReader("b\na\n")); !ok {
+		t.Errorf("expected the config comparator: %v", msg)
+	}
+	dir := t.TempDir()
+	opts = newMatchOptions([]MatchOption{WithSnapshotDir(dir), WithComparator(StringComparator)})
+	if opts.ModuleRelativeSnapshotDir != "" || opts.SnapshotDir != dir {
+		t.Errorf("expected WithSnapshotDir to override the config, got %+v", opts)
+	}
+	if ok, _ := opts.Comparator(strings.NewReader("a\nb\n"), strings.NewReader("b\na\n")); ok {
+		t.Errorf("expected WithComparator to override the config")
+	}
+	if inputOpts := newGetTestInputOptions(nil); inputOpts.ModuleRelativeSnapshotDir != "testdata/snaps" || !inputOpts.NoAutoCreate {
+		t.Errorf("expected config defaults, got %+v", inputOpts)
+	}
+}
+
+func TestProjectConfigUpdateMode(t *testing.T) {
+	setProjectConfig(t, snapshotConfig{update: updateNew})
+	if !updateFlagSet() {
+		if mode := snapshotUpdateMode(t); mode != updateNew {
+			t.Errorf("expected the config update mode, got %v", mode)
+		}
+	}
+	setUpdateSnapshots(t, updateAll)
+	if mode := snapshotUpdateMode(t); mode != updateAll {
+		t.Errorf("expected the flag to override the config, got %v", mode)
+	}
+}
+
+func TestProjectConfigLayoutOptions(t *testing.T) {
+	setProjectConfig(t, snapshotConfig{snapshotDir: "testdata/snaps"})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		opt      SnapshotOption
+		expected string
+	}{
+		{"golden", WithGoldenConvention(), filepath.Join(wd, "testdata", "TestProjectConfigLayoutOptions", "golden.golden")},
+		{"flat", WithFlatLayout(), filepath.Join(wd, "config_test.TestProjectConfigLayoutOptions_flat.output.txt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			if ok, msg := Match(t, strings.NewReader("hello"), tt.opt, WithStore(store)); !ok {
+				t.Fatalf("expected match to succeed: %v", msg)
+			}
+			if paths := store.paths(); len(paths) != 1 || paths[0] != tt.expected {
+				t.Errorf("expected the layout to override the config snapshot dir: expected %v, got %v", tt.expected, paths)
+			}
+			if inputOpts := newGetTestInputOptions([]GetTestInputOption{tt.opt}); inputOpts.ModuleRelativeSnapshotDir != "" {
+				t.Errorf("expected the layout to override the config snapshot dir of GetTestInput, got %q", inputOpts.ModuleRelativeSnapshotDir)
+			}
+		})
+	}
+}
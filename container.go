@@ -0,0 +1,186 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// The .snap container format holds the metadata and the gzip compressed
+// payload of a snapshot in a single file. It consists of a text header
+// followed by the payload:
+//
+//	snap/1
+//	version: 1.2.0
+//	created: 2024-01-02T03:04:05Z
+//	content-type: application/json
+//
+//	<gzip compressed payload>
+//
+// The first line is the format identifier, snapContainerMagic. It is followed
+// by "key: value" lines, terminated by an empty line. The version, created and
+// content-type keys hold the fields of SnapMetadata, and each is omitted if
+// the field is empty. created is formatted as RFC 3339. Unknown keys are
+// ignored when reading, so that later versions of the format may add keys.
+// The remainder of the file is the payload as a gzip stream, written without
+// a modification time so that the same payload is always encoded identically.
+const snapContainerMagic = "snap/1"
+
+// SnapContainerExtension is the conventional file extension of snapshots in
+// the .snap container format, e.g. for WithSnapshotFileExtension.
+const SnapContainerExtension = ".snap"
+
+// The header keys of the .snap container format.
+const (
+	snapVersionKey     = "version"
+	snapCreatedKey     = "created"
+	snapContentTypeKey = "content-type"
+)
+
+// SnapMetadata is the metadata stored alongside the payload of a snapshot in
+// the .snap container format.
+type SnapMetadata struct {
+	// Version is the version of the payload, e.g. of the format or of the
+	// system which produced it.
+	Version string
+	// Created is the time the payload was recorded.
+	Created time.Time
+	// ContentType is the media type of the payload, e.g. "application/json".
+	ContentType string
+}
+
+// AsSnapContainer encodes meta and payload to the io.Reader in the .snap
+// container format, as described by snapContainerMagic. For example:
+//
+//	out, err := snapshot.AsSnapContainer(snapshot.SnapMetadata{Version: "1.0.0"}, payload)
+//	...
+//	ok, msg := snapshot.Match(t, out,
+//		snapshot.WithSnapshotFileExtension(snapshot.SnapContainerExtension),
+//		snapshot.WithComparator(snapshot.SnapContainerComparator(snapshot.StringComparator)),
+//	)
+//
+// The metadata values must not contain newlines.
+func AsSnapContainer(meta SnapMetadata, payload io.Reader) (out io.Reader, err error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString(snapContainerMagic + "\n")
+	created := ""
+	if !meta.Created.IsZero() {
+		created = meta.Created.Format(time.RFC3339Nano)
+	}
+	header := [][2]string{
+		{snapVersionKey, meta.Version},
+		{snapCreatedKey, created},
+		{snapContentTypeKey, meta.ContentType},
+	}
+	for _, kv := range header {
+		if kv[1] == "" {
+			continue
+		}
+		if strings.ContainsAny(kv[1], "\r\n") {
+			err = fmt.Errorf("snap container %v %q must not contain a newline", kv[0], kv[1])
+			return
+		}
+		fmt.Fprintf(buf, "%v: %v\n", kv[0], kv[1])
+	}
+	buf.WriteString("\n")
+	gz := gzip.NewWriter(buf)
+	if _, err = io.Copy(gz, payload); err != nil {
+		err = fmt.Errorf("failed to compress snap container payload: %w", err)
+		return
+	}
+	if err = gz.Close(); err != nil {
+		err = fmt.Errorf("failed to compress snap container payload: %w", err)
+		return
+	}
+	out = buf
+	return
+}
+
+// ReadSnapContainer reads the metadata of the .snap container in r, as written
+// by AsSnapContainer, and returns it along with a reader of the decompressed
+// payload.
+func ReadSnapContainer(r io.Reader) (meta SnapMetadata, payload io.Reader, err error) {
+	br := bufio.NewReader(r)
+	line, err := readSnapHeaderLine(br)
+	if err != nil {
+		return
+	}
+	if line != snapContainerMagic {
+		err = fmt.Errorf("not a snap container, expected a leading %q line, got %q", snapContainerMagic, line)
+		return
+	}
+	for {
+		if line, err = readSnapHeaderLine(br); err != nil {
+			return
+		}
+		if line == "" {
+			break
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			err = fmt.Errorf("invalid snap container header line %q, expected \"key: value\"", line)
+			return
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case snapVersionKey:
+			meta.Version = value
+		case snapCreatedKey:
+			if meta.Created, err = time.Parse(time.RFC3339Nano, value); err != nil {
+				err = fmt.Errorf("invalid snap container %v time: %w", snapCreatedKey, err)
+				return
+			}
+		case snapContentTypeKey:
+			meta.ContentType = value
+		}
+	}
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		err = fmt.Errorf("failed to decompress snap container payload: %w", err)
+		return
+	}
+	payload = gz
+	return
+}
+
+// readSnapHeaderLine reads a line of the header of a .snap container, without
+// the trailing newline. The header must be terminated by an empty line.
+func readSnapHeaderLine(br *bufio.Reader) (string, error) {
+	line, err := br.ReadString('\n')
+	if err == io.EOF {
+		if line != "" {
+			return line, nil
+		}
+		return "", fmt.Errorf("unexpected end of snap container header")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read snap container header: %w", err)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// SnapContainerComparator creates a Comparator for snapshots in the .snap
+// container format, as written by AsSnapContainer, which extracts the payload
+// of both expected and actual and compares them using base. The metadata is
+// not compared, so that, for example, a payload recorded at a different time
+// still matches. The comparison fails if either side is not a valid
+// container.
+func SnapContainerComparator(base Comparator) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		_, ePayload, err := ReadSnapContainer(expected)
+		if err != nil {
+			msg = "expected: " + err.Error()
+			return
+		}
+		_, aPayload, err := ReadSnapContainer(actual)
+		if err != nil {
+			msg = "actual: " + err.Error()
+			return
+		}
+		return base(ePayload, aPayload)
+	}
+}
@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapContainerRoundTrip(t *testing.T) {
+	meta := SnapMetadata{
+		Version:     "1.2.0",
+		Created:     time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		ContentType: "application/json",
+	}
+	out, err := AsSnapContainer(meta, strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatalf("failed to encode container: %v", err)
+	}
+	b := []byte(readAllUnchecked(out))
+	header := "snap/1\nversion: 1.2.0\ncreated: 2024-01-02T03:04:05Z\ncontent-type: application/json\n\n"
+	if !strings.HasPrefix(string(b), header) {
+		t.Errorf("expected container to begin with %q, got %q", header, b)
+	}
+	gotMeta, payload, err := ReadSnapContainer(strings.NewReader(string(b)))
+	if err != nil {
+		t.Fatalf("failed to read container: %v", err)
+	}
+	if gotMeta != meta {
+		t.Errorf("expected metadata %+v, got %+v", meta, gotMeta)
+	}
+	if str := readAllUnchecked(payload); str != `{"a":1}` {
+		t.Errorf("expected payload %q, got %q", `{"a":1}`, str)
+	}
+
+	out, err = AsSnapContainer(SnapMetadata{}, strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("failed to encode empty container: %v", err)
+	}
+	gotMeta, payload, err = ReadSnapContainer(out)
+	if err != nil || gotMeta != (SnapMetadata{}) || readAllUnchecked(payload) != "" {
+		t.Errorf("expected empty container to round trip, got %+v, %v", gotMeta, err)
+	}
+
+	if _, err := AsSnapContainer(SnapMetadata{Version: "1\n2"}, strings.NewReader("")); err == nil {
+		t.Errorf("expected a newline in the metadata to fail")
+	}
+}
+
+func TestReadSnapContainerErrors(t *testing.T) {
+	tests := []struct {
+		data string
+		err  string
+	}{
+		{"hello\n\n", `not a snap container, expected a leading "snap/1" line, got "hello"`},
+		{"snap/1\nversion: 1.0.0\n", "unexpected end of snap container header"},
+		{"snap/1\nversion\n\n", `invalid snap container header line "version", expected "key: value"`},
+		{"snap/1\ncreated: yesterday\n\n", "invalid snap container created time"},
+		{"snap/1\n\nnot gzip", "failed to decompress snap container payload"},
+	}
+	for _, test := range tests {
+		_, _, err := ReadSnapContainer(strings.NewReader(test.data))
+		if err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("expected error %q for %q, got %v", test.err, test.data, err)
+		}
+	}
+}
+
+func TestSnapContainerComparator(t *testing.T) {
+	mk := func(created time.Time, payload string) string {
+		out, err := AsSnapContainer(SnapMetadata{Version: "1.0.0", Created: created}, strings.NewReader(payload))
+		if err != nil {
+			t.Fatalf("failed to encode container: %v", err)
+		}
+		return readAllUnchecked(out)
+	}
+	cmp := SnapContainerComparator(StringComparator)
+	then, now := time.Unix(0, 0).UTC(), time.Unix(100, 0).UTC()
+	if ok, msg := cmp(strings.NewReader(mk(then, "hello")), strings.NewReader(mk(now, "hello"))); !ok {
+		t.Errorf("expected payloads with different metadata to match: %v", msg)
+	}
+	if ok, msg := cmp(strings.NewReader(mk(then, "hello")), strings.NewReader(mk(then, "world"))); ok || msg == "" {
+		t.Errorf("expected different payloads to fail, got %v, %q", ok, msg)
+	}
+	if ok, msg := cmp(strings.NewReader(mk(then, "hello")), strings.NewReader("hello")); ok || !strings.HasPrefix(msg, "actual: not a snap container") {
+		t.Errorf("expected invalid actual container to fail, got %v, %q", ok, msg)
+	}
+}
+
+func TestMatchSnapContainer(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	opts := []MatchOption{
+		dirOpt,
+		WithSnapshotFileExtension(SnapContainerExtension),
+		WithComparator(SnapContainerComparator(StringComparator)),
+	}
+	for i, created := range []time.Time{time.Unix(0, 0), time.Unix(100, 0)} {
+		out, err := AsSnapContainer(SnapMetadata{Created: created.UTC()}, strings.NewReader("hello"))
+		if err != nil {
+			t.Fatalf("failed to encode container: %v", err)
+		}
+		if ok, msg := Match(t, out, opts...); !ok {
+			t.Errorf("expected match %d to pass: %v", i, msg)
+		}
+	}
+}
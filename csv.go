@@ -0,0 +1,261 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+)
+
+// AsCSV writes rows to the io.Reader as CSV, calling rows first if it is a
+// function, as for AsJSON. rows must be a slice or array of structs or of
+// maps, or pointers to them. The first record is a header: the exported
+// fields of a struct in declaration order, named by their csv tag if present
+// and skipped if the tag is "-", or the sorted union of the keys of every map.
+// Values implementing encoding.TextMarshaler, such as time.Time, are written
+// as their text, nil values as empty fields and all other values as
+// formatted by fmt.Sprint.
+func AsCSV(rows interface{}) (out io.Reader, err error) {
+	rows, err = callIfFunc(rows, "AsCSV")
+	if err != nil {
+		return
+	}
+	records, err := csvRecords(reflect.ValueOf(rows))
+	if err != nil {
+		err = fmt.Errorf("failed to encode snapshot as CSV: %w", err)
+		return
+	}
+	buf := new(bytes.Buffer)
+	w := csv.NewWriter(buf)
+	if err = w.WriteAll(records); err != nil {
+		err = fmt.Errorf("failed to encode snapshot as CSV: %w", err)
+		return
+	}
+	out = buf
+	return
+}
+
+// csvRecords returns the header and a record for each row of rows, which
+// must be a slice or array of structs or maps.
+func csvRecords(rows reflect.Value) ([][]string, error) {
+	if rows.Kind() == reflect.Ptr && !rows.IsNil() {
+		rows = rows.Elem()
+	}
+	if rows.Kind() != reflect.Slice && rows.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice of structs or maps, got %v", typeName(rows))
+	}
+	elems := make([]reflect.Value, rows.Len())
+	for i := range elems {
+		elems[i] = indirectValue(rows.Index(i))
+	}
+	elemType := indirectType(rows.Type().Elem())
+	if elemType.Kind() == reflect.Interface {
+		if len(elems) == 0 {
+			return nil, nil
+		}
+		// The type of the rows is that of the first non-nil row.
+		for _, elem := range elems {
+			if elem.IsValid() {
+				elemType = elem.Type()
+				break
+			}
+		}
+	}
+	for i, elem := range elems {
+		if elem.IsValid() && (elem.Kind() != elemType.Kind() || elem.Kind() == reflect.Struct && elem.Type() != elemType) {
+			return nil, fmt.Errorf("row %d is a %v, expected a %v as for the other rows", i, elem.Type(), elemType)
+		}
+	}
+	switch elemType.Kind() {
+	case reflect.Struct:
+		return csvStructRecords(elemType, elems), nil
+	case reflect.Map:
+		return csvMapRecords(elems), nil
+	default:
+		return nil, fmt.Errorf("expected a slice of structs or maps, got %v", rows.Type())
+	}
+}
+
+// csvStructRecords returns the records of rows, which are structs of type
+// typ or invalid for nil rows.
+func csvStructRecords(typ reflect.Type, rows []reflect.Value) [][]string {
+	var header []string
+	var fields []int
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag, ok := f.Tag.Lookup("csv"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+	records := [][]string{header}
+	for _, row := range rows {
+		record := make([]string, len(fields))
+		if row.IsValid() {
+			for j, i := range fields {
+				record[j] = csvField(row.Field(i))
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// csvMapRecords returns the records of rows, which are maps or invalid for
+// nil rows.
+func csvMapRecords(rows []reflect.Value) [][]string {
+	keys := map[string]bool{}
+	for _, row := range rows {
+		if !row.IsValid() {
+			continue
+		}
+		for _, k := range row.MapKeys() {
+			keys[csvField(k)] = true
+		}
+	}
+	header := make([]string, 0, len(keys))
+	for k := range keys {
+		header = append(header, k)
+	}
+	sort.Strings(header)
+	records := [][]string{header}
+	for _, row := range rows {
+		values := map[string]string{}
+		if row.IsValid() {
+			iter := row.MapRange()
+			for iter.Next() {
+				values[csvField(iter.Key())] = csvField(iter.Value())
+			}
+		}
+		record := make([]string, len(header))
+		for i, k := range header {
+			record[i] = values[k]
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// csvField formats v as a CSV field.
+func csvField(v reflect.Value) string {
+	v = indirectValue(v)
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Type().Implements(textMarshalerType) {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// indirectValue follows pointers and interfaces from v, returning an invalid
+// value if any is nil.
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// indirectType follows pointer types from typ.
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// typeName returns the name of the type of v for error messages, or "nil".
+func typeName(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return v.Type().String()
+}
+
+// WithCreateSnapshotAsCSV configures GetTestInput to use AsCSV as the
+// CreateSnapshot and sets the file extension to ".csv". When used with Match,
+// it sets the file extension to ".csv" and the comparator to CSVComparator,
+// as for WithCreateSnapshotAsJSON.
+func WithCreateSnapshotAsCSV(rows interface{}) SnapshotOption {
+	return withCreateSnapshotAsCSV{rows}
+}
+
+type withCreateSnapshotAsCSV struct {
+	rows interface{}
+}
+
+func (wo withCreateSnapshotAsCSV) ApplyInputOption(o *GetTestInputOptions) {
+	o.CreateSnapshot = func() (io.Reader, error) { return AsCSV(wo.rows) }
+	o.FileExtension = ".csv"
+}
+
+func (wo withCreateSnapshotAsCSV) ApplyMatchOption(o *MatchOptions) {
+	o.Comparator = CSVComparator
+	o.FileExtension = ".csv"
+}
+
+// CSVComparator decodes expected and actual as CSV and compares the records,
+// so that differences in quoting and line endings are ignored. On failure the
+// first differing record is reported, numbered from 1 including the header.
+func CSVComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eRecords, err := readCSV(expected)
+	if err != nil {
+		msg = "failed to decode expected CSV: " + err.Error()
+		return
+	}
+	aRecords, err := readCSV(actual)
+	if err != nil {
+		msg = "failed to decode actual CSV: " + err.Error()
+		return
+	}
+	for i := 0; i < len(eRecords) || i < len(aRecords); i++ {
+		switch {
+		case i >= len(eRecords):
+			msg = fmt.Sprintf("record %d: unexpected %q", i+1, aRecords[i])
+		case i >= len(aRecords):
+			msg = fmt.Sprintf("record %d: expected %q, got no record", i+1, eRecords[i])
+		case !reflect.DeepEqual(eRecords[i], aRecords[i]):
+			msg = fmt.Sprintf("record %d: expected %q, got %q", i+1, eRecords[i], aRecords[i])
+		default:
+			continue
+		}
+		return
+	}
+	ok = true
+	return
+}
+
+// readCSV reads every record from r, which may have any number of fields.
+func readCSV(r io.Reader) (records [][]string, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		} else if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
@@ -0,0 +1,113 @@
+package snapshot
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type csvRow struct {
+	Name    string
+	Count   int
+	When    *time.Time `csv:"when"`
+	Ignored string     `csv:"-"`
+	private string
+}
+
+func TestAsCSV(t *testing.T) {
+	when := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		rows     interface{}
+		expected string
+	}{
+		{
+			name:     "structs",
+			rows:     []csvRow{{Name: "a, b", Count: 1, When: &when, Ignored: "x", private: "y"}, {Name: `"c"`}},
+			expected: "Name,Count,when\n\"a, b\",1,2023-01-02T03:04:05Z\n\"\"\"c\"\"\",0,\n",
+		},
+		{
+			name:     "struct pointers",
+			rows:     func() []*csvRow { return []*csvRow{{Name: "a"}, nil} },
+			expected: "Name,Count,when\na,0,\n,,\n",
+		},
+		{
+			name:     "empty structs",
+			rows:     []csvRow{},
+			expected: "Name,Count,when\n",
+		},
+		{
+			name:     "maps",
+			rows:     []map[string]interface{}{{"b": 1, "a": "x"}, {"c": true}},
+			expected: "a,b,c\nx,1,\n,,true\n",
+		},
+		{
+			name:     "interfaces",
+			rows:     []interface{}{map[string]int{"a": 1}, map[string]string{"a": "x"}},
+			expected: "a\n1\nx\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := AsCSV(tt.rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if str := readAllUnchecked(r); str != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, str)
+			}
+		})
+	}
+
+	errTests := []struct {
+		rows     interface{}
+		expected string
+	}{
+		{csvRow{}, "failed to encode snapshot as CSV: expected a slice of structs or maps, got snapshot.csvRow"},
+		{nil, "failed to encode snapshot as CSV: expected a slice of structs or maps, got nil"},
+		{[]int{1}, "failed to encode snapshot as CSV: expected a slice of structs or maps, got []int"},
+		{[]interface{}{csvRow{}, map[string]int{}}, "failed to encode snapshot as CSV: row 1 is a map[string]int, expected a snapshot.csvRow as for the other rows"},
+	}
+	for _, tt := range errTests {
+		if _, err := AsCSV(tt.rows); err == nil || err.Error() != tt.expected {
+			t.Errorf("expected %q, got %v", tt.expected, err)
+		}
+	}
+}
+
+func TestCSVComparator(t *testing.T) {
+	expected := "a,b\n1,2\n"
+	if ok, msg := CSVComparator(strings.NewReader(expected), strings.NewReader("\"a\",b\r\n1,\"2\"\r\n")); !ok {
+		t.Errorf("expected quoting and line endings to be ignored: %v", msg)
+	}
+	tests := []struct {
+		actual string
+		msg    string
+	}{
+		{"a,b\n1,3\n", `record 2: expected ["1" "2"], got ["1" "3"]`},
+		{"a,b\n", `record 2: expected ["1" "2"], got no record`},
+		{"a,b\n1,2\n3\n", `record 3: unexpected ["3"]`},
+		{"a,\"b\n", `failed to decode actual CSV: parse error on line 1, column 6: extraneous or missing " in quoted-field`},
+	}
+	for _, tt := range tests {
+		if ok, msg := CSVComparator(strings.NewReader(expected), strings.NewReader(tt.actual)); ok || msg != tt.msg {
+			t.Errorf("expected %q, got %v, %q", tt.msg, ok, msg)
+		}
+	}
+}
+
+func TestCreateSnapshotAsCSVMatch(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	opt := WithCreateSnapshotAsCSV([]csvRow{{Name: "a", Count: 1}})
+	input := GetTestInput(t, opt, dirOpt)
+	if ok, msg := Match(t, input, opt, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(outputP, ".txt") + ".csv"); err != nil {
+		t.Fatalf("expected output snapshot with .csv extension: %v", err)
+	}
+	if ok, msg := Match(t, strings.NewReader("\"Name\",Count,when\r\na,1,\r\n"), opt, dirOpt); !ok {
+		t.Errorf("expected CSV comparator to ignore quoting: %v", msg)
+	}
+}
@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// A valueFormat encodes and decodes values as snapshots of a file format.
+type valueFormat struct {
+	name string
+	// encode marshals i, formatting any times within it using layout
+	// unless it is empty.
+	encode func(i interface{}, layout string) (io.Reader, error)
+	// decode unmarshals the document in r into v, which must be a pointer.
+	decode func(r io.Reader, v interface{}) error
+}
+
+// valueFormats are the formats of GetTestInputDecoded and
+// WithCreateSnapshotAsValue, by file extension.
+var valueFormats = map[string]valueFormat{
+	".json": {"JSON", asJSONWithTimeFormat, func(r io.Reader, v interface{}) error { return json.NewDecoder(r).Decode(v) }},
+	".yaml": {"YAML", asYAMLWithTimeFormat, decodeYAML},
+	".yml":  {"YAML", asYAMLWithTimeFormat, decodeYAML},
+}
+
+// lookupValueFormat returns the format of snapshots with the file extension
+// ext, or an error listing the supported extensions.
+func lookupValueFormat(ext string) (valueFormat, error) {
+	if f, ok := valueFormats[normaliseExtension(ext)]; ok {
+		return f, nil
+	}
+	exts := make([]string, 0, len(valueFormats))
+	for e := range valueFormats {
+		exts = append(exts, e)
+	}
+	sort.Strings(exts)
+	return valueFormat{}, fmt.Errorf("no format for snapshot file extension %q, expected one of %v", ext, strings.Join(exts, ", "))
+}
+
+// WithCreateSnapshotAsValue configures GetTestInput to create the input
+// snapshot by marshalling i in the format of the file extension: JSON, as for
+// AsJSON, for ".json", and YAML, as for AsYAML, for ".yaml" and ".yml". As for
+// AsJSON, if i is a function it is called and the result marshalled. The
+// extension is resolved when the snapshot is created, so it may be set by an
+// option in any order, and creating a snapshot with any other extension
+// fails. Times are formatted as for WithTimeFormat.
+func WithCreateSnapshotAsValue(i interface{}) GetTestInputOption {
+	return GetTestInputOptionFunc(func(o *GetTestInputOptions) {
+		o.CreateSnapshot = func() (io.Reader, error) {
+			f, err := lookupValueFormat(o.FileExtension)
+			if err != nil {
+				return nil, err
+			}
+			v, err := callIfFunc(i, "WithCreateSnapshotAsValue")
+			if err != nil {
+				return nil, err
+			}
+			return f.encode(v, o.TimeFormat)
+		}
+	})
+}
+
+// GetTestInputDecoded loads the input snapshot as GetTestInput and decodes it
+// into a T in the format of its file extension: JSON for ".json", the
+// default, and YAML for ".yaml" and ".yml". YAML is decoded through JSON, so
+// the json struct tags of T apply to both formats. When the input snapshot
+// does not exist, WithCreateSnapshotAsValue creates it from a value in the
+// same format. The test fails immediately if the extension has no format or
+// the snapshot cannot be decoded. For example:
+//
+//	cfg := snapshot.GetTestInputDecoded[Config](t,
+//		snapshot.WithSnapshotFileExtension(".yaml"),
+//		snapshot.WithCreateSnapshotAsValue(defaultConfig))
+func GetTestInputDecoded[T any](t *testing.T, optFns ...GetTestInputOption) (v T) {
+	skipIfSnapshotsDisabled(t)
+	opts := newGetTestInputOptions(append([]GetTestInputOption{WithSnapshotFileExtension(".json")}, optFns...))
+	f, err := lookupValueFormat(opts.FileExtension)
+	if err != nil {
+		t.Fatalf("failed to decode input snapshot: %v", err.Error())
+	}
+	p := resolveInputFilePath(t, 0, opts)
+	existing, in := openTestInput(t, p, opts)
+	if existing != nil {
+		in = existing
+	}
+	// The input is read in full, so that a created snapshot is complete even
+	// if the decoder stops at the end of the document.
+	b, err := io.ReadAll(in)
+	if err != nil {
+		t.Fatalf("failed to read input snapshot %v: %v", p, err.Error())
+	}
+	if err = f.decode(bytes.NewReader(b), &v); err != nil {
+		t.Fatalf("failed to decode input snapshot %v as %v: %v", p, f.name, err.Error())
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type decodedConfig struct {
+	Name    string   `json:"name"`
+	Retries int      `json:"retries,omitempty"`
+	Tags    []string `json:"tags"`
+}
+
+func TestGetTestInputDecoded(t *testing.T) {
+	value := decodedConfig{Name: "svc", Retries: 3, Tags: []string{"a", "true"}}
+	tests := []struct {
+		ext      string
+		expected string
+	}{
+		{".json", "{\n  \"name\": \"svc\",\n  \"retries\": 3,\n  \"tags\": [\n    \"a\",\n    \"true\"\n  ]\n}\n"},
+		{".yaml", "name: svc\nretries: 3\ntags:\n  - a\n  - \"true\"\n"},
+		{"yml", "name: svc\nretries: 3\ntags:\n  - a\n  - \"true\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.TrimPrefix(tt.ext, "."), func(t *testing.T) {
+			dir := t.TempDir()
+			opts := []GetTestInputOption{WithSnapshotDir(dir), WithSnapshotFileExtension(tt.ext), WithCreateSnapshotAsValue(value)}
+			if v := GetTestInputDecoded[decodedConfig](t, opts...); v.Name != "svc" || v.Retries != 3 || len(v.Tags) != 2 {
+				t.Fatalf("unexpected created value: %+v", v)
+			}
+			p := filepath.Join(dir, t.Name(), "input"+normaliseExtension(tt.ext))
+			if str := readAllUnchecked(openUnchecked(t, p)); str != tt.expected {
+				t.Fatalf("unexpected snapshot contents: %q", str)
+			}
+			if v := GetTestInputDecoded[decodedConfig](t, opts...); v.Name != "svc" || v.Retries != 3 || v.Tags[1] != "true" {
+				t.Errorf("unexpected decoded value: %+v", v)
+			}
+		})
+	}
+}
+
+func TestGetTestInputDecodedDefaultsToJSON(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	v := GetTestInputDecoded[map[string]int](t, dirOpt, WithCreateSnapshotAsValue(func() map[string]int {
+		return map[string]int{"a": 1}
+	}))
+	if v["a"] != 1 {
+		t.Errorf("unexpected value: %v", v)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(inputP, ".txt") + ".json"); err != nil {
+		t.Errorf("expected a JSON input snapshot: %v", err)
+	}
+}
+
+func TestLookupValueFormat(t *testing.T) {
+	expected := `no format for snapshot file extension ".txt", expected one of .json, .yaml, .yml`
+	if _, err := lookupValueFormat(".txt"); err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestDecodeYAML(t *testing.T) {
+	var v decodedConfig
+	if err := decodeYAML(strings.NewReader("name: svc\ntags: [x]\n"), &v); err != nil || v.Name != "svc" || v.Tags[0] != "x" {
+		t.Errorf("unexpected value %+v, %v", v, err)
+	}
+	if err := decodeYAML(strings.NewReader("name: [\n"), &v); err == nil {
+		t.Errorf("expected invalid YAML to fail")
+	}
+}
@@ -3,24 +3,246 @@ package snapshot
 import (
 	"fmt"
 	"io"
+	"strings"
+	"testing"
+	"unicode/utf8"
 
 	"github.com/google/go-cmp/cmp"
 )
 
-// StringDiffComparator reads expected and actual into strings compares them using
-// cmp.Diff from the go-cmp library. On failure the diff string is returned.
+// A DiffFunc compares the expected and actual strings and returns a human
+// readable diff along with whether the two are deemed equal. This allows a
+// diff library of choice to be adapted for use with DiffComparator.
+type DiffFunc func(expected, actual string) (diff string, equal bool)
+
+// CmpDiff is the default DiffFunc, backed by cmp.Diff from the go-cmp library.
+func CmpDiff(expected, actual string) (diff string, equal bool) {
+	diff = cmp.Diff(expected, actual)
+	equal = diff == ""
+	return
+}
+
+// cmpLineDiff is a DiffFunc as CmpDiff, but the strings are split on newlines
+// and diffed line by line, so that the diff of multi-line snapshots shows only
+// the changed lines.
+func cmpLineDiff(expected, actual string) (diff string, equal bool) {
+	diff = cmp.Diff(strings.Split(expected, "\n"), strings.Split(actual, "\n"))
+	equal = diff == ""
+	return
+}
+
+// DiffComparator creates a Comparator that reads expected and actual into
+// strings and compares them using df. On failure the diff string is returned.
+func DiffComparator(df DiffFunc) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		return compareAsStrings(expected, actual, func(expected, actual string) (bool, string) {
+			diff, equal := df(expected, actual)
+			return equal, diff
+		})
+	}
+}
+
+// StringDiffComparator reads expected and actual into strings and compares
+// them line by line using cmp.Diff from the go-cmp library. On failure the diff
+// string is returned.
 func StringDiffComparator(expected, actual io.Reader) (ok bool, msg string) {
-	actualString, err := readToString(actual)
+	return DiffComparator(cmpLineDiff)(expected, actual)
+}
+
+// WithDiffFunc overrides the default comparator with a DiffComparator using
+// df. This is useful to keep diff presentation consistent with other tooling.
+func WithDiffFunc(df DiffFunc) MatchOption {
+	return WithComparator(DiffComparator(df))
+}
+
+// firstDifference returns the 1-based line and column (in runes) of the first
+// position at which expected and actual differ, along with the full lines of
+// each at that position. If one string is a prefix of the other, the position
+// is that of the end of the shorter string.
+func firstDifference(expected, actual string) (line, col int, eLine, aLine string) {
+	i := 0
+	for i < len(expected) && i < len(actual) && expected[i] == actual[i] {
+		i++
+	}
+	// Step back to the start of a multi-byte rune that differs part way.
+	for i > 0 && i < len(expected) && !utf8.RuneStart(expected[i]) {
+		i--
+	}
+	lineStart := strings.LastIndexByte(expected[:i], '\n') + 1
+	line = strings.Count(expected[:i], "\n") + 1
+	col = utf8.RuneCountInString(expected[lineStart:i]) + 1
+	eLine = lineAt(expected, lineStart)
+	aLine = lineAt(actual, lineStart)
+	return
+}
+
+// lineAt returns the line of s that starts at byte offset start, without the
+// trailing newline.
+func lineAt(s string, start int) string {
+	if start > len(s) {
+		return ""
+	}
+	s = s[start:]
+	if end := strings.IndexByte(s, '\n'); end != -1 {
+		s = s[:end]
+	}
+	return s
+}
+
+// CompareStringsWithPosition performs an equality check of expected and
+// actual. On failure msg reports the line and column of the first difference
+// along with the differing lines, e.g.
+//
+//	first difference at line 42, col 7: expected "hello world", got "hello there"
+func CompareStringsWithPosition(expected, actual string) (ok bool, msg string) {
+	ok = expected == actual
+	if ok {
+		return
+	}
+	line, col, eLine, aLine := firstDifference(expected, actual)
+	msg = fmt.Sprintf("first difference at line %d, col %d: ", line, col)
+	switch {
+	case strings.HasPrefix(expected, actual):
+		msg += fmt.Sprintf("actual ends early, expected %q", eLine)
+	case strings.HasPrefix(actual, expected):
+		msg += fmt.Sprintf("actual continues, got %q", aLine)
+	default:
+		msg += fmt.Sprintf("expected %q, got %q", eLine, aLine)
+	}
+	return
+}
+
+// PositionComparator reads expected and actual into strings and compares them
+// using CompareStringsWithPosition, reporting the line and column of the first
+// difference on failure. This is useful for large text snapshots.
+func PositionComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return compareAsStrings(expected, actual, CompareStringsWithPosition)
+}
+
+// gitHubDiffContext is the number of unchanged lines shown around each change
+// by GitHubDiffComparator, as for git diff.
+const gitHubDiffContext = 3
+
+// formatHunkRange formats the start line and line count of one side of a hunk
+// header, omitting the count if it is 1, as for git diff.
+func formatHunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// unifiedDiff formats edits as a unified diff from the file named eName to the
+// file named aName, with hunks of the changed lines surrounded by up to
+// context unchanged lines.
+func unifiedDiff(eName, aName string, edits []lineEdit, context int) string {
+	buf := new(strings.Builder)
+	buf.WriteString("--- " + eName + "\n+++ " + aName)
+	// eLines[k] and aLines[k] are the numbers of lines of expected and actual
+	// before edit k.
+	eLines, aLines := make([]int, len(edits)+1), make([]int, len(edits)+1)
+	for k, edit := range edits {
+		eLines[k+1], aLines[k+1] = eLines[k], aLines[k]
+		if edit.op != '+' {
+			eLines[k+1]++
+		}
+		if edit.op != '-' {
+			aLines[k+1]++
+		}
+	}
+	for k := 0; k < len(edits); {
+		if edits[k].op == ' ' {
+			k++
+			continue
+		}
+		start := k - context
+		if start < 0 {
+			start = 0
+		}
+		// Extend the hunk until there are more than 2*context unchanged lines
+		// before the next change, or the edits run out.
+		end, unchanged := k, 0
+		for ; end < len(edits) && unchanged <= 2*context; end++ {
+			if edits[end].op == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		end -= unchanged
+		if end += context; end > len(edits) {
+			end = len(edits)
+		}
+		eStart, eCount := eLines[start]+1, eLines[end]-eLines[start]
+		aStart, aCount := aLines[start]+1, aLines[end]-aLines[start]
+		if eCount == 0 {
+			eStart--
+		}
+		if aCount == 0 {
+			aStart--
+		}
+		fmt.Fprintf(buf, "\n@@ -%v +%v @@", formatHunkRange(eStart, eCount), formatHunkRange(aStart, aCount))
+		for _, edit := range edits[start:end] {
+			buf.WriteString("\n" + string(edit.op) + edit.line)
+		}
+		k = end
+	}
+	return buf.String()
+}
+
+// GitHubDiffComparator reads expected and actual into strings and compares
+// them. On failure msg is a unified diff of the lines of each, as produced by
+// git diff, with "@@ -a,b +c,d @@" hunk headers giving the line numbers of
+// each hunk and three lines of context around each change. This is easier to
+// navigate than the output of CmpDiff for large text snapshots, and can be
+// pasted into review comments.
+func GitHubDiffComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return compareAsStrings(expected, actual, func(expected, actual string) (bool, string) {
+		if expected == actual {
+			return true, ""
+		}
+		edits := diffLines(splitLines(expected), splitLines(actual))
+		for _, edit := range edits {
+			if edit.op != ' ' {
+				return false, unifiedDiff("expected", "actual", edits, gitHubDiffContext)
+			}
+		}
+		if strings.HasSuffix(expected, "\n") {
+			return false, "actual is missing the trailing newline of expected"
+		}
+		return false, "actual has a trailing newline which expected does not"
+	})
+}
+
+// MatchDiff matches a unified diff of the lines of before and after against
+// the output snapshot, as Match, so that the expected change between two
+// versions of an output is locked in rather than either output itself. The
+// diff is from "before" to "after", with "@@ -a,b +c,d @@" hunk headers and
+// three lines of context around each change, as for GitHubDiffComparator,
+// and ends with a newline. It is empty if the lines of before and after are
+// the same, and a difference only in a trailing newline is ignored. The
+// snapshot is stored with the ".diff" file extension, which may be overridden
+// by optFns. If before or after cannot be read, Match is not called and the
+// error is returned in msg.
+func MatchDiff(t *testing.T, before, after io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	bBytes, err := io.ReadAll(before)
 	if err != nil {
-		msg = fmt.Sprintf("failed to read actual io.Reader: %v", err.Error())
+		msg = "failed to read before data from reader: " + err.Error()
 		return
 	}
-	expectedString, err := readToString(expected)
+	aBytes, err := io.ReadAll(after)
 	if err != nil {
-		msg = fmt.Sprintf("failed to read expected io.Reader: %v", err.Error())
+		msg = "failed to read after data from reader: " + err.Error()
 		return
 	}
-	msg = cmp.Diff(expectedString, actualString)
-	ok = msg == ""
-	return
+	diff := ""
+	edits := diffLines(splitLines(string(bBytes)), splitLines(string(aBytes)))
+	for _, edit := range edits {
+		if edit.op != ' ' {
+			diff = unifiedDiff("before", "after", edits, gitHubDiffContext) + "\n"
+			break
+		}
+	}
+	optFns = append([]MatchOption{WithSnapshotFileExtension(".diff")}, optFns...)
+	return match(t, 1, strings.NewReader(diff), optFns...)
 }
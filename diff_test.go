@@ -0,0 +1,192 @@
+package snapshot
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffComparator(t *testing.T) {
+	var gotExpected, gotActual string
+	df := func(expected, actual string) (diff string, equal bool) {
+		gotExpected, gotActual = expected, actual
+		return "custom diff", false
+	}
+	ok, msg := DiffComparator(df)(strings.NewReader("hello"), strings.NewReader("world"))
+	if ok {
+		t.Errorf("expected comparison to fail")
+	}
+	if msg != "custom diff" {
+		t.Errorf("expected message %q, got %q", "custom diff", msg)
+	}
+	if gotExpected != "hello" || gotActual != "world" {
+		t.Errorf("unexpected DiffFunc arguments: %q, %q", gotExpected, gotActual)
+	}
+}
+
+func TestStringDiffComparator(t *testing.T) {
+	if ok, msg := StringDiffComparator(strings.NewReader("hello"), strings.NewReader("hello")); !ok {
+		t.Errorf("expected equal strings to match: %v", msg)
+	}
+	if ok, msg := StringDiffComparator(strings.NewReader("hello"), strings.NewReader("world")); ok || msg == "" {
+		t.Errorf("expected different strings to fail with a diff, got %v, %q", ok, msg)
+	}
+}
+
+func TestCompareStringsWithPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		msg      string
+	}{
+		{
+			name:     "equal",
+			expected: "hello\nworld",
+			actual:   "hello\nworld",
+		},
+		{
+			name:     "difference on later line",
+			expected: "hello\nworld\nfoo",
+			actual:   "hello\nwonder\nfoo",
+			msg:      `first difference at line 2, col 3: expected "world", got "wonder"`,
+		},
+		{
+			name:     "difference in multi-byte rune",
+			expected: "héllo",
+			actual:   "hèllo",
+			msg:      `first difference at line 1, col 2: expected "héllo", got "hèllo"`,
+		},
+		{
+			name:     "actual is a prefix",
+			expected: "hello\nworld",
+			actual:   "hello\n",
+			msg:      `first difference at line 2, col 1: actual ends early, expected "world"`,
+		},
+		{
+			name:     "expected is a prefix",
+			expected: "hello",
+			actual:   "hello world",
+			msg:      `first difference at line 1, col 6: actual continues, got "hello world"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := CompareStringsWithPosition(tt.expected, tt.actual)
+			if ok != (tt.msg == "") {
+				t.Fatalf("unexpected result %v: %q", ok, msg)
+			}
+			if msg != tt.msg {
+				t.Fatalf("expected message %q, got %q", tt.msg, msg)
+			}
+		})
+	}
+}
+
+func TestStringDiffComparatorMultiLine(t *testing.T) {
+	expected := "first line\nsecond line\nthird line\n"
+	actual := "first line\nchanged line\nthird line\n"
+	ok, msg := StringDiffComparator(strings.NewReader(expected), strings.NewReader(actual))
+	if ok {
+		t.Fatalf("expected comparison to fail")
+	}
+	var removed, added []string
+	for _, line := range strings.Split(msg, "\n") {
+		switch {
+		case strings.HasPrefix(line, "-"):
+			removed = append(removed, line)
+		case strings.HasPrefix(line, "+"):
+			added = append(added, line)
+		}
+	}
+	if len(removed) != 1 || !strings.Contains(removed[0], `"second line"`) ||
+		len(added) != 1 || !strings.Contains(added[0], `"changed line"`) {
+		t.Errorf("expected a line diff of the changed line, got %q", msg)
+	}
+	if strings.Contains(msg, "first line\\n") {
+		t.Errorf("expected unchanged lines not to be quoted with the change, got %q", msg)
+	}
+	if ok, _ := StringDiffComparator(strings.NewReader("hello"), strings.NewReader("hello\n")); ok {
+		t.Errorf("expected a trailing newline to be a difference")
+	}
+}
+
+func TestGitHubDiffComparator(t *testing.T) {
+	lines := func(from, to int) string {
+		buf := new(strings.Builder)
+		for i := from; i <= to; i++ {
+			fmt.Fprintf(buf, "line %d\n", i)
+		}
+		return buf.String()
+	}
+	tests := []struct {
+		name, expected, actual string
+		ok                     bool
+		msg                    string
+	}{
+		{name: "equal", expected: lines(1, 5), actual: lines(1, 5), ok: true},
+		{
+			name: "changed line", expected: lines(1, 10), actual: lines(1, 4) + "changed\n" + lines(6, 10),
+			msg: "--- expected\n+++ actual\n@@ -2,7 +2,7 @@\n line 2\n line 3\n line 4\n-line 5\n+changed\n line 6\n line 7\n line 8",
+		},
+		{
+			name: "separate hunks", expected: lines(1, 20), actual: "line 0\n" + lines(1, 19),
+			msg: "--- expected\n+++ actual\n@@ -1,3 +1,4 @@\n+line 0\n line 1\n line 2\n line 3" +
+				"\n@@ -17,4 +18,3 @@\n line 17\n line 18\n line 19\n-line 20",
+		},
+		{
+			name: "empty expected", expected: "", actual: "a\n",
+			msg: "--- expected\n+++ actual\n@@ -0,0 +1 @@\n+a",
+		},
+		{
+			name: "trailing newline", expected: "a\n", actual: "a",
+			msg: "actual is missing the trailing newline of expected",
+		},
+	}
+	for _, tt := range tests {
+		ok, msg := GitHubDiffComparator(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+		if ok != tt.ok || msg != tt.msg {
+			t.Errorf("%v: expected %v, %q, got %v, %q", tt.name, tt.ok, tt.msg, ok, msg)
+		}
+	}
+}
+
+func TestMatchDiff(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	before := "a\nb\nc\nd\ne\nf\ng\n"
+	after := "a\nb\nc\nD\ne\nf\ng\nh\n"
+	if ok, msg := MatchDiff(t, strings.NewReader(before), strings.NewReader(after), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	expected := "--- before\n+++ after\n@@ -1,7 +1,8 @@\n a\n b\n c\n-d\n+D\n e\n f\n g\n+h\n"
+	diffP := strings.TrimSuffix(outputP, ".txt") + ".diff"
+	if str := readAllUnchecked(openUnchecked(t, diffP)); str != expected {
+		t.Fatalf("unexpected snapshot contents. expected %q, got %q", expected, str)
+	}
+	if ok, msg := MatchDiff(t, strings.NewReader("x\n"+before), strings.NewReader("x\n"+after), dirOpt); ok {
+		t.Errorf("expected a shifted diff to fail")
+	} else if !strings.Contains(msg, "@@ -2,7 +2,8 @@") {
+		t.Errorf("expected the shifted hunk in the failure message: %v", msg)
+	}
+	t.Run("unchanged", func(t *testing.T) {
+		if ok, msg := MatchDiff(t, strings.NewReader("same\n"), strings.NewReader("same"), dirOpt); !ok {
+			t.Fatalf("expected first match to succeed: %v", msg)
+		}
+		p := filepath.Join(filepath.Dir(filepath.Dir(diffP)), "TestMatchDiff", "unchanged", "output.diff")
+		if str := readAllUnchecked(openUnchecked(t, p)); str != "" {
+			t.Errorf("expected an empty diff, got %q", str)
+		}
+	})
+}
+
+func TestCmpDiffUnchanged(t *testing.T) {
+	expected, actual := "first line\nsecond line\n", "first line\nchanged line\n"
+	diff, equal := CmpDiff(expected, actual)
+	if equal || diff != cmp.Diff(expected, actual) {
+		t.Errorf("expected CmpDiff to return cmp.Diff of the strings, got %v, %q", equal, diff)
+	}
+}
@@ -0,0 +1,54 @@
+package snapshot
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// directivePrefix marks the first line of an output snapshot file as a
+// directive rather than snapshot data.
+const directivePrefix = "# snapshot:"
+
+// Supported snapshot directives.
+const (
+	// DirectiveSkip causes Match to pass without comparing the snapshot.
+	// Any text following the directive is treated as the reason and logged.
+	DirectiveSkip = "skip"
+)
+
+// errMalformedDirective is returned by readDirective for a directive line
+// without a directive, so that it is reported rather than silently removed
+// from the snapshot data.
+var errMalformedDirective = errors.New(`malformed snapshot directive, expected "` + directivePrefix + ` <directive> [reason]"`)
+
+// readDirective checks whether the first line of r is a directive of the form
+// "# snapshot: <directive> [reason]". If so, the directive and reason are
+// returned along with a reader over the remaining data with the directive line
+// stripped. Otherwise directive is empty and rest yields all data from r. A
+// directive line without a directive fails with errMalformedDirective.
+func readDirective(r io.Reader) (directive, reason string, rest io.Reader, err error) {
+	br := bufio.NewReader(r)
+	rest = br
+	prefix, err := br.Peek(len(directivePrefix))
+	if err == io.EOF {
+		err = nil
+		return
+	}
+	if err != nil || string(prefix) != directivePrefix {
+		return
+	}
+	line, err := br.ReadString('\n')
+	if err == io.EOF {
+		err = nil
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, directivePrefix))
+	if len(fields) == 0 {
+		err = errMalformedDirective
+		return
+	}
+	directive = fields[0]
+	reason = strings.Join(fields[1:], " ")
+	return
+}
@@ -0,0 +1,78 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadDirective(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		directive string
+		reason    string
+		rest      string
+	}{
+		{
+			name:  "no directive",
+			input: "hello\nworld",
+			rest:  "hello\nworld",
+		},
+		{
+			name:  "empty input",
+			input: "",
+			rest:  "",
+		},
+		{
+			name:      "directive is stripped",
+			input:     "# snapshot: skip\nhello",
+			directive: "skip",
+			rest:      "hello",
+		},
+		{
+			name:      "directive with reason",
+			input:     "# snapshot: skip TODO: flaky output\nhello",
+			directive: "skip",
+			reason:    "TODO: flaky output",
+			rest:      "hello",
+		},
+		{
+			name:      "directive without trailing newline",
+			input:     "# snapshot: skip",
+			directive: "skip",
+			rest:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			directive, reason, rest, err := readDirective(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if directive != tt.directive {
+				t.Errorf("expected directive %q, got %q", tt.directive, directive)
+			}
+			if reason != tt.reason {
+				t.Errorf("expected reason %q, got %q", tt.reason, reason)
+			}
+			if str := readAllUnchecked(rest); str != tt.rest {
+				t.Errorf("expected rest %q, got %q", tt.rest, str)
+			}
+		})
+	}
+}
+
+func TestMalformedDirective(t *testing.T) {
+	for _, input := range []string{"# snapshot:\nhello", "# snapshot:   "} {
+		if _, _, _, err := readDirective(strings.NewReader(input)); err != errMalformedDirective {
+			t.Errorf("%q: expected %v, got %v", input, errMalformedDirective, err)
+		}
+	}
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	writeSnapshotFile(t, outputP, "# snapshot:\nhello")
+	expectedMsg := `malformed snapshot directive, expected "# snapshot: <directive> [reason]" in ` + outputP
+	if ok, msg := Match(t, strings.NewReader("hello"), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %q, got %v, %q", expectedMsg, ok, msg)
+	}
+}
@@ -0,0 +1,135 @@
+package snapshot
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// encryptedExtension is appended to the file extension of snapshots stored
+// with WithEncryption.
+const encryptedExtension = ".enc"
+
+// WithEncryption stores the snapshot file encrypted with key using AES-GCM,
+// with ".enc" appended to the file extension, e.g. "output.json.enc". The key
+// must be 16, 24 or 32 bytes, to select AES-128, AES-192 or AES-256, and any
+// other key is reported before the snapshot file is created. The data
+// is decrypted when the snapshot is read, so that normalisers, comparators
+// and the test see the plaintext. This allows fixtures containing sensitive
+// data to be committed, with the key provided by the environment, e.g.
+//
+//	key, _ := hex.DecodeString(os.Getenv("SNAPSHOT_KEY"))
+//	snapshot.Match(t, actual, snapshot.WithEncryption(key))
+//
+// As the ciphertext is binary, this may be combined with WithBase64Encoding.
+// A new random nonce is used each time the snapshot is written.
+func WithEncryption(key []byte) SnapshotOption {
+	return withEncryption{append([]byte(nil), key...)}
+}
+
+type withEncryption struct {
+	key []byte
+}
+
+func (wo withEncryption) ApplyInputOption(o *GetTestInputOptions) {
+	o.EncryptionKey = wo.key
+}
+
+func (wo withEncryption) ApplyMatchOption(o *MatchOptions) {
+	o.EncryptionKey = wo.key
+}
+
+// newGCM returns the AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// checkEncryptionKey returns an error if key is set but is not a valid AES
+// key, so that an invalid key is reported before the snapshot file is
+// created, rather than leaving an empty file when the data is encrypted.
+func checkEncryptionKey(key []byte) error {
+	if key == nil {
+		return nil
+	}
+	_, err := newGCM(key)
+	return err
+}
+
+// encryptWriter buffers the plaintext written to it and, on Close, writes the
+// nonce followed by the AES-GCM ciphertext to w, which is then closed.
+type encryptWriter struct {
+	w   io.WriteCloser
+	key []byte
+	buf bytes.Buffer
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	return e.buf.Write(p)
+}
+
+func (e *encryptWriter) Close() error {
+	gcm, err := newGCM(e.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	if _, err = e.w.Write(gcm.Seal(nonce, nonce, e.buf.Bytes(), nil)); err != nil {
+		return err
+	}
+	return e.w.Close()
+}
+
+// errCiphertextTooShort is returned when decrypting a snapshot which is too
+// short to hold a nonce.
+var errCiphertextTooShort = errors.New("encrypted snapshot is too short")
+
+// decryptReader reads and decrypts the whole of r, as written by
+// encryptWriter, on the first Read, then returns the plaintext.
+type decryptReader struct {
+	r         io.Reader
+	key       []byte
+	plaintext io.Reader
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	if d.plaintext == nil {
+		b, err := decrypt(d.r, d.key)
+		if err != nil {
+			d.plaintext = errReader{err}
+		} else {
+			d.plaintext = bytes.NewReader(b)
+		}
+	}
+	return d.plaintext.Read(p)
+}
+
+// decrypt decrypts the whole of r, as written by encryptWriter, with key.
+func decrypt(r io.Reader, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < gcm.NonceSize() {
+		return nil, errCiphertextTooShort
+	}
+	plaintext, err := gcm.Open(nil, b[:gcm.NonceSize()], b[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+	return plaintext, nil
+}
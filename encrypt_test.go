@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithEncryption(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	key := bytes.Repeat([]byte{1}, 32)
+	if ok, msg := Match(t, strings.NewReader("secret"), WithEncryption(key), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	b, err := os.ReadFile(outputP + ".enc")
+	if err != nil {
+		t.Fatalf("failed to read encrypted snapshot: %v", err)
+	}
+	if bytes.Contains(b, []byte("secret")) {
+		t.Errorf("expected snapshot to be encrypted, got %q", b)
+	}
+	if ok, msg := Match(t, strings.NewReader("secret"), WithEncryption(key), dirOpt); !ok {
+		t.Errorf("expected same plaintext to match: %v", msg)
+	}
+	expectedMsg := `expected "secret", got "public"`
+	if ok, msg := Match(t, strings.NewReader("public"), WithEncryption(key), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+	wrongKey := bytes.Repeat([]byte{2}, 32)
+	ok, msg := Match(t, strings.NewReader("secret"), WithEncryption(wrongKey), dirOpt)
+	if ok || !strings.Contains(msg, "failed to decrypt snapshot") {
+		t.Errorf("expected decryption to fail, got %v, %q", ok, msg)
+	}
+}
+
+func TestWithEncryptionInput(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	opts := []GetTestInputOption{WithEncryption([]byte("0123456789abcdef")), WithBase64Encoding(), dirOpt}
+	input := GetTestInput(t, append(opts, WithDefaultInput("secret"))...)
+	if str := readAllUnchecked(input); str != "secret" {
+		t.Fatalf("expected %q, got %q", "secret", str)
+	}
+	p := filepath.Join(filepath.Dir(inputP), "input.txt.enc.b64")
+	if str := readAllUnchecked(openUnchecked(t, p)); strings.Contains(str, "secret") || !strings.HasSuffix(str, "\n") {
+		t.Errorf("expected base64 encoded ciphertext, got %q", str)
+	}
+	if str := readAllUnchecked(GetTestInput(t, opts...)); str != "secret" {
+		t.Errorf("expected existing input to be decrypted. expected %q, got %q", "secret", str)
+	}
+}
+
+func TestWithEncryptionInvalidKey(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	key := []byte("short")
+	expectedMsg := "invalid snapshot encryption key: crypto/aes: invalid key size 5"
+	if ok, msg := Match(t, strings.NewReader("secret"), WithEncryption(key), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+	if _, err := os.Stat(outputP + ".enc"); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshot file to be created: %v", err)
+	}
+	input := GetTestInput(t, WithEncryption(key), WithDefaultInput("secret"), dirOpt)
+	if _, err := io.ReadAll(input); err == nil || err.Error() != expectedMsg {
+		t.Errorf("expected %q, got %v", expectedMsg, err)
+	}
+	if _, err := os.Stat(inputP + ".enc"); !os.IsNotExist(err) {
+		t.Errorf("expected no input snapshot file to be created: %v", err)
+	}
+}
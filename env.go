@@ -0,0 +1,85 @@
+package snapshot
+
+import (
+	"os"
+	"sync"
+	"testing"
+)
+
+// snapshotsEnv is the environment variable which enables or disables snapshot
+// testing for the whole package.
+const snapshotsEnv = "SNAPSHOTS"
+
+// The values of the SNAPSHOTS environment variable.
+const (
+	// snapshotsOn runs snapshot tests. This is the default when SNAPSHOTS is
+	// unset or empty.
+	snapshotsOn = "on"
+	// snapshotsOff skips every test which matches or reads a snapshot.
+	snapshotsOff = "off"
+	// snapshotsVerifyClean runs snapshot tests, but fails any test which
+	// would create or overwrite a snapshot.
+	snapshotsVerifyClean = "verify-clean"
+)
+
+// snapshotsMode returns the value of the SNAPSHOTS environment variable, or on
+// if it is unset. The test fails if the value is not recognised.
+func snapshotsMode(t testing.TB) string {
+	switch mode := os.Getenv(snapshotsEnv); mode {
+	case "":
+		return snapshotsOn
+	case snapshotsOn, snapshotsOff, snapshotsVerifyClean:
+		return mode
+	default:
+		t.Fatalf("invalid %v mode %q, expected on, off or verify-clean", snapshotsEnv, mode)
+		return snapshotsOn
+	}
+}
+
+// skipIfSnapshotsDisabled skips the test if snapshot testing is disabled with
+// SNAPSHOTS=off, e.g. `SNAPSHOTS=off go test ./...`, so that expensive
+// snapshot tests, especially those with a SnapshotCreator, can be left out of
+// quick local iterations without testing.Short checks in each test. The
+// -update-snapshots flag takes precedence: if its mode is new or all the test
+// is run, so that an update is never silently skipped.
+func skipIfSnapshotsDisabled(t testing.TB) {
+	t.Helper()
+	if snapshotsMode(t) == snapshotsOff && snapshotUpdateMode(t) == updateOff {
+		t.Skipf("snapshot tests are disabled by %v=%v", snapshotsEnv, snapshotsOff)
+	}
+}
+
+// forbiddenChanges records the snapshots which tests would have created or
+// overwritten under SNAPSHOTS=verify-clean, for PrintSummary. It is guarded by
+// a mutex as tests may run in parallel.
+var forbiddenChanges struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// checkSnapshotChange fails the test if SNAPSHOTS=verify-clean, e.g.
+// `SNAPSHOTS=verify-clean go test ./...` in CI, as the snapshot at p would
+// otherwise be changed as described by change, such as "created". This guards
+// against snapshots which drift from the code or updates which are not
+// committed. Unlike NoAutoCreate, it also applies with -update-snapshots, so
+// that no snapshot is overwritten. Existing snapshots are still read and
+// compared. The path is recorded for PrintSummary.
+func checkSnapshotChange(t testing.TB, p, change string) {
+	t.Helper()
+	if snapshotsMode(t) != snapshotsVerifyClean {
+		return
+	}
+	forbiddenChanges.mu.Lock()
+	forbiddenChanges.paths = append(forbiddenChanges.paths, p)
+	forbiddenChanges.mu.Unlock()
+	t.Fatalf("snapshot %v would be %v, but %v=%v forbids changes to snapshots: update the snapshots and commit them",
+		p, change, snapshotsEnv, snapshotsVerifyClean)
+}
+
+// forbiddenChangePaths returns a copy of the paths recorded by
+// checkSnapshotChange.
+func forbiddenChangePaths() []string {
+	forbiddenChanges.mu.Lock()
+	defer forbiddenChanges.mu.Unlock()
+	return append([]string(nil), forbiddenChanges.paths...)
+}
@@ -0,0 +1,100 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotsOff(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	t.Setenv(snapshotsEnv, snapshotsOff)
+	ran := false
+	t.Run("match", func(t *testing.T) {
+		Match(t, strings.NewReader("hello"), dirOpt)
+		ran = true
+	})
+	if ran {
+		t.Errorf("expected Match to skip the test")
+	}
+	t.Run("input", func(t *testing.T) {
+		GetTestInput(t, dirOpt, WithCreateSnapshot(func() (io.Reader, error) {
+			t.Errorf("expected the creator not to be called")
+			return strings.NewReader("input"), nil
+		}))
+		ran = true
+	})
+	if ran {
+		t.Errorf("expected GetTestInput to skip the test")
+	}
+
+	setUpdateSnapshots(t, updateNew)
+	if ok, msg := Match(t, strings.NewReader("hello"), dirOpt); !ok {
+		t.Errorf("expected -update-snapshots=new to run the snapshot test: %v", msg)
+	}
+}
+
+// fatalRecorder is a testing.TB which records the message of Fatalf rather
+// than stopping the test, so that expected failures can be asserted.
+type fatalRecorder struct {
+	testing.TB
+	msg string
+}
+
+func (r *fatalRecorder) Fatalf(format string, args ...any) {
+	r.msg = fmt.Sprintf(format, args...)
+}
+
+// resetForbiddenChanges clears the paths recorded by checkSnapshotChange for
+// the duration of the test.
+func resetForbiddenChanges(t *testing.T) {
+	forbiddenChanges.mu.Lock()
+	saved := forbiddenChanges.paths
+	forbiddenChanges.paths = nil
+	forbiddenChanges.mu.Unlock()
+	t.Cleanup(func() {
+		forbiddenChanges.mu.Lock()
+		forbiddenChanges.paths = saved
+		forbiddenChanges.mu.Unlock()
+	})
+}
+
+func TestSnapshotsVerifyClean(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	if ok, msg := Match(t, strings.NewReader("hello"), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+
+	t.Setenv(snapshotsEnv, snapshotsVerifyClean)
+	resetSummary(t)
+	resetForbiddenChanges(t)
+	if ok, msg := Match(t, strings.NewReader("hello"), dirOpt); !ok {
+		t.Errorf("expected an existing snapshot to be matched: %v", msg)
+	}
+
+	r := &fatalRecorder{TB: t}
+	checkSnapshotChange(r, "a/output.txt", "created")
+	expected := "snapshot a/output.txt would be created, but SNAPSHOTS=verify-clean forbids changes to snapshots: update the snapshots and commit them"
+	if r.msg != expected {
+		t.Errorf("expected %q, got %q", expected, r.msg)
+	}
+
+	setUpdateSnapshots(t, updateAll)
+	s := newMemStore()
+	s.files["b/output.txt"] = []byte("hello")
+	r = &fatalRecorder{TB: t}
+	if file, err := openSnapshotFileForUpdate(r, s, "b/output.txt", "", true); err == nil {
+		_ = file.Close()
+	}
+	if !strings.Contains(r.msg, "snapshot b/output.txt would be overwritten") {
+		t.Errorf("expected -update-snapshots=all to be forbidden, got %q", r.msg)
+	}
+
+	buf := new(strings.Builder)
+	PrintSummary(buf)
+	expected = "snapshots: 1 matched, 0 created, 0 failed\nsnapshots which would change with SNAPSHOTS=verify-clean:\n  a/output.txt\n  b/output.txt\n"
+	if str := buf.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+}
@@ -16,31 +16,31 @@ var letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 // which kindly also exposes a version number for their library.
 const EARVersion = "v0.2.0"
 
-func ExpensiveAsyncronousRandomOperation() (out io.Reader, err error) {
+func ExpensiveAsyncronousRandomOperation(r *rand.Rand) io.Reader {
 	out, in := io.Pipe()
 	go func() {
 		for i := 1; i < 1000; i++ {
-			n := rand.Intn(len(letters))
+			n := r.Intn(len(letters))
 			c := letters[n]
 			in.Write([]byte{c})
 			time.Sleep(2 * time.Millisecond)
 		}
 		in.Close()
 	}()
-	return
+	return out
 }
 
 func TestEAR(t *testing.T) {
-	rand.Seed(time.Now().UnixNano())
-
 	// First time this test runs, this will take ~2 seconds. However, the
 	// the input data is persisted to disk and reused on subsequent runs. This
 	// allows for fast, repeatable tests that require complex input data
 	// without having to manually create it. This should be used with custom
 	// snapshot names based on versions so that a new snapshot is created
-	// and breaking changes can be detected between versions.
+	// and breaking changes can be detected between versions. The random
+	// source is seeded deterministically, so that recording the snapshot
+	// again produces the same data.
 	input := snapshot.GetTestInput(t,
-		snapshot.WithCreateSnapshot(ExpensiveAsyncronousRandomOperation),
+		snapshot.WithCreateSnapshot(snapshot.DeterministicReader(ExpensiveAsyncronousRandomOperation, 42)),
 		snapshot.WithSnapshotName("ear_"+EARVersion),
 	)
 	buf := new(strings.Builder)
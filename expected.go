@@ -0,0 +1,14 @@
+package snapshot
+
+import "fmt"
+
+// invertExpectedFailure inverts the result of a comparison that is expected to
+// fail for reason, as configured with WithExpectedFailure. An expected
+// mismatch is logged to logf.
+func invertExpectedFailure(logf Logger, reason string, ok bool, msg string) (bool, string) {
+	if ok {
+		return false, fmt.Sprintf("snapshot matched but was expected to fail: %v", reason)
+	}
+	logf("snapshot mismatch is an expected failure (%v): %v", reason, msg)
+	return true, ""
+}
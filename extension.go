@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	extensionComparatorsMu sync.RWMutex
+	extensionComparators   = map[string]Comparator{}
+	extensionNormalisersMu sync.RWMutex
+	extensionNormalisers   = map[string]ReaderNormaliser{}
+)
+
+// normaliseExtension returns ext with a leading ".", so that "txt" and ".txt"
+// name the same file extension. The empty extension is returned unmodified.
+func normaliseExtension(ext string) string {
+	if ext == "" || strings.HasPrefix(ext, ".") {
+		return ext
+	}
+	return "." + ext
+}
+
+// SetComparatorForExtension registers cmp as the default Comparator of Match
+// for snapshots with the file extension ext, e.g. ".json", which may omit the
+// leading ".". The comparator is
+// chosen from the resolved file extension, and is overridden by an explicit
+// comparator option such as WithComparator. Passing a nil cmp removes the
+// registration. This is typically called from TestMain or an init function.
+func SetComparatorForExtension(ext string, cmp Comparator) {
+	extensionComparatorsMu.Lock()
+	defer extensionComparatorsMu.Unlock()
+	ext = normaliseExtension(ext)
+	if cmp == nil {
+		delete(extensionComparators, ext)
+		return
+	}
+	extensionComparators[ext] = cmp
+}
+
+// comparatorForExtension returns the Comparator registered for ext with
+// SetComparatorForExtension, or, if there is none, the comparator of the
+// project config file or StringComparator.
+func comparatorForExtension(ext string) Comparator {
+	extensionComparatorsMu.RLock()
+	defer extensionComparatorsMu.RUnlock()
+	if cmp, ok := extensionComparators[normaliseExtension(ext)]; ok {
+		return cmp
+	}
+	if cmp := configDefaults().comparator; cmp != nil {
+		return cmp
+	}
+	return StringComparator
+}
+
+// SetNormaliserForExtension registers rn as the default ReaderNormaliser of
+// Match for snapshots with the file extension ext, as for
+// SetComparatorForExtension, so that each format is normalised appropriately
+// without passing WithReaderNormaliser to every call, e.g.
+//
+//	snapshot.SetNormaliserForExtension(".json", snapshot.CanonicalJSONNormaliser)
+//	snapshot.SetNormaliserForExtension(".svg", snapshot.HTMLNormaliser)
+//
+// No normalisers are registered by default. The normaliser is chosen from the
+// resolved file extension, and is overridden by WithReaderNormaliser. Passing
+// a nil rn removes the registration. This is typically called from TestMain or
+// an init function.
+func SetNormaliserForExtension(ext string, rn ReaderNormaliser) {
+	extensionNormalisersMu.Lock()
+	defer extensionNormalisersMu.Unlock()
+	ext = normaliseExtension(ext)
+	if rn == nil {
+		delete(extensionNormalisers, ext)
+		return
+	}
+	extensionNormalisers[ext] = rn
+}
+
+// normaliserForExtension returns the ReaderNormaliser registered for ext with
+// SetNormaliserForExtension, or NopReaderNormaliser if there is none.
+func normaliserForExtension(ext string) ReaderNormaliser {
+	extensionNormalisersMu.RLock()
+	defer extensionNormalisersMu.RUnlock()
+	if rn, ok := extensionNormalisers[normaliseExtension(ext)]; ok {
+		return rn
+	}
+	return NopReaderNormaliser
+}
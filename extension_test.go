@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetComparatorForExtension(t *testing.T) {
+	SetComparatorForExtension(".json", JSONComparator)
+	t.Cleanup(func() { SetComparatorForExtension(".json", nil) })
+	fail := func(expected, actual io.Reader) (bool, string) { return false, "fail" }
+
+	tests := []struct {
+		name   string
+		optFns []MatchOption
+		ok     bool
+	}{
+		{"registered extension", []MatchOption{WithSnapshotFileExtension(".json")}, true},
+		{"registered extension without dot", []MatchOption{WithExtension("json")}, true},
+		{"other extension", []MatchOption{WithSnapshotFileExtension(".txt")}, false},
+		{"explicit comparator", []MatchOption{WithSnapshotFileExtension(".json"), WithComparator(fail)}, false},
+		{"comparator before extension", []MatchOption{WithComparator(fail), WithSnapshotFileExtension(".json")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := Compare(strings.NewReader(`{"a": 1}`), strings.NewReader(`{"a":1}`), tt.optFns...)
+			if ok != tt.ok {
+				t.Errorf("expected %v, got %v, %q", tt.ok, ok, msg)
+			}
+		})
+	}
+}
+
+func TestSetNormaliserForExtension(t *testing.T) {
+	SetNormaliserForExtension("page", HTMLNormaliser)
+	t.Cleanup(func() { SetNormaliserForExtension(".page", nil) })
+	expected, actual := "<p>a</p>\n<p>b</p>", "<p>a</p><p>b</p>"
+
+	tests := []struct {
+		name   string
+		optFns []MatchOption
+		ok     bool
+	}{
+		{"registered extension", []MatchOption{WithSnapshotFileExtension(".page")}, true},
+		{"other extension", []MatchOption{WithSnapshotFileExtension(".txt")}, false},
+		{"explicit normaliser", []MatchOption{WithSnapshotFileExtension(".page"), WithReaderNormaliser(NopReaderNormaliser)}, false},
+		{"normaliser before extension", []MatchOption{WithReaderNormaliser(NopReaderNormaliser), WithSnapshotFileExtension(".page")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := Compare(strings.NewReader(expected), strings.NewReader(actual), tt.optFns...)
+			if ok != tt.ok {
+				t.Errorf("expected %v, got %v, %q", tt.ok, ok, msg)
+			}
+		})
+	}
+
+	SetNormaliserForExtension(".page", nil)
+	if ok, _ := Compare(strings.NewReader(expected), strings.NewReader(actual), WithSnapshotFileExtension(".page")); ok {
+		t.Errorf("expected the registration to be removed")
+	}
+}
+
+func TestCanonicalJSONNormaliserForExtension(t *testing.T) {
+	expected, actual := `{"a": 1, "b": [1.50, "x"]}`, "{\"b\":[1.50,\"x\"],\"a\":1}"
+	if ok, _ := Compare(strings.NewReader(expected), strings.NewReader(actual), WithSnapshotFileExtension(".json")); ok {
+		t.Errorf("expected no normaliser to be registered for .json by default")
+	}
+	SetNormaliserForExtension(".json", CanonicalJSONNormaliser)
+	t.Cleanup(func() { SetNormaliserForExtension(".json", nil) })
+	tests := []struct {
+		name             string
+		expected, actual string
+		ok               bool
+	}{
+		{"reformatted", expected, actual, true},
+		{"difference", `{"a": 1}`, `{"a": 2}`, false},
+		{"invalid", `{"a": 1`, `{"a":1`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := Compare(strings.NewReader(tt.expected), strings.NewReader(tt.actual), WithSnapshotFileExtension(".json"))
+			if ok != tt.ok {
+				t.Errorf("expected %v, got %v, %q", tt.ok, ok, msg)
+			}
+		})
+	}
+}
+
+func TestCanonicalJSONNormaliser(t *testing.T) {
+	expected := "{\n  \"a\": 1.50,\n  \"b\": null\n}\n"
+	if str := readAllUnchecked(CanonicalJSONNormaliser(strings.NewReader(`{"b":null,"a":1.50}`))); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+	if str := readAllUnchecked(CanonicalJSONNormaliser(strings.NewReader("not json"))); str != "not json" {
+		t.Errorf("expected invalid JSON to be passed through, got %q", str)
+	}
+}
+
+func TestExtensionLeadingDot(t *testing.T) {
+	for _, ext := range []string{"json", ".json"} {
+		t.Run(ext, func(t *testing.T) {
+			dirOpt, inputP, outputP := getInputOutputPaths(t)
+			dir := filepath.Dir(inputP)
+			input := GetTestInput(t, WithDefaultInput("{}"), WithExtension(ext), dirOpt)
+			if str := readAllUnchecked(input); str != "{}" {
+				t.Fatalf("expected %q, got %q", "{}", str)
+			}
+			if ok, msg := Match(t, strings.NewReader("{}"), WithSnapshotFileExtension(ext), dirOpt); !ok {
+				t.Fatalf("expected match to succeed: %v", msg)
+			}
+			for _, p := range []string{filepath.Join(dir, "input.json"), strings.TrimSuffix(outputP, ".txt") + ".json"} {
+				if str := readAllUnchecked(openUnchecked(t, p)); str != "{}" {
+					t.Errorf("expected snapshot %v to contain %q, got %q", p, "{}", str)
+				}
+			}
+		})
+	}
+}
+
+func TestNormaliseExtension(t *testing.T) {
+	for ext, expected := range map[string]string{"": "", "txt": ".txt", ".txt": ".txt", "tar.gz": ".tar.gz"} {
+		if got := normaliseExtension(ext); got != expected {
+			t.Errorf("%q: expected %q, got %q", ext, expected, got)
+		}
+	}
+}
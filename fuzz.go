@@ -0,0 +1,65 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// SeedFuzzFromSnapshots adds the contents of recorded input snapshots to the
+// seed corpus of f, so that fixtures recorded with GetTestInput are reused for
+// fuzzing. The __snapshots__ directory at the same level as the file containing
+// the fuzz test is searched recursively for files named
+// <SnapshotName><FileExtension>, which defaults to "input.txt". SnapshotName
+// may be a glob pattern as accepted by filepath.Match, e.g. using
+// WithSnapshotName("ear_*"). Each file is added with f.Add as a single []byte
+// argument, so the fuzz target must accept a single []byte argument.
+func SeedFuzzFromSnapshots(f *testing.F, optFns ...GetTestInputOption) {
+	opts := newGetTestInputOptions(optFns)
+	dir := resolveSnapshotBaseDir(f, 0, opts.pathOptions())
+	paths, err := findSnapshotFiles(dir, opts.SnapshotName+opts.pathOptions().ext)
+	if err != nil {
+		f.Fatalf("failed to find input snapshots in %v: %v", dir, err.Error())
+	}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err == nil {
+			b, err = io.ReadAll(decodeSnapshot(bytes.NewReader(b), opts.encoding()))
+		}
+		if err != nil {
+			f.Fatalf("failed to read input snapshot %v: %v", p, err.Error())
+		}
+		resolveLogger(f, opts.Logger)("seeding fuzz corpus from input snapshot: %v", p)
+		f.Add(b)
+	}
+}
+
+// findSnapshotFiles returns the sorted paths of all files within dir, at any
+// depth, whose name matches pattern. If dir does not exist, no paths are
+// returned.
+func findSnapshotFiles(dir, pattern string) (paths []string, err error) {
+	if _, err = filepath.Match(pattern, ""); err != nil {
+		return
+	}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if ok, _ := filepath.Match(pattern, d.Name()); ok {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		err = nil
+	}
+	sort.Strings(paths)
+	return
+}
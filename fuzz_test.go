@@ -0,0 +1,98 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindSnapshotFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"TestA/input.txt",
+		"TestA/output.txt",
+		"TestB/sub/input.txt",
+		"TestB/input.json",
+		"TestC/ear_v0.1.0.txt",
+	} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		dir      string
+		pattern  string
+		expected []string
+	}{
+		{
+			name:    "exact name",
+			dir:     dir,
+			pattern: "input.txt",
+			expected: []string{
+				filepath.Join(dir, "TestA/input.txt"),
+				filepath.Join(dir, "TestB/sub/input.txt"),
+			},
+		},
+		{
+			name:     "glob pattern",
+			dir:      dir,
+			pattern:  "ear_*.txt",
+			expected: []string{filepath.Join(dir, "TestC/ear_v0.1.0.txt")},
+		},
+		{
+			name:    "missing directory",
+			dir:     filepath.Join(dir, "missing"),
+			pattern: "input.txt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths, err := findSnapshotFiles(tt.dir, tt.pattern)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, paths); diff != "" {
+				t.Fatalf("unexpected paths: %v", diff)
+			}
+		})
+	}
+}
+
+func FuzzSeedFromSnapshots(f *testing.F) {
+	SeedFuzzFromSnapshots(f)
+	f.Add([]byte("a\r\nb\r\r\n\n"))
+	f.Add([]byte{0, 0xff, '\r'})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		normalised, err := ReadAll(LineEndingNormaliser(bytes.NewReader(b)))
+		expected := strings.ReplaceAll(string(b), "\r\n", "\n")
+		if err != nil || normalised != expected {
+			t.Errorf("expected the streamed line endings to be normalised as the whole data: %q, got %q, %v", expected, normalised, err)
+		}
+
+		enc := snapshotEncoding{base64: true, key: make([]byte, 16)}
+		buf := new(bytes.Buffer)
+		w := encodeSnapshot(buf, enc)
+		if _, err := w.Write(b); err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("failed to encode: %v", err)
+		}
+		decoded, err := io.ReadAll(decodeSnapshot(buf, enc))
+		if err != nil || !bytes.Equal(decoded, b) {
+			t.Errorf("expected the encoded snapshot to round-trip: %q, got %q, %v", b, decoded, err)
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"io"
+	"testing"
+)
+
+// Generate writes actual to the output snapshot for a particular test case,
+// overwriting any existing snapshot, and never compares. The snapshot file is
+// resolved in the same way as Match, using the same options. This separates
+// recording snapshots from verifying them, for example:
+//
+//	var generate = flag.Bool("generate", false, "generate snapshots")
+//
+//	func TestOutput(t *testing.T) {
+//		if *generate {
+//			snapshot.Generate(t, render())
+//			return
+//		}
+//		ok, msg := snapshot.Match(t, render(), snapshot.WithNoAutoCreate())
+//		...
+//	}
+//
+// Snapshots can then be recorded with `go test -run TestOutput -args -generate`.
+func Generate(t *testing.T, actual io.Reader, optFns ...MatchOption) {
+	opts := newMatchOptions(optFns)
+	p := resolveSnapshotFilePath(t, 0, opts.pathOptions(), true)
+	logf := resolveLogger(t, opts.Logger)
+	logf("output snapshot filename: %v", p)
+	actual, err := prepareActual(actual, opts)
+	if err != nil {
+		t.Fatalf("failed to generate snapshot: %v", err.Error())
+	}
+	checkSnapshotChange(t, p, "generated")
+	logf("generating output snapshot")
+	unlock := lockSnapshotPath(p)
+	_ = writeOutputSnapshot(t, p, actual, opts)
+	unlock()
+	recordResult(t.Name(), p, true, true, "")
+}
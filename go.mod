@@ -1,9 +1,16 @@
 module github.com/deej-io/snapshot
 
-go 1.17
+go 1.21
 
-require github.com/google/go-cmp v0.5.7
-
-retract (
-	v0.1.2 // Incorrect copyright owner
+require (
+	github.com/google/go-cmp v0.5.7
+	github.com/itchyny/gojq v0.12.13
+	github.com/itchyny/timefmt-go v0.1.5 // indirect
+	github.com/ledongthuc/pdf v0.0.0-20240201131950-da5b75280b06
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/sergi/go-diff v1.3.1
+	golang.org/x/net v0.17.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+retract v0.1.2 // Incorrect copyright owner
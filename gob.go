@@ -0,0 +1,117 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// AsGob gob encodes i to the io.Reader. As with AsJSON, if i is a function it
+// is called and the result is encoded instead. The value is encoded as an
+// interface so that it can be decoded without knowing its type, therefore
+// the concrete type of i is registered with gob.Register. Any types nested
+// within interface fields must be registered using WithGobTypes. Note that
+// gob.Register modifies the process wide gob type registry, so registered
+// types remain registered for every subsequent gob encoding and decoding.
+func AsGob(i interface{}) (out io.Reader, err error) {
+	i, err = callIfFunc(i, "AsGob")
+	if err != nil {
+		return
+	}
+	if i == nil {
+		err = fmt.Errorf("cannot encode nil value as gob")
+		return
+	}
+	gob.Register(i)
+	buf := new(bytes.Buffer)
+	err = gob.NewEncoder(buf).Encode(&i)
+	if err != nil {
+		err = fmt.Errorf("failed to encode snapshot as gob: %w", err)
+		return
+	}
+	out = buf
+	return
+}
+
+// WithGobTypes registers the types of values with gob.Register, so that they
+// can be encoded and decoded when held in interface values. As with AsGob, the
+// registration is global to the process and is not undone when the test ends.
+func WithGobTypes(values ...interface{}) SnapshotOption {
+	return withGobTypes{values}
+}
+
+type withGobTypes struct {
+	values []interface{}
+}
+
+func (wo withGobTypes) register() {
+	for _, v := range wo.values {
+		gob.Register(v)
+	}
+}
+
+func (wo withGobTypes) ApplyInputOption(*GetTestInputOptions) {
+	wo.register()
+}
+
+func (wo withGobTypes) ApplyMatchOption(*MatchOptions) {
+	wo.register()
+}
+
+// decodeGob decodes a value encoded by AsGob from b.
+func decodeGob(b []byte) (v interface{}, err error) {
+	err = gob.NewDecoder(bytes.NewReader(b)).Decode(&v)
+	return
+}
+
+// GobComparator decodes the gob encoded expected and actual values, as written
+// by AsGob, and compares them with reflect.DeepEqual. On failure a diff of the
+// hex dumps of the encoded data is returned.
+func GobComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		msg = err.Error()
+		return
+	}
+	eValue, err := decodeGob(eBytes)
+	if err != nil {
+		msg = "failed to decode expected gob: " + err.Error()
+		return
+	}
+	aValue, err := decodeGob(aBytes)
+	if err != nil {
+		msg = "failed to decode actual gob: " + err.Error()
+		return
+	}
+	ok = reflect.DeepEqual(eValue, aValue)
+	if !ok {
+		msg = "decoded gob values differ:\n" + hexDumpDiff(eBytes, aBytes)
+	}
+	return
+}
+
+// hexDumpDiff returns the diff of the hex dumps of expected and actual.
+func hexDumpDiff(expected, actual []byte) string {
+	return cmp.Diff(hex.Dump(expected), hex.Dump(actual))
+}
+
+// HexDumpComparator compares the bytes of expected and actual exactly. On
+// failure a diff of the hex dumps of both is returned, which is useful for
+// binary snapshots.
+func HexDumpComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		msg = err.Error()
+		return
+	}
+	ok = bytes.Equal(eBytes, aBytes)
+	if !ok {
+		msg = hexDumpDiff(eBytes, aBytes)
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package snapshot
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+type gobTestStruct struct {
+	Name  string
+	Value interface{}
+}
+
+type gobUnexportedStruct struct {
+	value int
+}
+
+func TestGobComparator(t *testing.T) {
+	mk := func(v interface{}) func() gobTestStruct {
+		return func() gobTestStruct { return gobTestStruct{Name: "hello", Value: v} }
+	}
+	mustGob := func(v interface{}) io.Reader {
+		r, err := AsGob(v)
+		if err != nil {
+			t.Fatalf("failed to encode %v: %v", v, err)
+		}
+		return r
+	}
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	opts := []MatchOption{WithComparator(GobComparator), WithGobTypes(complex128(0)), WithExtension(".gob"), dirOpt}
+
+	if ok, msg := Match(t, mustGob(mk(complex(1, 2))), opts...); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(outputP, ".txt") + ".gob"); err != nil {
+		t.Fatalf("expected output snapshot with .gob extension: %v", err)
+	}
+	if ok, msg := Match(t, mustGob(mk(complex(1, 2))), opts...); !ok {
+		t.Errorf("expected equal values to match: %v", msg)
+	}
+	if ok, msg := Match(t, mustGob(mk(complex(2, 1))), opts...); ok || !strings.Contains(msg, "decoded gob values differ") {
+		t.Errorf("expected different values to fail, got %v, %q", ok, msg)
+	}
+}
+
+func TestAsGobError(t *testing.T) {
+	out, err := AsGob(gobUnexportedStruct{})
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to encode snapshot as gob: ") {
+		t.Fatalf("expected an encoding error, got %v", err)
+	}
+	if out != nil {
+		t.Errorf("expected no output on error, got %v", out)
+	}
+}
+
+func TestHexDumpComparator(t *testing.T) {
+	if ok, msg := HexDumpComparator(strings.NewReader("\x00\x01"), strings.NewReader("\x00\x01")); !ok {
+		t.Errorf("expected equal bytes to match: %v", msg)
+	}
+	if ok, msg := HexDumpComparator(strings.NewReader("\x00\x01"), strings.NewReader("\x00\x02")); ok || msg == "" {
+		t.Errorf("expected different bytes to fail with a diff, got %v, %q", ok, msg)
+	}
+}
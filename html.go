@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// canonicalHTML parses b as an HTML document and re-serialises it with any
+// whitespace-only text between tags removed.
+func canonicalHTML(b []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	removeWhitespaceText(doc)
+	buf := new(bytes.Buffer)
+	err = html.Render(buf, doc)
+	return buf.Bytes(), err
+}
+
+// removeWhitespaceText removes all whitespace-only text nodes from the tree
+// rooted at n, except within <pre> and <textarea> elements where whitespace is
+// significant.
+func removeWhitespaceText(n *html.Node) {
+	if n.Type == html.ElementNode && (n.Data == "pre" || n.Data == "textarea") {
+		return
+	}
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.TextNode && strings.TrimSpace(c.Data) == "" {
+			n.RemoveChild(c)
+		} else {
+			removeWhitespaceText(c)
+		}
+		c = next
+	}
+}
+
+// HTMLNormaliser is a ReaderNormaliser that parses the data as HTML using
+// golang.org/x/net/html and re-serialises it, removing whitespace between
+// tags. If the data cannot be parsed it is passed through unmodified.
+func HTMLNormaliser(r io.Reader) io.Reader {
+	return htmlNormaliser(nil)(r)
+}
+
+// htmlNormaliser creates a ReaderNormaliser as HTMLNormaliser. If logf is not
+// nil, data that cannot be parsed as HTML is noted with logf.
+func htmlNormaliser(logf Logger) ReaderNormaliser {
+	return func(r io.Reader) io.Reader {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return errReader{err}
+		}
+		canonical, err := canonicalHTML(b)
+		if err != nil {
+			if logf != nil {
+				logf("failed to parse HTML, falling back to raw comparison: %v", err)
+			}
+			return bytes.NewReader(b)
+		}
+		return bytes.NewReader(canonical)
+	}
+}
+
+// MatchHTML behaves as Match, but stores the snapshot with the ".html" file
+// extension and compares the expected and actual data after canonicalising
+// them as HTML, so that insignificant whitespace between tags is ignored. If
+// either side is not valid HTML, the raw data is compared instead. These
+// defaults may be overridden by optFns.
+func MatchHTML(t *testing.T, actual io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	optFns = append([]MatchOption{
+		WithSnapshotFileExtension(".html"),
+		MatchOptionFunc(func(o *MatchOptions) {
+			// The Logger is resolved when the normaliser logs, as it may be
+			// set by the options which are applied after this one.
+			o.ReaderNormaliser = htmlNormaliser(func(format string, args ...interface{}) {
+				resolveLogger(t, o.Logger)(format, args...)
+			})
+		}),
+	}, optFns...)
+	return match(t, 1, actual, optFns...)
+}
@@ -0,0 +1,36 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMatchHTML(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	first := "<ul>\n  <li>hello</li>\n  <li>world</li>\n</ul>\n"
+	if ok, msg := MatchHTML(t, strings.NewReader(first), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	htmlP := strings.TrimSuffix(outputP, ".txt") + ".html"
+	if str := readAllUnchecked(openUnchecked(t, htmlP)); str != first {
+		t.Fatalf("unexpected snapshot contents: %q", str)
+	}
+	if ok, msg := MatchHTML(t, strings.NewReader("<ul><li>hello</li><li>world</li></ul>"), dirOpt); !ok {
+		t.Errorf("expected whitespace between tags to be ignored: %v", msg)
+	}
+	if ok, _ := MatchHTML(t, strings.NewReader("<ul><li>hello world</li></ul>"), dirOpt); ok {
+		t.Errorf("expected different HTML to fail")
+	}
+}
+
+func TestMatchHTMLAppliesOptionsOnce(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	calls := 0
+	count := MatchOptionFunc(func(*MatchOptions) { calls++ })
+	if ok, msg := MatchHTML(t, strings.NewReader("<p>hello</p>"), count, dirOpt); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	if calls != 1 {
+		t.Errorf("expected the option to be applied once, got %d", calls)
+	}
+}
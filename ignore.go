@@ -0,0 +1,79 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// IgnoredPlaceholder replaces regions of the snapshot data that are ignored
+// by comparators such as IgnoreRegexComparator.
+const IgnoredPlaceholder = "<IGNORED>"
+
+// IgnoreRegexComparator creates a Comparator that replaces all matches of
+// patterns in both expected and actual with IgnoredPlaceholder before
+// delegating to base. Unlike a ReaderNormaliser applied on creation, the stored
+// snapshot keeps the real values.
+func IgnoreRegexComparator(base Comparator, patterns ...*regexp.Regexp) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		eBytes, aBytes, err := readBoth(expected, actual)
+		if err != nil {
+			msg = err.Error()
+			return
+		}
+		for _, re := range patterns {
+			eBytes = re.ReplaceAllLiteral(eBytes, []byte(IgnoredPlaceholder))
+			aBytes = re.ReplaceAllLiteral(aBytes, []byte(IgnoredPlaceholder))
+		}
+		return base(bytes.NewReader(eBytes), bytes.NewReader(aBytes))
+	}
+}
+
+// IgnoreLinesComparator creates a Comparator that replaces the lines within
+// ranges in both expected and actual with IgnoredPlaceholder before delegating
+// to base. Each range is the first and last line to ignore, inclusive and
+// numbered from 1, e.g. [2]int{2, 2} ignores a timestamp that is always on the
+// second line. This is simpler than IgnoreRegexComparator for noise at fixed
+// positions. The comparison fails if a range is invalid or extends beyond the
+// last line of either side.
+func IgnoreLinesComparator(base Comparator, ranges ...[2]int) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		eBytes, aBytes, err := readBoth(expected, actual)
+		if err != nil {
+			msg = err.Error()
+			return
+		}
+		eStr, err := ignoreLines(string(eBytes), ranges)
+		if err != nil {
+			msg = "expected: " + err.Error()
+			return
+		}
+		aStr, err := ignoreLines(string(aBytes), ranges)
+		if err != nil {
+			msg = "actual: " + err.Error()
+			return
+		}
+		return base(strings.NewReader(eStr), strings.NewReader(aStr))
+	}
+}
+
+// ignoreLines replaces the lines of s within ranges with IgnoredPlaceholder,
+// as for IgnoreLinesComparator.
+func ignoreLines(s string, ranges [][2]int) (string, error) {
+	n := len(splitLines(s))
+	lines := strings.Split(s, "\n")
+	for _, r := range ranges {
+		if r[0] < 1 || r[1] < r[0] {
+			return "", fmt.Errorf("invalid ignored line range %d-%d", r[0], r[1])
+		}
+		if r[1] > n {
+			return "", fmt.Errorf("ignored line range %d-%d exceeds the %d lines of the snapshot", r[0], r[1], n)
+		}
+		for i := r[0] - 1; i < r[1]; i++ {
+			lines[i] = IgnoredPlaceholder
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRegexComparator(t *testing.T) {
+	cmp := IgnoreRegexComparator(StringComparator,
+		regexp.MustCompile(`id=\d+`),
+		regexp.MustCompile(`\d{4}-\d{2}-\d{2}`),
+	)
+	expected := "created id=1 on 2021-01-01"
+	if ok, msg := cmp(strings.NewReader(expected), strings.NewReader("created id=42 on 2022-03-04")); !ok {
+		t.Errorf("expected ignored regions to match: %v", msg)
+	}
+	expectedMsg := `expected "created <IGNORED> on <IGNORED>", got "deleted <IGNORED> on <IGNORED>"`
+	if ok, msg := cmp(strings.NewReader(expected), strings.NewReader("deleted id=42 on 2022-03-04")); ok || msg != expectedMsg {
+		t.Errorf("expected comparison to fail with %q, got %v, %q", expectedMsg, ok, msg)
+	}
+}
+
+func TestIgnoreLinesComparator(t *testing.T) {
+	cmp := IgnoreLinesComparator(StringComparator, [2]int{1, 2}, [2]int{4, 4})
+	cases := []struct {
+		name, expected, actual string
+		ok                     bool
+		msg                    string
+	}{
+		{name: "ignored", expected: "a\nb\nbody\nc\n", actual: "x\ny\nbody\nz\n", ok: true},
+		{
+			name: "body differs", expected: "a\nb\nbody\nc\n", actual: "a\nb\nother\nc\n",
+			msg: `expected "<IGNORED>\n<IGNORED>\nbody\n<IGNORED>\n", got "<IGNORED>\n<IGNORED>\nother\n<IGNORED>\n"`,
+		},
+		{
+			name: "too short", expected: "a\nb\nbody\nc\n", actual: "a\nb\nbody\n",
+			msg: "actual: ignored line range 4-4 exceeds the 3 lines of the snapshot",
+		},
+	}
+	for _, tc := range cases {
+		ok, msg := cmp(strings.NewReader(tc.expected), strings.NewReader(tc.actual))
+		if ok != tc.ok || msg != tc.msg {
+			t.Errorf("%v: expected %v, %q, got %v, %q", tc.name, tc.ok, tc.msg, ok, msg)
+		}
+	}
+	ok, msg := IgnoreLinesComparator(StringComparator, [2]int{3, 2})(strings.NewReader("a"), strings.NewReader("a"))
+	if expectedMsg := "expected: invalid ignored line range 3-2"; ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
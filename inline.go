@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"io"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// InlineDiff is a DiffFunc which produces a character level inline diff using
+// go-diff, in the style of `git diff --word-diff`. Deleted text is wrapped as
+// [-text-] and inserted text as {+text+}, e.g.
+//
+//	https://example.com/?q=[-old-]{+new+}&page=1
+//
+// This makes small changes within long lines, such as single-line JSON or
+// URLs, easy to spot.
+func InlineDiff(expected, actual string) (diff string, equal bool) {
+	if expected == actual {
+		equal = true
+		return
+	}
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffCleanupSemantic(dmp.DiffMain(expected, actual, false))
+	buf := new(strings.Builder)
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			buf.WriteString("[-" + d.Text + "-]")
+		case diffmatchpatch.DiffInsert:
+			buf.WriteString("{+" + d.Text + "+}")
+		default:
+			buf.WriteString(d.Text)
+		}
+	}
+	diff = buf.String()
+	return
+}
+
+// InlineDiffComparator reads expected and actual into strings and compares
+// them, returning a character level inline diff from InlineDiff on failure.
+func InlineDiffComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return DiffComparator(InlineDiff)(expected, actual)
+}
@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInlineDiffComparator(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		ok       bool
+		msg      string
+	}{
+		{
+			name:     "equal",
+			expected: "hello world",
+			actual:   "hello world",
+			ok:       true,
+		},
+		{
+			name:     "change within line",
+			expected: "https://example.com/?q=old&page=1",
+			actual:   "https://example.com/?q=new&page=1",
+			msg:      "https://example.com/?q=[-old-]{+new+}&page=1",
+		},
+		{
+			name:     "insertion",
+			expected: `{"a":1}`,
+			actual:   `{"a":1,"b":2}`,
+			msg:      `{"a":1{+,"b":2+}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := InlineDiffComparator(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Fatalf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"io/fs"
+	"os"
+	"testing"
+)
+
+// GetTestInputDir loads the input snapshot directory for a particular test
+// case, for tests which consume a set of input files. By default, this is the
+// directory <test-directory>/__snapshots__/<test-name>/input, which is named
+// after the snapshot name, and the file extension is ignored. The returned
+// fs.FS is read-only and rooted at the directory, so that the test can open
+// multiple named inputs. If the directory does not exist, the test immediately
+// fails, unless a SnapshotDirCreator is provided with WithCreateInputDir. In
+// this case the directory is created and populated by the SnapshotDirCreator,
+// and persisted to disk for use in subsequent test runs. When the
+// -update-snapshots mode is all, an existing directory is removed and created
+// again by the SnapshotDirCreator, if one is provided.
+func GetTestInputDir(t *testing.T, optFns ...GetTestInputOption) fs.FS {
+	skipIfSnapshotsDisabled(t)
+	opts := newGetTestInputOptions(optFns)
+	po := opts.pathOptions()
+	po.ext = ""
+	dir := resolveSnapshotFilePath(t, 0, po, false)
+	logf := resolveLogger(t, opts.Logger)
+	logf("input snapshot directory: %v", dir)
+	info, err := statSnapshotDirForUpdate(t, dir, opts.CreateInputDir != nil)
+	switch {
+	case err == nil && info.IsDir():
+		logf("using existing snapshot")
+		recordResult(t.Name(), dir, false, true, "")
+	case err == nil:
+		t.Fatalf("input snapshot %q is not a directory", dir)
+	case os.IsNotExist(err):
+		checkSnapshotChange(t, dir, "created")
+		if autoCreateDisabled(t, opts.NoAutoCreate) {
+			t.Fatalf(noAutoCreateMessage, dir)
+		}
+		if opts.CreateInputDir == nil {
+			t.Fatalf("snapshot directory %q does not exist and no CreateInputDir option was provided", dir)
+		}
+		logf("creating new input snapshot directory")
+		if err = os.MkdirAll(dir, 0750); err != nil {
+			t.Fatalf("failed to create snapshot directory %v: %v", dir, err.Error())
+		}
+		if err = opts.CreateInputDir(dir); err != nil {
+			_ = os.RemoveAll(dir)
+			t.Fatalf("snapshot directory creator failed with an error %v", err)
+		}
+		recordResult(t.Name(), dir, true, true, "")
+	default:
+		t.Fatalf("error opening input snapshot directory %v: %v", dir, err.Error())
+	}
+	return os.DirFS(dir)
+}
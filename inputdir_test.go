@@ -0,0 +1,62 @@
+package snapshot
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetTestInputDir(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	calls := 0
+	create := func(dir string) error {
+		calls++
+		if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0600); err != nil {
+			return err
+		}
+		if err := os.Mkdir(filepath.Join(dir, "sub"), 0750); err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, "sub", "b.txt"), []byte("world"), 0600)
+	}
+	for _, name := range []string{"created", "existing"} {
+		fsys := GetTestInputDir(t, WithCreateInputDir(create), dirOpt)
+		if err := fstest.TestFS(fsys, "a.txt", "sub/b.txt"); err != nil {
+			t.Fatalf("%v: unexpected input directory: %v", name, err)
+		}
+		b, err := fs.ReadFile(fsys, "sub/b.txt")
+		if err != nil || string(b) != "world" {
+			t.Errorf("%v: expected %q, got %q, %v", name, "world", b, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the directory creator to be called once, got %d", calls)
+	}
+}
+
+func TestGetTestInputDirUpdateSnapshots(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	p := filepath.Join(filepath.Dir(inputP), "input", "a.txt")
+	create := func(data string) GetTestInputOption {
+		return WithCreateInputDir(func(dir string) error {
+			return os.WriteFile(filepath.Join(dir, "a.txt"), []byte(data), 0600)
+		})
+	}
+	setUpdateSnapshots(t, updateNew)
+	GetTestInputDir(t, create("hello"), WithNoAutoCreate(), dirOpt)
+	GetTestInputDir(t, create("world"), dirOpt)
+	if str := readAllUnchecked(openUnchecked(t, p)); str != "hello" {
+		t.Errorf("expected existing input directory to be kept, got %q", str)
+	}
+	setUpdateSnapshots(t, updateAll)
+	GetTestInputDir(t, dirOpt)
+	if str := readAllUnchecked(openUnchecked(t, p)); str != "hello" {
+		t.Errorf("expected input directory without a creator to be kept, got %q", str)
+	}
+	GetTestInputDir(t, create("world"), dirOpt)
+	if str := readAllUnchecked(openUnchecked(t, p)); str != "world" {
+		t.Errorf("expected input directory to be created again, got %q", str)
+	}
+}
@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+)
+
+// JQNormaliser creates a ReaderNormaliser which runs the jq program over each
+// JSON value in the data, using github.com/itchyny/gojq, e.g.
+//
+//	snapshot.WithReaderNormaliser(snapshot.JQNormaliser(`del(.timestamp) | .items |= sort`))
+//
+// The results of the program are encoded in the same format as AsJSON, one
+// after another. If the program is invalid, or fails, the returned io.Reader
+// fails with the jq error, which fails the comparison.
+func JQNormaliser(program string) ReaderNormaliser {
+	query, err := gojq.Parse(program)
+	var code *gojq.Code
+	if err == nil {
+		code, err = gojq.Compile(query)
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid jq program %q: %w", program, err)
+		return func(io.Reader) io.Reader { return errReader{err} }
+	}
+	return func(r io.Reader) io.Reader {
+		out, err := runJQ(code, r)
+		if err != nil {
+			return errReader{err}
+		}
+		return out
+	}
+}
+
+// runJQ runs code over each JSON value decoded from r, returning the encoded
+// results.
+func runJQ(code *gojq.Code, r io.Reader) (io.Reader, error) {
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetIndent("", "  ")
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			return buf, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to decode JSON: %w", err)
+		}
+		iter := code.Run(v)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := result.(error); ok {
+				return nil, fmt.Errorf("jq program failed: %w", err)
+			}
+			if err := enc.Encode(result); err != nil {
+				return nil, fmt.Errorf("failed to encode jq result as JSON: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJQNormaliser(t *testing.T) {
+	tests := []struct {
+		name     string
+		program  string
+		expected string
+		actual   string
+		ok       bool
+		msg      string
+	}{
+		{
+			name:     "delete and sort",
+			program:  `del(.timestamp) | .items |= sort`,
+			expected: `{"timestamp": 1, "items": [1, 2, 3]}`,
+			actual:   `{"items": [3, 1, 2], "timestamp": 2}`,
+			ok:       true,
+		},
+		{
+			name:     "large numbers",
+			program:  `.id`,
+			expected: `{"id": 12345678901234567890}`,
+			actual:   `{"id": 12345678901234567891}`,
+			ok:       false,
+			msg:      `expected "12345678901234567890\n", got "12345678901234567891\n"`,
+		},
+		{
+			name:     "invalid program",
+			program:  `.items |=`,
+			expected: `{}`,
+			actual:   `{}`,
+			ok:       false,
+			msg:      `failed to read expected data from reader: invalid jq program ".items |=": unexpected EOF`,
+		},
+		{
+			name:     "runtime error",
+			program:  `.items[0]`,
+			expected: `{"items": {}}`,
+			actual:   `{"items": {}}`,
+			ok:       false,
+			msg:      `failed to read expected data from reader: jq program failed: expected an array but got: object ({})`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := Compare(strings.NewReader(tt.expected), strings.NewReader(tt.actual), WithReaderNormaliser(JQNormaliser(tt.program)))
+			if ok != tt.ok || msg != tt.msg {
+				t.Errorf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
@@ -3,9 +3,13 @@ package snapshot
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"sort"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 // AsJSON marshals i to the io.Reader. If i is a function (as determined via
@@ -13,18 +17,20 @@ import (
 // io.Reader - this is useful in the case where the generating the input data is
 // expensive to compute or comes from an external source.
 func AsJSON(i interface{}) (out io.Reader, err error) {
-	v := reflect.ValueOf(i)
-	if v.Kind() == reflect.Func {
-		res := v.Call([]reflect.Value{})
-		if len(res) != 1 {
-			err = fmt.Errorf("callable arguments to AsJSON must return a single value")
-			return
-		}
-		i = res[0].Interface()
+	i, err = callIfFunc(i, "AsJSON")
+	if err != nil {
+		return
 	}
+	return encodeJSON(i, true)
+}
+
+// encodeJSON encodes i as for AsJSON, escaping <, > and & in strings if
+// escapeHTML is true.
+func encodeJSON(i interface{}, escapeHTML bool) (out io.Reader, err error) {
 	buf := new(bytes.Buffer)
 	enc := json.NewEncoder(buf)
 	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(escapeHTML)
 	err = enc.Encode(i)
 	if err != nil {
 		err = fmt.Errorf("failed to encode snapshot as JSON: %w", err)
@@ -33,11 +39,308 @@ func AsJSON(i interface{}) (out io.Reader, err error) {
 	return
 }
 
+// asJSONWithTimeFormat behaves as AsJSON, but first formats any times within
+// the value using layout as for FormatTimes, unless layout is empty. HTML
+// characters are then not escaped, so that TimePlaceholder is stored as
+// "<TIME>" rather than "\u003cTIME\u003e".
+func asJSONWithTimeFormat(i interface{}, layout string) (io.Reader, error) {
+	if layout == "" {
+		return AsJSON(i)
+	}
+	i, err := callIfFunc(i, "AsJSON")
+	if err != nil {
+		return nil, err
+	}
+	return encodeJSON(FormatTimes(i, layout), false)
+}
+
+// callIfFunc calls i and returns the result if i is a function (as determined
+// via reflection), otherwise i is returned unmodified. caller is used in the
+// error message if i does not return a single value.
+func callIfFunc(i interface{}, caller string) (out interface{}, err error) {
+	out = i
+	v := reflect.ValueOf(i)
+	if v.Kind() == reflect.Func {
+		res := v.Call([]reflect.Value{})
+		if len(res) != 1 {
+			err = fmt.Errorf("callable arguments to %v must return a single value", caller)
+			return
+		}
+		out = res[0].Interface()
+	}
+	return
+}
+
 // WithCreateSnapshotAsJSON configures GetTestInput to use AsJSON as the
-// CreateSnapshot and sets the file extension to ".json".
-func WithCreateSnapshotAsJSON(i interface{}) GetTestInputOption {
-	return GetTestInputOptionFunc(func(o *GetTestInputOptions) {
-		o.CreateSnapshot = func() (io.Reader, error) { return AsJSON(i) }
-		o.FileExtension = ".json"
-	})
+// CreateSnapshot and sets the file extension to ".json". When used with Match,
+// it sets the file extension to ".json" and the comparator to JSONComparator,
+// so that the format of the snapshot and the comparison stay in sync. The
+// comparator can be overridden by passing WithComparator after this option.
+func WithCreateSnapshotAsJSON(i interface{}) SnapshotOption {
+	return withCreateSnapshotAsJSON{i}
+}
+
+type withCreateSnapshotAsJSON struct {
+	i interface{}
+}
+
+func (wo withCreateSnapshotAsJSON) ApplyInputOption(o *GetTestInputOptions) {
+	// o is read when the snapshot is created, after all options have been
+	// applied, so that WithTimeFormat may be passed in any order.
+	o.CreateSnapshot = func() (io.Reader, error) { return asJSONWithTimeFormat(wo.i, o.TimeFormat) }
+	o.FileExtension = ".json"
+}
+
+func (wo withCreateSnapshotAsJSON) ApplyMatchOption(o *MatchOptions) {
+	o.Comparator = JSONComparator
+	o.FileExtension = ".json"
+}
+
+// JSONComparator decodes expected and actual as JSON and compares the decoded
+// values, so that differences in formatting and the order of object keys are
+// ignored. Numbers are compared by their literal representation. On failure
+// a diff of the decoded values is returned.
+func JSONComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eValue, err := decodeJSON(expected)
+	if err != nil {
+		msg = "failed to decode expected JSON: " + err.Error()
+		return
+	}
+	aValue, err := decodeJSON(actual)
+	if err != nil {
+		msg = "failed to decode actual JSON: " + err.Error()
+		return
+	}
+	ok = reflect.DeepEqual(eValue, aValue)
+	if !ok {
+		msg = cmp.Diff(eValue, aValue)
+	}
+	return
+}
+
+// decodeJSON decodes a single JSON value from r, preserving numbers as
+// json.Number. Any data after the value, other than whitespace, is an error.
+func decodeJSON(r io.Reader) (v interface{}, err error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err = dec.Decode(&v); err != nil {
+		return
+	}
+	err = checkJSONEOF(dec)
+	return
+}
+
+// errJSONTrailingData is returned when a JSON value is followed by further
+// data.
+var errJSONTrailingData = errors.New("unexpected data after the JSON value")
+
+// checkJSONEOF returns errJSONTrailingData unless dec has no more data after
+// the value it has decoded.
+func checkJSONEOF(dec *json.Decoder) error {
+	if _, err := dec.Token(); err != io.EOF {
+		return errJSONTrailingData
+	}
+	return nil
+}
+
+// extractJSONSubtree decodes the JSON document in r and returns the value at
+// path, encoded in the same format as AsJSON.
+func extractJSONSubtree(r io.Reader, path string) (out io.Reader, err error) {
+	v, err := decodeJSON(r)
+	if err != nil {
+		err = fmt.Errorf("failed to decode JSON: %w", err)
+		return
+	}
+	subtree, err := lookupJSONPath(v, path)
+	if err != nil {
+		return
+	}
+	return AsJSON(subtree)
+}
+
+// WithJSONSubtree restricts the comparison of JSON snapshots to the value at
+// path, e.g. "$.data.items". The subtree is extracted from actual before it is
+// compared and, when the snapshot is created, only the subtree is stored, so
+// the expected snapshot is compared as it is. Match fails if path is invalid
+// or cannot be found in actual.
+func WithJSONSubtree(path string) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.JSONSubtree = path })
+}
+
+// CanonicalJSONNormaliser is a ReaderNormaliser that decodes the data as a
+// JSON document and re-encodes it as for AsJSON, with object keys sorted and
+// consistent indentation, so that differences in formatting and key order
+// are ignored. Numbers keep their literal representation. If the data is not
+// valid JSON it is passed through unmodified. It can be registered for the
+// ".json" file extension with SetNormaliserForExtension.
+func CanonicalJSONNormaliser(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errReader{err}
+	}
+	v, err := decodeJSON(bytes.NewReader(b))
+	if err != nil {
+		return bytes.NewReader(b)
+	}
+	out, err := AsJSON(v)
+	if err != nil {
+		return bytes.NewReader(b)
+	}
+	return out
+}
+
+// errReader is an io.Reader that always fails with err. It allows a
+// ReaderNormaliser to surface an error to the Comparator.
+type errReader struct {
+	err error
+}
+
+func (er errReader) Read([]byte) (int, error) {
+	return 0, er.err
+}
+
+// SortJSONArrayNormaliser creates a ReaderNormaliser that decodes a JSON
+// document, sorts the array of objects at arrayPath (e.g. "$.items") by the
+// value of the field sortKey in each object and re-encodes the document. This
+// stabilises snapshots of collections that are returned in an arbitrary order.
+// Numbers are sorted numerically and other values by their string form.
+// Objects without sortKey are sorted first. If the document cannot be decoded
+// or the array cannot be found, the returned io.Reader fails with the error.
+func SortJSONArrayNormaliser(arrayPath, sortKey string) ReaderNormaliser {
+	return func(r io.Reader) io.Reader {
+		v, err := decodeJSON(r)
+		if err != nil {
+			return errReader{fmt.Errorf("failed to decode JSON: %w", err)}
+		}
+		found, err := lookupJSONPath(v, arrayPath)
+		if err != nil {
+			return errReader{err}
+		}
+		arr, ok := found.([]interface{})
+		if !ok {
+			return errReader{fmt.Errorf("JSON path %q: value is not an array", arrayPath)}
+		}
+		for i, elem := range arr {
+			if _, ok := elem.(map[string]interface{}); !ok {
+				return errReader{fmt.Errorf("JSON path %q: element %d is not an object", arrayPath, i)}
+			}
+		}
+		sort.SliceStable(arr, func(i, j int) bool {
+			return lessJSONValue(
+				arr[i].(map[string]interface{})[sortKey],
+				arr[j].(map[string]interface{})[sortKey],
+			)
+		})
+		out, err := AsJSON(v)
+		if err != nil {
+			return errReader{err}
+		}
+		return out
+	}
+}
+
+// lessJSONValue orders two decoded JSON values. Missing (nil) values are
+// ordered first, numbers are compared numerically and all other values are
+// compared by their string representation.
+func lessJSONValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
+	}
+	aNum, aOk := a.(json.Number)
+	bNum, bOk := b.(json.Number)
+	if aOk && bOk {
+		aFloat, aErr := aNum.Float64()
+		bFloat, bErr := bNum.Float64()
+		if aErr == nil && bErr == nil {
+			return aFloat < bFloat
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+// JSONSubsetComparator decodes expected and actual as JSON and passes if every
+// value in expected is present in actual. Objects in actual may contain fields
+// which are not in expected, which allows snapshots to remain stable as new
+// optional fields are added. Arrays must have the same length, with each
+// element compared in the same way. On failure the JSON path of the first
+// expected value that is missing or differs is reported.
+func JSONSubsetComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eValue, err := decodeJSON(expected)
+	if err != nil {
+		msg = "failed to decode expected JSON: " + err.Error()
+		return
+	}
+	aValue, err := decodeJSON(actual)
+	if err != nil {
+		msg = "failed to decode actual JSON: " + err.Error()
+		return
+	}
+	if err = checkJSONSubset(eValue, aValue, "$"); err != nil {
+		msg = err.Error()
+		return
+	}
+	ok = true
+	return
+}
+
+// checkJSONSubset returns an error describing the first value in expected, at
+// or below path, which is missing from or differs in actual.
+func checkJSONSubset(expected, actual interface{}, path string) error {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected an object, got %v", path, jsonTypeName(actual))
+		}
+		keys := make([]string, 0, len(e))
+		for k := range e {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			aChild, ok := a[k]
+			if !ok {
+				return fmt.Errorf("%v.%v: missing from actual", path, k)
+			}
+			if err := checkJSONSubset(e[k], aChild, path+"."+k); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v: expected an array, got %v", path, jsonTypeName(actual))
+		}
+		if len(e) != len(a) {
+			return fmt.Errorf("%v: expected an array of length %d, got %d", path, len(e), len(a))
+		}
+		for i := range e {
+			if err := checkJSONSubset(e[i], a[i], fmt.Sprintf("%v[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		if !reflect.DeepEqual(expected, actual) {
+			return fmt.Errorf("%v: expected %v, got %v", path, expected, actual)
+		}
+	}
+	return nil
+}
+
+// jsonTypeName returns the name of the JSON type of a decoded value.
+func jsonTypeName(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return "null"
+	}
 }
@@ -2,6 +2,7 @@ package snapshot
 
 import (
 	"io"
+	"os"
 	"strings"
 	"testing"
 
@@ -64,3 +65,215 @@ func TestAsJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := map[string]interface{}{
+		"data": map[string]interface{}{
+			"items": []interface{}{"a", "b"},
+		},
+	}
+	tests := []struct {
+		name     string
+		path     string
+		expected interface{}
+		err      bool
+	}{
+		{name: "root", path: "$", expected: doc},
+		{name: "nested key", path: "$.data.items", expected: []interface{}{"a", "b"}},
+		{name: "index", path: "$.data.items[1]", expected: "b"},
+		{name: "without leading dollar", path: ".data.items[0]", expected: "a"},
+		{name: "missing key", path: "$.data.missing", err: true},
+		{name: "index out of range", path: "$.data.items[2]", err: true},
+		{name: "index into object", path: "$.data[0]", err: true},
+		{name: "empty key", path: "$..data", err: true},
+		{name: "invalid index", path: "$.data.items[x]", err: true},
+		{name: "unterminated index", path: "$.data.items[0", err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := lookupJSONPath(doc, tt.path)
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got value %v", v)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, v); diff != "" {
+				t.Fatalf("unexpected value: %v", diff)
+			}
+		})
+	}
+}
+
+func TestJSONSubtree(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	first := strings.NewReader(`{"requestId": "1", "data": {"items": [1, 2]}}`)
+	if ok, msg := Match(t, first, WithJSONSubtree("$.data.items"), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	outputF, err := os.Open(outputP)
+	if err != nil {
+		t.Fatalf("failed to open output snapshot: %v", err)
+	}
+	expectedSnapshot := "[\n  1,\n  2\n]\n"
+	if str := readAllUnchecked(outputF); str != expectedSnapshot {
+		t.Fatalf("expected only the subtree to be stored. expected %q, got %q", expectedSnapshot, str)
+	}
+	second := strings.NewReader(`{"requestId": "2", "data": {"items": [1, 2]}}`)
+	if ok, msg := Match(t, second, WithJSONSubtree("$.data.items"), dirOpt); !ok {
+		t.Errorf("expected envelope changes to be ignored: %v", msg)
+	}
+	third := strings.NewReader(`{"requestId": "3", "data": {}}`)
+	if ok, _ := Match(t, third, WithJSONSubtree("$.data.items"), dirOpt); ok {
+		t.Errorf("expected missing subtree to fail")
+	}
+}
+
+func TestJSONSubtreeWithPathKey(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	for i := 0; i < 2; i++ {
+		if ok, msg := Match(t, strings.NewReader(`{"a": {"a": 1}}`), WithJSONSubtree("$.a"), dirOpt); !ok {
+			t.Fatalf("expected match %d to succeed: %v", i+1, msg)
+		}
+	}
+}
+
+func TestSortJSONArrayNormaliser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		sortKey  string
+		expected string
+		err      bool
+	}{
+		{
+			name:     "sorts by string key",
+			input:    `{"items": [{"id": "b"}, {"id": "a"}]}`,
+			sortKey:  "id",
+			expected: "{\n  \"items\": [\n    {\n      \"id\": \"a\"\n    },\n    {\n      \"id\": \"b\"\n    }\n  ]\n}\n",
+		},
+		{
+			name:     "sorts numbers numerically",
+			input:    `{"items": [{"n": 10}, {"n": 9}]}`,
+			sortKey:  "n",
+			expected: "{\n  \"items\": [\n    {\n      \"n\": 9\n    },\n    {\n      \"n\": 10\n    }\n  ]\n}\n",
+		},
+		{
+			name:    "non-object elements fail",
+			input:   `{"items": [1, 2]}`,
+			sortKey: "id",
+			err:     true,
+		},
+		{
+			name:    "missing array fails",
+			input:   `{"other": []}`,
+			sortKey: "id",
+			err:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := ReadAll(SortJSONArrayNormaliser("$.items", tt.sortKey)(strings.NewReader(tt.input)))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("expected error, got %q", str)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, str); diff != "" {
+				t.Fatalf("unexpected normaliser output: %v", diff)
+			}
+		})
+	}
+}
+
+func TestJSONComparator(t *testing.T) {
+	expected := "{\n  \"a\": 1,\n  \"b\": [true, null]\n}\n"
+	if ok, msg := JSONComparator(strings.NewReader(expected), strings.NewReader(`{"b":[true,null],"a":1}`)); !ok {
+		t.Errorf("expected formatting differences to be ignored: %v", msg)
+	}
+	if ok, msg := JSONComparator(strings.NewReader(expected), strings.NewReader(`{"b":[true,null],"a":2}`)); ok || msg == "" {
+		t.Errorf("expected different values to fail with a diff, got %v, %q", ok, msg)
+	}
+	if ok, _ := JSONComparator(strings.NewReader(expected), strings.NewReader(`{"b":`)); ok {
+		t.Errorf("expected invalid JSON to fail")
+	}
+	expectedMsg := "failed to decode actual JSON: unexpected data after the JSON value"
+	if ok, msg := JSONComparator(strings.NewReader(expected), strings.NewReader(expected+" {}")); ok || msg != expectedMsg {
+		t.Errorf("expected trailing data to fail with %q, got %v, %q", expectedMsg, ok, msg)
+	}
+	if _, err := decodeJSON(strings.NewReader("1 \n")); err != nil {
+		t.Errorf("expected trailing whitespace to be accepted: %v", err)
+	}
+}
+
+func TestCreateSnapshotAsJSONMatch(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	opt := WithCreateSnapshotAsJSON(mkTestStruct)
+	input := GetTestInput(t, opt, dirOpt)
+	if ok, msg := Match(t, input, opt, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if _, err := os.Stat(strings.TrimSuffix(outputP, ".txt") + ".json"); err != nil {
+		t.Fatalf("expected output snapshot with .json extension: %v", err)
+	}
+	if ok, msg := Match(t, strings.NewReader(`{"Member2":"world","Member1":"hello"}`), opt, dirOpt); !ok {
+		t.Errorf("expected JSON comparator to ignore formatting: %v", msg)
+	}
+}
+
+func TestJSONSubsetComparator(t *testing.T) {
+	expected := `{"id": 1, "user": {"name": "hello", "tags": ["a", "b"]}}`
+	tests := []struct {
+		name   string
+		actual string
+		msg    string
+	}{
+		{
+			name:   "extra fields ignored",
+			actual: `{"id": 1, "new": true, "user": {"name": "hello", "age": 2, "tags": ["a", "b"]}}`,
+		},
+		{
+			name:   "missing field",
+			actual: `{"id": 1, "user": {"tags": ["a", "b"]}}`,
+			msg:    "$.user.name: missing from actual",
+		},
+		{
+			name:   "changed value",
+			actual: `{"id": 2, "user": {"name": "hello", "tags": ["a", "b"]}}`,
+			msg:    "$.id: expected 1, got 2",
+		},
+		{
+			name:   "changed array element",
+			actual: `{"id": 1, "user": {"name": "hello", "tags": ["a", "c"]}}`,
+			msg:    "$.user.tags[1]: expected b, got c",
+		},
+		{
+			name:   "changed array length",
+			actual: `{"id": 1, "user": {"name": "hello", "tags": ["a"]}}`,
+			msg:    "$.user.tags: expected an array of length 2, got 1",
+		},
+		{
+			name:   "changed type",
+			actual: `{"id": 1, "user": "hello"}`,
+			msg:    "$.user: expected an object, got a string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := JSONSubsetComparator(strings.NewReader(expected), strings.NewReader(tt.actual))
+			if ok != (tt.msg == "") || msg != tt.msg {
+				t.Fatalf("expected %q, got %v, %q", tt.msg, ok, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+)
+
+// removeJSONFields decodes the JSON document in r, removes every object field
+// named in names, at any depth, and returns the document encoded in the same
+// format as AsJSON.
+func removeJSONFields(r io.Reader, names map[string]bool) (io.Reader, error) {
+	v, err := decodeJSON(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	return AsJSON(deleteJSONFields(v, names))
+}
+
+// deleteJSONFields removes the fields named in names from every object
+// within the decoded JSON value v, returning v.
+func deleteJSONFields(v interface{}, names map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if names[k] {
+				delete(v, k)
+				continue
+			}
+			deleteJSONFields(child, names)
+		}
+	case []interface{}:
+		for _, child := range v {
+			deleteJSONFields(child, names)
+		}
+	}
+	return v
+}
+
+// fieldSet returns the set of names.
+func fieldSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+// IgnoreJSONFieldsNormaliser creates a ReaderNormaliser which decodes a JSON
+// document and removes every object field named in names, wherever it appears,
+// before re-encoding the document in the same format as AsJSON. If the
+// document cannot be decoded, the returned io.Reader fails with the error.
+func IgnoreJSONFieldsNormaliser(names ...string) ReaderNormaliser {
+	set := fieldSet(names)
+	return func(r io.Reader) io.Reader {
+		out, err := removeJSONFields(r, set)
+		if err != nil {
+			return errReader{err}
+		}
+		return out
+	}
+}
+
+// WithIgnoreJSONFields removes every object field named in names, such as "id"
+// or "createdAt", wherever it appears in a JSON snapshot, without specifying
+// its full path. The fields are removed from actual before it is compared and,
+// when the snapshot is created, before it is stored. They are also removed
+// from the expected snapshot, so that existing snapshots which contain them
+// still match. Match fails if actual is not valid JSON.
+func WithIgnoreJSONFields(names ...string) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) {
+		o.IgnoreJSONFields = append(o.IgnoreJSONFields, names...)
+	})
+}
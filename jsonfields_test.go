@@ -0,0 +1,42 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithIgnoreJSONFields(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	ignore := WithIgnoreJSONFields("id", "createdAt")
+	actual := `{"id": 1, "name": "a", "items": [{"id": 2, "createdAt": "today", "v": true}]}`
+	if ok, msg := Match(t, strings.NewReader(actual), ignore, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	expected := "{\n  \"items\": [\n    {\n      \"v\": true\n    }\n  ],\n  \"name\": \"a\"\n}\n"
+	if str := readAllUnchecked(openUnchecked(t, outputP)); str != expected {
+		t.Fatalf("expected ignored fields to be removed on create. expected %q, got %q", expected, str)
+	}
+	actual = `{"id": 3, "name": "a", "items": [{"id": 4, "createdAt": "tomorrow", "v": true}]}`
+	if ok, msg := Match(t, strings.NewReader(actual), ignore, dirOpt); !ok {
+		t.Errorf("expected ignored fields not to affect the comparison: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader(`{"id": 3, "name": "b", "items": []}`), ignore, dirOpt); ok {
+		t.Errorf("expected other fields to be compared")
+	}
+	writeSnapshotFile(t, outputP, `{"id": 9, "name": "a", "items": [{"v": true, "createdAt": "x"}]}`)
+	if ok, msg := Match(t, strings.NewReader(actual), ignore, dirOpt); !ok {
+		t.Errorf("expected ignored fields to be removed from expected: %v", msg)
+	}
+	expectedMsg := "failed to remove ignored JSON fields from actual: failed to decode JSON: invalid character 'o' in literal null (expecting 'u')"
+	if ok, msg := Match(t, strings.NewReader("not json"), ignore, dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
+
+func TestIgnoreJSONFieldsNormaliser(t *testing.T) {
+	out := readAllUnchecked(IgnoreJSONFieldsNormaliser("id")(strings.NewReader(`[{"id": 1, "a": {"id": 2}}]`)))
+	expected := "[\n  {\n    \"a\": {}\n  }\n]\n"
+	if out != expected {
+		t.Errorf("expected %q, got %q", expected, out)
+	}
+}
@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// decodeJSONL decodes each non-blank line of r as a JSON value.
+func decodeJSONL(r io.Reader) (values []interface{}, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		v, decodeErr := decodeJSON(bytes.NewReader(scanner.Bytes()))
+		if decodeErr != nil {
+			err = fmt.Errorf("failed to decode JSON on line %d: %w", line, decodeErr)
+			return
+		}
+		values = append(values, v)
+	}
+	err = scanner.Err()
+	return
+}
+
+// JSONLComparator compares newline-delimited JSON, as commonly used for event
+// logs. Each non-blank line of expected and actual is decoded as JSON and the
+// values are compared in order, so that the order of fields within each object
+// does not matter. Numbers are compared by their literal representation. On
+// failure the first record that differs is reported along with a structural
+// diff.
+func JSONLComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return compareJSONL(expected, actual, "")
+}
+
+// SortedJSONLComparator creates a Comparator that behaves as JSONLComparator,
+// but first sorts the records of both expected and actual by the value of the
+// object field sortKey. This is useful where records are emitted in an
+// arbitrary order.
+func SortedJSONLComparator(sortKey string) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		return compareJSONL(expected, actual, sortKey)
+	}
+}
+
+// compareJSONL implements JSONLComparator, sorting the records by sortKey if
+// it is not empty.
+func compareJSONL(expected, actual io.Reader, sortKey string) (ok bool, msg string) {
+	eValues, err := decodeJSONL(expected)
+	if err != nil {
+		msg = "failed to decode expected JSONL: " + err.Error()
+		return
+	}
+	aValues, err := decodeJSONL(actual)
+	if err != nil {
+		msg = "failed to decode actual JSONL: " + err.Error()
+		return
+	}
+	if sortKey != "" {
+		sortJSONLRecords(eValues, sortKey)
+		sortJSONLRecords(aValues, sortKey)
+	}
+	for i := 0; i < len(eValues) && i < len(aValues); i++ {
+		if !reflect.DeepEqual(eValues[i], aValues[i]) {
+			msg = fmt.Sprintf("record %d differs: %v", i+1, cmp.Diff(eValues[i], aValues[i]))
+			return
+		}
+	}
+	if len(eValues) != len(aValues) {
+		msg = fmt.Sprintf("expected %d records, got %d", len(eValues), len(aValues))
+		return
+	}
+	ok = true
+	return
+}
+
+// sortJSONLRecords sorts records by the value of the object field sortKey.
+// Records which are not objects, or do not contain sortKey, are sorted first.
+func sortJSONLRecords(records []interface{}, sortKey string) {
+	key := func(v interface{}) interface{} {
+		if obj, ok := v.(map[string]interface{}); ok {
+			return obj[sortKey]
+		}
+		return nil
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return lessJSONValue(key(records[i]), key(records[j]))
+	})
+}
@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONLComparator(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator Comparator
+		expected   string
+		actual     string
+		ok         bool
+		msg        string
+	}{
+		{
+			name:       "field order is ignored",
+			comparator: JSONLComparator,
+			expected:   "{\"a\": 1, \"b\": 2}\n{\"c\": 3}\n",
+			actual:     "{\"b\": 2, \"a\": 1}\n\n{\"c\": 3}",
+			ok:         true,
+		},
+		{
+			name:       "reports first differing record",
+			comparator: JSONLComparator,
+			expected:   "{\"a\": 1}\n{\"b\": 2}\n",
+			actual:     "{\"a\": 1}\n{\"b\": 3}\n",
+			msg:        "record 2 differs",
+		},
+		{
+			name:       "reports record count",
+			comparator: JSONLComparator,
+			expected:   "{\"a\": 1}\n",
+			actual:     "{\"a\": 1}\n{\"a\": 1}\n",
+			msg:        "expected 1 records, got 2",
+		},
+		{
+			name:       "reports invalid lines",
+			comparator: JSONLComparator,
+			expected:   "{\"a\": 1}\n",
+			actual:     "{\"a\": 1}\n{\"a\":\n",
+			msg:        "failed to decode JSON on line 2",
+		},
+		{
+			name:       "record order is significant",
+			comparator: JSONLComparator,
+			expected:   "{\"id\": 1}\n{\"id\": 2}\n",
+			actual:     "{\"id\": 2}\n{\"id\": 1}\n",
+			msg:        "record 1 differs",
+		},
+		{
+			name:       "sorted records ignore order",
+			comparator: SortedJSONLComparator("id"),
+			expected:   "{\"id\": 1}\n{\"id\": 2}\n",
+			actual:     "{\"id\": 2}\n{\"id\": 1}\n",
+			ok:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := tt.comparator(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok {
+				t.Fatalf("expected ok to be %v, got %v: %v", tt.ok, ok, msg)
+			}
+			if !strings.Contains(msg, tt.msg) {
+				t.Fatalf("expected message to contain %q, got %q", tt.msg, msg)
+			}
+		})
+	}
+}
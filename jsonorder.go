@@ -0,0 +1,162 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+)
+
+// JSONKeyOrderInsensitiveComparator reads expected and actual as JSON
+// documents and sorts the members of every object by key, recursively, while
+// preserving all other bytes, including whitespace and the literal form of
+// values. The resulting documents are compared strictly as for
+// CompareStringsWithPosition. Unlike JSONComparator, this catches formatting
+// changes while ignoring the order of object keys, e.g. from encoding maps.
+func JSONKeyOrderInsensitiveComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		msg = err.Error()
+		return
+	}
+	eSorted, err := sortJSONKeys(eBytes)
+	if err != nil {
+		msg = "failed to decode expected JSON: " + err.Error()
+		return
+	}
+	aSorted, err := sortJSONKeys(aBytes)
+	if err != nil {
+		msg = "failed to decode actual JSON: " + err.Error()
+		return
+	}
+	return CompareStringsWithPosition(string(eSorted), string(aSorted))
+}
+
+// sortJSONKeys returns the JSON document b with the members of every object
+// sorted by key, preserving all other bytes.
+func sortJSONKeys(b []byte) ([]byte, error) {
+	if !json.Valid(b) {
+		return nil, errors.New("invalid JSON")
+	}
+	s := jsonKeySorter{b: b}
+	out := s.whitespace()
+	out = append(out, s.value()...)
+	return append(out, s.whitespace()...), nil
+}
+
+// jsonKeySorter sorts the members of objects within a valid JSON document b,
+// which is consumed from offset i.
+type jsonKeySorter struct {
+	b []byte
+	i int
+}
+
+// whitespace consumes and returns any whitespace.
+func (s *jsonKeySorter) whitespace() []byte {
+	start := s.i
+	for s.i < len(s.b) && (s.b[s.i] == ' ' || s.b[s.i] == '\t' || s.b[s.i] == '\n' || s.b[s.i] == '\r') {
+		s.i++
+	}
+	return s.b[start:s.i]
+}
+
+// value consumes a value and returns it with the members of any objects
+// sorted.
+func (s *jsonKeySorter) value() []byte {
+	switch s.b[s.i] {
+	case '{':
+		return s.object()
+	case '[':
+		return s.array()
+	case '"':
+		return s.str()
+	}
+	start := s.i
+	for s.i < len(s.b) && !isJSONDelimiter(s.b[s.i]) {
+		s.i++
+	}
+	return s.b[start:s.i]
+}
+
+// isJSONDelimiter reports whether c ends a number or literal.
+func isJSONDelimiter(c byte) bool {
+	switch c {
+	case ',', '}', ']', ' ', '\t', '\n', '\r':
+		return true
+	}
+	return false
+}
+
+// str consumes a string, including the quotes.
+func (s *jsonKeySorter) str() []byte {
+	start := s.i
+	s.i++
+	for s.b[s.i] != '"' {
+		if s.b[s.i] == '\\' {
+			s.i++
+		}
+		s.i++
+	}
+	s.i++
+	return s.b[start:s.i]
+}
+
+// array consumes an array, sorting the members of any objects within it.
+func (s *jsonKeySorter) array() []byte {
+	out := []byte{'['}
+	s.i++
+	for {
+		out = append(out, s.whitespace()...)
+		if s.b[s.i] == ']' {
+			s.i++
+			return append(out, ']')
+		}
+		out = append(out, s.value()...)
+		out = append(out, s.whitespace()...)
+		if s.b[s.i] == ',' {
+			out = append(out, ',')
+			s.i++
+		}
+	}
+}
+
+// object consumes an object and returns it with its members sorted by key.
+// The whitespace and commas between members keep their positions.
+func (s *jsonKeySorter) object() []byte {
+	type member struct {
+		key  string
+		text []byte
+	}
+	var members []member
+	var separators [][]byte
+	s.i++
+	sep := s.whitespace()
+	for s.b[s.i] != '}' {
+		separators = append(separators, sep)
+		rawKey := s.str()
+		var key string
+		_ = json.Unmarshal(rawKey, &key)
+		text := append([]byte(nil), rawKey...)
+		text = append(text, s.whitespace()...)
+		text = append(text, ':')
+		s.i++
+		text = append(text, s.whitespace()...)
+		text = append(text, s.value()...)
+		members = append(members, member{key, text})
+		sep = append([]byte(nil), s.whitespace()...)
+		if s.b[s.i] == ',' {
+			s.i++
+			sep = append(sep, ',')
+			sep = append(sep, s.whitespace()...)
+		}
+	}
+	s.i++
+	sort.SliceStable(members, func(i, j int) bool { return members[i].key < members[j].key })
+	out := []byte{'{'}
+	for i, m := range members {
+		out = append(out, separators[i]...)
+		out = append(out, m.text...)
+	}
+	out = append(out, sep...)
+	return append(out, '}')
+}
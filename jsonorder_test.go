@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONKeyOrderInsensitiveComparator(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		ok       bool
+		msg      string
+	}{
+		{
+			name:     "reordered keys",
+			expected: "{\n  \"a\": 1,\n  \"b\": {\"x\": [{\"q\": 1, \"p\": 2}], \"w\": \"s\"}\n}\n",
+			actual:   "{\n  \"b\": {\"w\": \"s\", \"x\": [{\"p\": 2, \"q\": 1}]},\n  \"a\": 1\n}\n",
+			ok:       true,
+		},
+		{
+			name:     "escaped keys",
+			expected: `{"a": 1, "b\"": 2}`,
+			actual:   `{"b\"": 2, "a": 1}`,
+			ok:       true,
+		},
+		{
+			name:     "formatting change",
+			expected: `{"a": 1, "b": 2}`,
+			actual:   `{"b":2,"a":1}`,
+			ok:       false,
+			msg:      `first difference at line 1, col 6: expected "{\"a\": 1, \"b\": 2}", got "{\"a\":1,\"b\":2}"`,
+		},
+		{
+			name:     "number literal change",
+			expected: `{"a": 1.0}`,
+			actual:   `{"a": 1}`,
+			ok:       false,
+			msg:      `first difference at line 1, col 8: expected "{\"a\": 1.0}", got "{\"a\": 1}"`,
+		},
+		{
+			name:     "invalid JSON",
+			expected: `{}`,
+			actual:   `{`,
+			ok:       false,
+			msg:      "failed to decode actual JSON: invalid JSON",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := JSONKeyOrderInsensitiveComparator(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Errorf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
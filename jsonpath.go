@@ -0,0 +1,90 @@
+package snapshot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A jsonPathSegment is a single step in a JSON path. It is either an object
+// key or, if isIndex is true, an array index.
+type jsonPathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses a simple JSON path such as "$.data.items[0].name". The
+// leading "$" is optional. Object keys are separated by "." and array indexes
+// are written as "[n]".
+func parseJSONPath(path string) (segments []jsonPathSegment, err error) {
+	rest := strings.TrimPrefix(path, "$")
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				err = fmt.Errorf("invalid JSON path %q: empty key", path)
+				return
+			}
+			segments = append(segments, jsonPathSegment{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				err = fmt.Errorf("invalid JSON path %q: unterminated index", path)
+				return
+			}
+			index, convErr := strconv.Atoi(rest[1:end])
+			if convErr != nil || index < 0 {
+				err = fmt.Errorf("invalid JSON path %q: invalid index %q", path, rest[1:end])
+				return
+			}
+			segments = append(segments, jsonPathSegment{index: index, isIndex: true})
+			rest = rest[end+1:]
+		default:
+			err = fmt.Errorf("invalid JSON path %q: unexpected character %q", path, rest[0])
+			return
+		}
+	}
+	return
+}
+
+// lookupJSONPath returns the value at path within v, where v is a value decoded
+// by encoding/json into an interface{}.
+func lookupJSONPath(v interface{}, path string) (out interface{}, err error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return
+	}
+	out = v
+	for _, s := range segments {
+		if s.isIndex {
+			arr, ok := out.([]interface{})
+			if !ok {
+				err = fmt.Errorf("JSON path %q: cannot index non-array value with [%d]", path, s.index)
+				return
+			}
+			if s.index >= len(arr) {
+				err = fmt.Errorf("JSON path %q: index %d out of range for array of length %d", path, s.index, len(arr))
+				return
+			}
+			out = arr[s.index]
+			continue
+		}
+		obj, ok := out.(map[string]interface{})
+		if !ok {
+			err = fmt.Errorf("JSON path %q: cannot lookup key %q in non-object value", path, s.key)
+			return
+		}
+		if out, ok = obj[s.key]; !ok {
+			err = fmt.Errorf("JSON path %q: key %q not found", path, s.key)
+			return
+		}
+	}
+	return
+}
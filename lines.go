@@ -0,0 +1,245 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// splitLines splits s into lines. A trailing newline does not result in a
+// final empty line.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineSet returns the set of non-empty lines in s.
+func lineSet(s string) map[string]struct{} {
+	set := make(map[string]struct{})
+	for _, line := range splitLines(s) {
+		if line != "" {
+			set[line] = struct{}{}
+		}
+	}
+	return set
+}
+
+// setDifference returns the sorted elements of a that are not in b.
+func setDifference(a, b map[string]struct{}) (out []string) {
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return
+}
+
+// SetComparator reads expected and actual into sets of lines and compares
+// their membership, ignoring both the order of lines and the number of times
+// each line appears. Empty lines are ignored. On failure the lines only in
+// expected and only in actual are reported.
+func SetComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return compareAsStrings(expected, actual, func(expected, actual string) (ok bool, msg string) {
+		eSet, aSet := lineSet(expected), lineSet(actual)
+		missing, extra := setDifference(eSet, aSet), setDifference(aSet, eSet)
+		ok = len(missing) == 0 && len(extra) == 0
+		if !ok {
+			msg = fmt.Sprintf("only in expected: %q, only in actual: %q", missing, extra)
+		}
+		return
+	})
+}
+
+// fuzzyLineSampleSize is the maximum number of differing lines reported by
+// FuzzyLineComparator.
+const fuzzyLineSampleSize = 10
+
+// A lineEdit is an operation of a line diff: ' ' for a line common to both
+// sides, '-' for a line only in expected and '+' for a line only in actual.
+type lineEdit struct {
+	op   byte
+	line string
+}
+
+// diffLines returns the edits which transform e into a, keeping the lines of
+// the longest common subsequence of both. At each divergence the removed lines
+// precede the added lines. The common prefix and suffix are trimmed first, so
+// that the quadratic cost only applies to the lines between them.
+func diffLines(e, a []string) (edits []lineEdit) {
+	for len(e) > 0 && len(a) > 0 && e[0] == a[0] {
+		edits = append(edits, lineEdit{' ', e[0]})
+		e, a = e[1:], a[1:]
+	}
+	var suffix []lineEdit
+	for len(e) > 0 && len(a) > 0 && e[len(e)-1] == a[len(a)-1] {
+		suffix = append(suffix, lineEdit{' ', e[len(e)-1]})
+		e, a = e[:len(e)-1], a[:len(a)-1]
+	}
+	// lengths[i][j] is the length of the LCS of e[i:] and a[j:].
+	lengths := make([][]int32, len(e)+1)
+	for i := range lengths {
+		lengths[i] = make([]int32, len(a)+1)
+	}
+	for i := len(e) - 1; i >= 0; i-- {
+		for j := len(a) - 1; j >= 0; j-- {
+			switch {
+			case e[i] == a[j]:
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			case lengths[i+1][j] >= lengths[i][j+1]:
+				lengths[i][j] = lengths[i+1][j]
+			default:
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(e) || j < len(a) {
+		switch {
+		case i < len(e) && j < len(a) && e[i] == a[j]:
+			edits = append(edits, lineEdit{' ', e[i]})
+			i, j = i+1, j+1
+		case j == len(a) || (i < len(e) && lengths[i+1][j] >= lengths[i][j+1]):
+			edits = append(edits, lineEdit{'-', e[i]})
+			i++
+		default:
+			edits = append(edits, lineEdit{'+', a[j]})
+			j++
+		}
+	}
+	for k := len(suffix) - 1; k >= 0; k-- {
+		edits = append(edits, suffix[k])
+	}
+	return
+}
+
+// FuzzyLineComparator creates a Comparator which passes if at least threshold,
+// a fraction between 0 and 1, of the lines of expected and actual match. The
+// matching lines are the longest common subsequence of the lines of both, so
+// the comparison is sensitive to order, and the fraction is relative to the
+// longer of the two. On failure the percentage of mismatched lines is reported
+// along with a sample of the differing lines. This is intended for inherently
+// noisy output, such as logs, where a few lines legitimately vary.
+func FuzzyLineComparator(threshold float64) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		return compareAsStrings(expected, actual, func(expected, actual string) (ok bool, msg string) {
+			eLines, aLines := splitLines(expected), splitLines(actual)
+			total := len(eLines)
+			if len(aLines) > total {
+				total = len(aLines)
+			}
+			matched := 0
+			var diff []string
+			for _, edit := range diffLines(eLines, aLines) {
+				if edit.op == ' ' {
+					matched++
+				} else {
+					diff = append(diff, string(edit.op)+" "+edit.line)
+				}
+			}
+			fraction := 1.0
+			if total > 0 {
+				fraction = float64(matched) / float64(total)
+			}
+			ok = fraction >= threshold
+			if !ok {
+				more := ""
+				if len(diff) > fuzzyLineSampleSize {
+					more = fmt.Sprintf("\n... and %d more", len(diff)-fuzzyLineSampleSize)
+					diff = diff[:fuzzyLineSampleSize]
+				}
+				msg = fmt.Sprintf("%.1f%% of lines mismatched, %d of %d lines match, below the threshold of %.1f%%:\n%v%v",
+					100*(1-fraction), matched, total, 100*threshold, strings.Join(diff, "\n"), more)
+			}
+			return
+		})
+	}
+}
+
+// DedentNormaliser is a ReaderNormaliser that removes the common leading
+// whitespace from each block of lines, so that changes in indentation alone,
+// e.g. of reformatted code or templates, are ignored. Blocks are separated by
+// blank lines, which are emptied, and the common indentation of each block is
+// the longest run of spaces and tabs that prefixes all of its lines. If the
+// data cannot be read, the returned io.Reader fails with the error.
+func DedentNormaliser(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errReader{err}
+	}
+	lines := strings.Split(string(b), "\n")
+	for start := 0; start < len(lines); {
+		if strings.TrimSpace(lines[start]) == "" {
+			lines[start] = ""
+			start++
+			continue
+		}
+		end := start
+		indent := leadingWhitespace(lines[start])
+		for ; end < len(lines) && strings.TrimSpace(lines[end]) != ""; end++ {
+			indent = commonPrefix(indent, leadingWhitespace(lines[end]))
+		}
+		for i := start; i < end; i++ {
+			lines[i] = strings.TrimPrefix(lines[i], indent)
+		}
+		start = end
+	}
+	return strings.NewReader(strings.Join(lines, "\n"))
+}
+
+// leadingWhitespace returns the leading spaces and tabs of line.
+func leadingWhitespace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// commonPrefix returns the longest common prefix of a and b.
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+// CollapseBlankLinesNormaliser is a ReaderNormaliser that replaces each run of
+// two or more blank lines, which are empty or contain only whitespace, with a
+// single empty line, so that output which varies only in the number of blank
+// lines between sections compares as equal. Single blank lines and all other
+// whitespace are kept. If the data cannot be read, the returned io.Reader
+// fails with the error.
+func CollapseBlankLinesNormaliser(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errReader{err}
+	}
+	// pending is the first blank line of the current run of run lines.
+	var pending string
+	run := 0
+	var sb strings.Builder
+	flush := func() {
+		switch {
+		case run == 1:
+			sb.WriteString(pending)
+		case run > 1:
+			// Keep only the line ending, which may be "\r\n".
+			sb.WriteString(strings.TrimLeft(pending, " \t\v\f"))
+		}
+		run = 0
+	}
+	for _, line := range strings.SplitAfter(string(b), "\n") {
+		if strings.HasSuffix(line, "\n") && strings.TrimSpace(line) == "" {
+			if run == 0 {
+				pending = line
+			}
+			run++
+			continue
+		}
+		flush()
+		sb.WriteString(line)
+	}
+	flush()
+	return strings.NewReader(sb.String())
+}
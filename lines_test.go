@@ -0,0 +1,129 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetComparator(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		ok       bool
+		msg      string
+	}{
+		{
+			name:     "order and duplicates ignored",
+			expected: "a\nb\nc\n",
+			actual:   "c\na\nb\na\n\n",
+			ok:       true,
+		},
+		{
+			name:     "membership differences reported",
+			expected: "a\nb\nc\n",
+			actual:   "a\nd\n",
+			msg:      `only in expected: ["b" "c"], only in actual: ["d"]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := SetComparator(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Fatalf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
+
+func TestFuzzyLineComparator(t *testing.T) {
+	lines := func(n int, replace map[int]string) string {
+		buf := new(strings.Builder)
+		for i := 0; i < n; i++ {
+			if line, ok := replace[i]; ok {
+				buf.WriteString(line + "\n")
+				continue
+			}
+			buf.WriteString("line " + strings.Repeat("x", i) + "\n")
+		}
+		return buf.String()
+	}
+	tests := []struct {
+		name      string
+		expected  string
+		actual    string
+		threshold float64
+		ok        bool
+		msg       string
+	}{
+		{"equal", lines(20, nil), lines(20, nil), 1, true, ""},
+		{"within threshold", lines(20, nil), lines(20, map[int]string{3: "changed"}), 0.95, true, ""},
+		{
+			name:      "below threshold",
+			expected:  lines(20, nil),
+			actual:    lines(20, map[int]string{3: "changed", 7: "other"}),
+			threshold: 0.95,
+			msg:       "10.0% of lines mismatched, 18 of 20 lines match, below the threshold of 95.0%:\n- line xxx\n+ changed\n- line xxxxxxx\n+ other",
+		},
+		{"both empty", "", "", 1, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := FuzzyLineComparator(tt.threshold)(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Fatalf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
+
+func TestDedentNormaliser(t *testing.T) {
+	tests := []struct {
+		name, in, expected string
+	}{
+		{"no indentation", "a\n  b\n", "a\n  b\n"},
+		{"common indentation", "    a\n      b\n    c\n", "a\n  b\nc\n"},
+		{"blocks", "  a\n    b\n\n\t\tc\n\t\td\n", "a\n  b\n\nc\nd\n"},
+		{"whitespace only separator", "  a\n   \n    b", "a\n\nb"},
+		{"shared mixed indentation", "\t a\n\t  b\n", "a\n b\n"},
+		{"different indentation characters", "\ta\n  b\n", "\ta\n  b\n"},
+	}
+	for _, tt := range tests {
+		if got := readAllUnchecked(DedentNormaliser(strings.NewReader(tt.in))); got != tt.expected {
+			t.Errorf("%v: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+	ok, msg := Compare(strings.NewReader("func f() {\n\treturn\n}\n"),
+		strings.NewReader("    func f() {\n    \treturn\n    }\n"), WithReaderNormaliser(DedentNormaliser))
+	if !ok {
+		t.Errorf("expected indentation only change to match: %v", msg)
+	}
+}
+
+func TestCollapseBlankLinesNormaliser(t *testing.T) {
+	tests := []struct {
+		name, in, expected string
+	}{
+		{"no blank lines", "a\nb\n", "a\nb\n"},
+		{"single blank line", "a\n\nb\n", "a\n\nb\n"},
+		{"run of blank lines", "a\n\n\n\nb\n", "a\n\nb\n"},
+		{"whitespace only lines", "a\n  \n\t\nb", "a\n\nb"},
+		{"single whitespace line kept", "a\n  \nb", "a\n  \nb"},
+		{"leading and trailing", "\n\na\n\n\n", "\na\n\n"},
+		{"crlf", "a\r\n\r\n\r\nb\r\n", "a\r\n\r\nb\r\n"},
+	}
+	for _, tt := range tests {
+		if got := readAllUnchecked(CollapseBlankLinesNormaliser(strings.NewReader(tt.in))); got != tt.expected {
+			t.Errorf("%v: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+	ok, msg := Compare(strings.NewReader("# Header\n\nbody\n\n# Footer\n"),
+		strings.NewReader("# Header\n\n\n\nbody\n\n\n# Footer\n"), WithReaderNormaliser(CollapseBlankLinesNormaliser))
+	if !ok {
+		t.Errorf("expected blank line count only change to match: %v", msg)
+	}
+	if ok, _ := Compare(strings.NewReader("a\nb\n"), strings.NewReader("a\n\nb\n"), WithReaderNormaliser(CollapseBlankLinesNormaliser)); ok {
+		t.Errorf("expected an added blank line to fail")
+	}
+}
@@ -0,0 +1,21 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// ListSnapshots returns the sorted paths of all files within the snapshot
+// directory of the currently running test,
+// <test-directory>/__snapshots__/<test-name>, including those of any subtests.
+// The directory may be changed with the WithSnapshotDir and WithCallerSkip
+// options. If the directory does not exist, an empty slice is returned.
+func ListSnapshots(t *testing.T, optFns ...GetTestInputOption) ([]string, error) {
+	opts := newGetTestInputOptions(optFns)
+	dir := filepath.Join(resolveSnapshotBaseDir(t, 0, opts.pathOptions()), t.Name())
+	paths, err := findSnapshotFiles(dir, "*")
+	if paths == nil {
+		paths = []string{}
+	}
+	return paths, err
+}
@@ -0,0 +1,25 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestListSnapshots(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	paths, err := ListSnapshots(t, dirOpt)
+	if err != nil || paths == nil || len(paths) != 0 {
+		t.Fatalf("expected an empty slice, got %#v, %v", paths, err)
+	}
+	_ = readAllUnchecked(GetTestInput(t, WithDefaultInput("hello"), dirOpt))
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	paths, err = ListSnapshots(t, dirOpt)
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if diff := cmp.Diff([]string{inputP, outputP}, paths); diff != "" {
+		t.Errorf("unexpected snapshots: %v", diff)
+	}
+}
@@ -0,0 +1,20 @@
+package snapshot
+
+import "sync"
+
+// snapshotLocks holds a *sync.Mutex for each resolved snapshot path, so that
+// concurrent calls for the same snapshot, e.g. from parallel tests or
+// goroutines, do not race to create it, while calls for distinct snapshots
+// never contend on a shared lock.
+var snapshotLocks sync.Map
+
+// lockSnapshotPath locks the snapshot file at p against concurrent use within
+// the test binary, returning the function which unlocks it.
+func lockSnapshotPath(p string) (unlock func()) {
+	mu, ok := snapshotLocks.Load(p)
+	if !ok {
+		mu, _ = snapshotLocks.LoadOrStore(p, new(sync.Mutex))
+	}
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
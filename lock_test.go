@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestMatchConcurrentCreate(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	data := strings.Repeat("hello\n", 10000)
+	var wg sync.WaitGroup
+	results := make([]string, 8)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if ok, msg := Match(t, strings.NewReader(data), dirOpt); !ok {
+				results[i] = msg
+			}
+		}(i)
+	}
+	wg.Wait()
+	for i, msg := range results {
+		if msg != "" {
+			t.Errorf("expected concurrent match %d to pass: %v", i, msg)
+		}
+	}
+}
+
+// BenchmarkSnapshotPathLock measures parallel matches against existing
+// snapshots, for distinct snapshot paths, which should not contend and so
+// scale with -cpu, and for a single shared path, which are serialised by the
+// snapshot path lock. Match requires a *testing.T, so match is called directly.
+func BenchmarkSnapshotPathLock(b *testing.B) {
+	data := strings.Repeat("hello\n", 1000)
+	matchName := func(b *testing.B, dirOpt SnapshotOption, name string) {
+		if ok, msg := match(b, 0, strings.NewReader(data), dirOpt, WithSnapshotName(name), WithQuiet()); !ok {
+			b.Error(msg)
+		}
+	}
+	b.Run("distinct", func(b *testing.B) {
+		dirOpt := WithSnapshotDir(b.TempDir())
+		for i := 0; i < runtime.GOMAXPROCS(0); i++ {
+			matchName(b, dirOpt, strconv.Itoa(i))
+		}
+		var next int64
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			name := strconv.FormatInt((atomic.AddInt64(&next, 1)-1)%int64(runtime.GOMAXPROCS(0)), 10)
+			for pb.Next() {
+				matchName(b, dirOpt, name)
+			}
+		})
+	})
+	b.Run("shared", func(b *testing.B) {
+		dirOpt := WithSnapshotDir(b.TempDir())
+		matchName(b, dirOpt, "shared")
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				matchName(b, dirOpt, "shared")
+			}
+		})
+	})
+}
@@ -0,0 +1,44 @@
+package snapshot
+
+import "testing"
+
+// A Logger receives the informational messages logged by this package, such as
+// the resolved snapshot filename and whether a snapshot was created. The
+// arguments are as for fmt.Printf.
+type Logger func(format string, args ...interface{})
+
+// nopLogger is the Logger used by WithQuiet, which discards all messages.
+func nopLogger(string, ...interface{}) {}
+
+// resolveLogger returns l, or t.Logf if l is nil.
+func resolveLogger(t testing.TB, l Logger) Logger {
+	if l == nil {
+		return t.Logf
+	}
+	return l
+}
+
+// WithQuiet suppresses the informational messages logged by GetTestInput and
+// Match. Failures are still reported on the test.
+func WithQuiet() SnapshotOption {
+	return withLogger{nopLogger}
+}
+
+// WithLogger redirects the informational messages logged by GetTestInput and
+// Match to l, rather than the test log. Failures are still reported on the
+// test.
+func WithLogger(l func(format string, args ...interface{})) SnapshotOption {
+	return withLogger{l}
+}
+
+type withLogger struct {
+	l Logger
+}
+
+func (wo withLogger) ApplyInputOption(o *GetTestInputOptions) {
+	o.Logger = wo.l
+}
+
+func (wo withLogger) ApplyMatchOption(o *MatchOptions) {
+	o.Logger = wo.l
+}
@@ -0,0 +1,83 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// moduleRoots caches the module root of each working directory, as found by
+// findModuleRoot, so that the file system is only walked once.
+var moduleRoots sync.Map
+
+// errNoModuleRoot is returned by findModuleRoot when no go.mod is found.
+var errNoModuleRoot = errors.New("go.mod not found in the working directory or any parent directory")
+
+// findModuleRoot returns the directory containing the go.mod file of the Go
+// module of dir, by walking up from dir until a go.mod file is found.
+func findModuleRoot(dir string) (string, error) {
+	if root, ok := moduleRoots.Load(dir); ok {
+		return root.(string), nil
+	}
+	for d := dir; ; {
+		if info, err := os.Stat(filepath.Join(d, "go.mod")); err == nil && !info.IsDir() {
+			moduleRoots.Store(dir, d)
+			return d, nil
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return "", errNoModuleRoot
+		}
+		d = parent
+	}
+}
+
+// moduleSnapshotDir returns rel joined to the root of the Go module of the
+// working directory, which is the package directory when run by go test,
+// followed by the path of the package directory relative to the module root,
+// so that the snapshots of tests with the same name in different packages do
+// not clash. The test fails if the module root cannot be found.
+func moduleSnapshotDir(t testing.TB, rel string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to determine the working directory to find the module root: %v", err.Error())
+	}
+	root, err := findModuleRoot(wd)
+	if err != nil {
+		t.Fatalf("failed to find the module root for WithModuleRelativeSnapshotDir(%q): %v", rel, err.Error())
+	}
+	pkg, err := filepath.Rel(root, wd)
+	if err != nil {
+		t.Fatalf("failed to determine the package directory relative to the module root %v: %v", root, err.Error())
+	}
+	return filepath.Join(root, rel, pkg)
+}
+
+// WithModuleRelativeSnapshotDir stores the snapshots in the directory rel,
+// relative to the root of the Go module, which is found by walking up from the
+// package directory to the go.mod file. This gives a stable location for the
+// snapshots of every package in the module, e.g.
+// WithModuleRelativeSnapshotDir("testdata/__snapshots__"). Snapshots are
+// stored as for WithSnapshotDir, in the subdirectory of rel given by the path
+// of the package relative to the module root, e.g.
+// testdata/__snapshots__/internal/parser/TestParse/output.txt. The later of
+// the two options takes precedence.
+func WithModuleRelativeSnapshotDir(rel string) SnapshotOption {
+	return withModuleRelativeSnapshotDir{rel}
+}
+
+type withModuleRelativeSnapshotDir struct {
+	rel string
+}
+
+func (wo withModuleRelativeSnapshotDir) ApplyInputOption(o *GetTestInputOptions) {
+	o.SnapshotDir = ""
+	o.ModuleRelativeSnapshotDir = wo.rel
+}
+
+func (wo withModuleRelativeSnapshotDir) ApplyMatchOption(o *MatchOptions) {
+	o.SnapshotDir = ""
+	o.ModuleRelativeSnapshotDir = wo.rel
+}
@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindModuleRoot(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(nested, 0750); err != nil {
+		t.Fatalf("failed to create directories: %v", err)
+	}
+	if _, err := findModuleRoot(nested); err != errNoModuleRoot {
+		t.Errorf("expected %v without go.mod, got %v", errNoModuleRoot, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "go.mod"), []byte("module a\n"), 0666); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	root, err := findModuleRoot(nested)
+	if err != nil || root != filepath.Join(dir, "a") {
+		t.Errorf("expected %v, %v, got %v, %v", filepath.Join(dir, "a"), nil, root, err)
+	}
+	if err := os.Remove(filepath.Join(dir, "a", "go.mod")); err != nil {
+		t.Fatalf("failed to remove go.mod: %v", err)
+	}
+	if root, err := findModuleRoot(nested); err != nil || root != filepath.Join(dir, "a") {
+		t.Errorf("expected cached module root %v, got %v, %v", filepath.Join(dir, "a"), root, err)
+	}
+}
+
+// chdirModule changes the working directory to a throwaway Go module in a
+// temporary directory for the duration of the test, and returns its root.
+func chdirModule(t *testing.T) string {
+	t.Helper()
+	_, _ = loadProjectConfig()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	root := t.TempDir()
+	pkg := filepath.Join(root, "pkg")
+	if err := os.MkdirAll(pkg, 0750); err != nil {
+		t.Fatalf("failed to create directories: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/m\n"), 0666); err != nil {
+		t.Fatalf("failed to write go.mod: %v", err)
+	}
+	if err := os.Chdir(pkg); err != nil {
+		t.Fatalf("failed to change working directory: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(wd); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	})
+	return root
+}
+
+func TestModuleRelativeSnapshotDir(t *testing.T) {
+	root := chdirModule(t)
+	rel := filepath.Join("testdata", "module_snapshots")
+	if ok, msg := Match(t, strings.NewReader("hello"), WithModuleRelativeSnapshotDir(rel)); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	p := filepath.Join(root, rel, "pkg", t.Name(), "output.txt")
+	if str := readAllUnchecked(openUnchecked(t, p)); str != "hello" {
+		t.Errorf("expected snapshot %q at %v, got %q", "hello", p, str)
+	}
+	dir := t.TempDir()
+	opts := newMatchOptions([]MatchOption{WithModuleRelativeSnapshotDir(rel), WithSnapshotDir(dir)})
+	if opts.ModuleRelativeSnapshotDir != "" || opts.SnapshotDir != dir {
+		t.Errorf("expected WithSnapshotDir to take precedence, got %q, %q", opts.ModuleRelativeSnapshotDir, opts.SnapshotDir)
+	}
+}
+
+func TestModuleRelativeSnapshotDirPackages(t *testing.T) {
+	root := chdirModule(t)
+	rel := filepath.Join("testdata", "module_snapshots")
+	for _, pkg := range []string{"pkg", filepath.Join("internal", "other")} {
+		if err := os.MkdirAll(filepath.Join(root, pkg), 0750); err != nil {
+			t.Fatalf("failed to create directories: %v", err)
+		}
+		if err := os.Chdir(filepath.Join(root, pkg)); err != nil {
+			t.Fatalf("failed to change working directory: %v", err)
+		}
+		if ok, msg := Match(t, strings.NewReader(pkg), WithModuleRelativeSnapshotDir(rel)); !ok {
+			t.Fatalf("%v: expected match to succeed: %v", pkg, msg)
+		}
+	}
+	for _, pkg := range []string{"pkg", filepath.Join("internal", "other")} {
+		p := filepath.Join(root, rel, pkg, t.Name(), "output.txt")
+		if str := readAllUnchecked(openUnchecked(t, p)); str != pkg {
+			t.Errorf("expected snapshot %q at %v, got %q", pkg, p, str)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package snapshot
 
 import (
+	"bytes"
 	"io"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type SnapshotOption interface {
@@ -28,13 +30,21 @@ func (wo withSnapshotName) ApplyInputOption(o *GetTestInputOptions) {
 
 func (wo withSnapshotName) ApplyMatchOption(o *MatchOptions) {
 	o.SnapshotName = wo.name
+	o.namedSnapshot = true
 }
 
 // WithSnapshotFileExtension overrides the file extension for the resulting
 // snapshot file. This is useful if the snapshots are read by external tools
-// and make use of the extensions to determine the filetype.
+// and make use of the extensions to determine the filetype. The leading "." is
+// optional, so "json" and ".json" are equivalent.
 func WithSnapshotFileExtension(name string) SnapshotOption {
-	return withSnapshotFileExtension{name}
+	return withSnapshotFileExtension{normaliseExtension(name)}
+}
+
+// WithExtension is an alias of WithSnapshotFileExtension, which sets the file
+// extension of both input and output snapshots.
+func WithExtension(ext string) SnapshotOption {
+	return WithSnapshotFileExtension(ext)
 }
 
 type withSnapshotFileExtension struct {
@@ -67,6 +77,192 @@ func (wo withSnapshotFilename) ApplyInputOption(o *GetTestInputOptions) {
 func (wo withSnapshotFilename) ApplyMatchOption(o *MatchOptions) {
 	o.FileExtension = filepath.Ext(wo.filename)
 	o.SnapshotName = strings.TrimSuffix(wo.filename, o.FileExtension)
+	o.namedSnapshot = true
+}
+
+// WithVersionedSnapshot names the snapshot file <name>.<version>.<ext>. When a
+// new version of the snapshot is created it is also copied to
+// <name>.latest.<ext>, so that there is always a reference to the most
+// recently created version to diff against.
+func WithVersionedSnapshot(version string) SnapshotOption {
+	return withVersionedSnapshot{version}
+}
+
+type withVersionedSnapshot struct {
+	version string
+}
+
+func (wo withVersionedSnapshot) ApplyInputOption(o *GetTestInputOptions) {
+	o.Version = wo.version
+}
+
+func (wo withVersionedSnapshot) ApplyMatchOption(o *MatchOptions) {
+	o.Version = wo.version
+}
+
+// WithPlatformSpecificSnapshots names snapshot files <name>.<GOOS>.<ext>
+// using runtime.GOOS, e.g. "output.linux.txt", for outputs that legitimately
+// differ between platforms. If no platform specific snapshot exists, the base
+// snapshot <name>.<ext> is used if it exists. New snapshots are always created
+// as the platform specific variant.
+func WithPlatformSpecificSnapshots() SnapshotOption {
+	return withPlatformSpecificSnapshots{}
+}
+
+type withPlatformSpecificSnapshots struct{}
+
+func (withPlatformSpecificSnapshots) ApplyInputOption(o *GetTestInputOptions) {
+	o.PlatformSpecific = true
+}
+
+func (withPlatformSpecificSnapshots) ApplyMatchOption(o *MatchOptions) {
+	o.PlatformSpecific = true
+}
+
+// WithFlatLayout stores snapshot files alongside the test file, named
+// <testfile>.<testname>.<name><ext>, e.g. "parse_test.TestParse.output.txt",
+// rather than nesting them under __snapshots__/<testname>/. This suits tests
+// with few, small snapshots. This overrides the snapshot directory of the
+// project config file, as the layout determines the directory.
+func WithFlatLayout() SnapshotOption {
+	return withFlatLayout{}
+}
+
+type withFlatLayout struct{}
+
+func (withFlatLayout) ApplyInputOption(o *GetTestInputOptions) {
+	o.FlatLayout = true
+	o.ModuleRelativeSnapshotDir = ""
+}
+
+func (withFlatLayout) ApplyMatchOption(o *MatchOptions) {
+	o.FlatLayout = true
+	o.ModuleRelativeSnapshotDir = ""
+}
+
+// goldenExtension is the file extension of snapshots stored with
+// WithGoldenConvention.
+const goldenExtension = ".golden"
+
+// WithGoldenConvention stores snapshots following the common Go convention for
+// golden files, in the testdata directory beside the test file with the
+// ".golden" file extension, which eases migration from hand-written golden
+// file tests. The output snapshot of Match is named testdata/<testname>.golden,
+// e.g. "testdata/TestParse.golden", and other snapshots, such as the input
+// snapshot, testdata/<testname>.<name>.golden, e.g.
+// "testdata/TestParse.input.golden". Subtests are stored in subdirectories, as
+// t.Name() contains a "/". The name can be set with WithSnapshotName, and the
+// extension overridden by passing the relevant option after this option. As
+// for WithFlatLayout, this
+// overrides the snapshot directory of the project config file.
+func WithGoldenConvention() SnapshotOption {
+	return withGoldenConvention{}
+}
+
+type withGoldenConvention struct{}
+
+func (withGoldenConvention) ApplyInputOption(o *GetTestInputOptions) {
+	o.GoldenLayout = true
+	o.ModuleRelativeSnapshotDir = ""
+	o.FileExtension = goldenExtension
+}
+
+func (withGoldenConvention) ApplyMatchOption(o *MatchOptions) {
+	o.GoldenLayout = true
+	o.ModuleRelativeSnapshotDir = ""
+	o.FileExtension = goldenExtension
+	if !o.namedSnapshot && o.SnapshotName == defaultOutputSnapshotName {
+		o.SnapshotName = ""
+	}
+}
+
+// WithCallerSkip skips a further skip stack frames when determining the file
+// that contains the test, which locates the snapshot directory. This is needed
+// when GetTestInput or Match are called from a helper function, in a different
+// file to the test, which should be skipped.
+func WithCallerSkip(skip int) SnapshotOption {
+	return withCallerSkip{skip}
+}
+
+type withCallerSkip struct {
+	skip int
+}
+
+func (wo withCallerSkip) ApplyInputOption(o *GetTestInputOptions) {
+	o.CallerSkip = wo.skip
+}
+
+func (wo withCallerSkip) ApplyMatchOption(o *MatchOptions) {
+	o.CallerSkip = wo.skip
+}
+
+// WithSnapshotDir stores the snapshots in dir, as
+// <dir>/<test-name>/<name><ext>, rather than in the __snapshots__ directory
+// beside the test file. This takes precedence over WithFlatLayout, and no
+// stack frames are inspected, so it is also useful when the file containing
+// the test cannot be determined. For example, WithSnapshotDir(t.TempDir())
+// isolates snapshots from the source tree.
+func WithSnapshotDir(dir string) SnapshotOption {
+	return withSnapshotDir{dir}
+}
+
+type withSnapshotDir struct {
+	dir string
+}
+
+func (wo withSnapshotDir) ApplyInputOption(o *GetTestInputOptions) {
+	o.SnapshotDir = wo.dir
+	o.ModuleRelativeSnapshotDir = ""
+}
+
+func (wo withSnapshotDir) ApplyMatchOption(o *MatchOptions) {
+	o.SnapshotDir = wo.dir
+	o.ModuleRelativeSnapshotDir = ""
+}
+
+// WithNoAutoCreate causes GetTestInput and Match to fail the test when the
+// snapshot file does not exist, rather than creating it. For GetTestInput this
+// applies even when a SnapshotCreator is provided.
+func WithNoAutoCreate() SnapshotOption {
+	return withNoAutoCreate{}
+}
+
+type withNoAutoCreate struct{}
+
+func (withNoAutoCreate) ApplyInputOption(o *GetTestInputOptions) {
+	o.NoAutoCreate = true
+}
+
+func (withNoAutoCreate) ApplyMatchOption(o *MatchOptions) {
+	o.NoAutoCreate = true
+}
+
+// WithRequireExistingOutput causes Match to fail the test when the output
+// snapshot file does not exist, rather than creating it from actual and
+// trivially passing. This is the Match only form of WithNoAutoCreate, for
+// tests whose output snapshots are created by a separate step, such as
+// Generate, so that a forgotten snapshot is reported clearly.
+func WithRequireExistingOutput() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.NoAutoCreate = true })
+}
+
+// WithMaxSnapshotSize limits the size of snapshot data to n bytes. Reading or
+// writing more data fails with a "snapshot exceeded n bytes" error, which
+// guards against a SnapshotCreator or actual io.Reader that never ends.
+func WithMaxSnapshotSize(n int64) SnapshotOption {
+	return withMaxSnapshotSize{n}
+}
+
+type withMaxSnapshotSize struct {
+	n int64
+}
+
+func (wo withMaxSnapshotSize) ApplyInputOption(o *GetTestInputOptions) {
+	o.MaxSnapshotSize = wo.n
+}
+
+func (wo withMaxSnapshotSize) ApplyMatchOption(o *MatchOptions) {
+	o.MaxSnapshotSize = wo.n
 }
 
 // WithCreateSnapshot provides a SnapshotCreator function to specify the
@@ -76,6 +272,42 @@ func WithCreateSnapshot(r func() (io.Reader, error)) GetTestInputOption {
 	return GetTestInputOptionFunc(func(o *GetTestInputOptions) { o.CreateSnapshot = r })
 }
 
+// WithCreateRetry retries the SnapshotCreator up to attempts times if it
+// returns an error, which is useful when creating the snapshot depends on an
+// external system with transient failures. The delay before the first retry is
+// backoff, which doubles after each subsequent attempt.
+func WithCreateRetry(attempts int, backoff time.Duration) GetTestInputOption {
+	return GetTestInputOptionFunc(func(o *GetTestInputOptions) {
+		o.CreateAttempts = attempts
+		o.CreateBackoff = backoff
+	})
+}
+
+// WithCreateTimeout fails reading the input if the SnapshotCreator does not
+// return within d, or its data is not then read in full within d of calling
+// it, which guards against creators that hang, such as a pipe which is never
+// closed. The creator is called in a goroutine, which stops retrying and
+// logging on timeout, but cannot interrupt a call which is in progress. No
+// partial snapshot file is left on disk.
+func WithCreateTimeout(d time.Duration) GetTestInputOption {
+	return GetTestInputOptionFunc(func(o *GetTestInputOptions) { o.CreateTimeout = d })
+}
+
+// WithInputFallbackNames sets the snapshot names GetTestInput falls back to, in
+// order, if the input snapshot does not exist, before calling the
+// SnapshotCreator. For example, a version specific snapshot can fall back to
+// a default one:
+//
+//	input := snapshot.GetTestInput(t,
+//		snapshot.WithSnapshotName("ear_"+EARVersion),
+//		snapshot.WithInputFallbackNames("ear_default"),
+//	)
+//
+// The first candidate which exists is used, and its name is logged.
+func WithInputFallbackNames(names ...string) GetTestInputOption {
+	return GetTestInputOptionFunc(func(o *GetTestInputOptions) { o.InputFallbackNames = names })
+}
+
 // GetTestInputOptionFunc applies a func to the GetTestInputOptions defaults.
 type GetTestInputOptionFunc func(*GetTestInputOptions)
 
@@ -88,6 +320,25 @@ func WithCreateSnapshotFromReader(r io.Reader) GetTestInputOption {
 	return WithCreateSnapshot(func() (io.Reader, error) { return r, nil })
 }
 
+// WithDefaultInput uses s as the input data when no input snapshot file
+// exists. The data is also persisted to disk and used for subsequent test runs.
+func WithDefaultInput(s string) GetTestInputOption {
+	return WithCreateSnapshot(func() (io.Reader, error) { return strings.NewReader(s), nil })
+}
+
+// WithDefaultInputBytes uses b as the input data when no input snapshot file
+// exists. The data is also persisted to disk and used for subsequent test runs.
+func WithDefaultInputBytes(b []byte) GetTestInputOption {
+	return WithCreateSnapshot(func() (io.Reader, error) { return bytes.NewReader(b), nil })
+}
+
+// WithCreateInputDir provides a SnapshotDirCreator to populate the input
+// snapshot directory of GetTestInputDir when it does not exist. The files are
+// persisted to disk and used for subsequent test runs.
+func WithCreateInputDir(fn func(dir string) error) GetTestInputOption {
+	return GetTestInputOptionFunc(func(o *GetTestInputOptions) { o.CreateInputDir = fn })
+}
+
 // WithMatchOption applies f to the MatchOptions defaults.
 type MatchOptionFunc func(*MatchOptions)
 
@@ -105,3 +356,63 @@ func WithComparator(cmp Comparator) MatchOption {
 func WithReaderNormaliser(rn ReaderNormaliser) MatchOption {
 	return MatchOptionFunc(func(o *MatchOptions) { o.ReaderNormaliser = rn })
 }
+
+// WithCaseInsensitive ignores differences in case, e.g. between hex digests
+// rendered by different tools, by lowercasing expected and actual with
+// LowercaseNormaliser before comparison. This is applied after, rather than
+// replacing, any ReaderNormaliser, regardless of the order of the options.
+func WithCaseInsensitive() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.CaseInsensitive = true })
+}
+
+// WithWriteActualOnFailure writes the actual data to <name>.actual.<ext>
+// beside the snapshot file when the comparison fails, so that it can be
+// diffed in an editor or promoted to the snapshot. The file is overwritten on
+// each failure and removed once the comparison succeeds.
+func WithWriteActualOnFailure() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.WriteActualOnFailure = true })
+}
+
+// WithStreaming disables the buffering of the expected and actual data by
+// Match, so that they are read by the ReaderNormaliser and Comparator as they
+// are streamed from their sources. By default, both are read into memory
+// before comparison, which allows normalisers and comparators to read the data
+// more than once. This should be paired with a streaming ReaderNormaliser and
+// Comparator, such as LineEndingNormaliser and StreamComparator, for very large
+// snapshots. When the snapshot is created, the actual data is read back from
+// the new snapshot file rather than from memory. WithWriteActualOnFailure still
+// buffers the actual data.
+func WithStreaming() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.Streaming = true })
+}
+
+// WithFastEqual compares the expected and actual data byte for byte with
+// StreamComparator as they are streamed, as for WithStreaming, returning on
+// the first differing byte with only its offset rather than a diff. This
+// avoids buffering and diffing huge snapshots when only pass or fail is
+// needed. Any ReaderNormaliser is still applied, so should be streaming, and
+// the comparator can be overridden by passing WithComparator after this
+// option.
+func WithFastEqual() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) {
+		o.Streaming = true
+		o.Comparator = StreamComparator
+	})
+}
+
+// WithClock provides the function used to get the current time wherever Match
+// records a time, such as the recording time in the sentinel file written by
+// WithVerification, in place of time.Now. This makes the recorded times
+// reproducible.
+func WithClock(now func() time.Time) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.Clock = now })
+}
+
+// WithExpectedFailure marks the snapshot as known to be broken, for reason.
+// The comparison still runs, but its result is inverted: a mismatch is logged
+// along with reason and Match reports ok, whereas an unexpected match is
+// reported as a failure so that the option can be removed. This allows drifted
+// snapshots to be tracked without deleting the test.
+func WithExpectedFailure(reason string) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.ExpectedFailure = reason })
+}
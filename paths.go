@@ -0,0 +1,167 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// pathOptions are the options shared by GetTestInputOptions and MatchOptions
+// which determine the location of a snapshot file.
+type pathOptions struct {
+	name             string
+	ext              string
+	version          string
+	platformSpecific bool
+	flatLayout       bool
+	goldenLayout     bool
+	snapshotDir      string
+	moduleDir        string
+	callerSkip       int
+	shared           bool
+	store            Store
+}
+
+func (o GetTestInputOptions) pathOptions() pathOptions {
+	return pathOptions{
+		name:             o.SnapshotName,
+		ext:              snapshotExtension(o.FileExtension, o.encoding()),
+		version:          o.Version,
+		platformSpecific: o.PlatformSpecific,
+		flatLayout:       o.FlatLayout,
+		goldenLayout:     o.GoldenLayout,
+		snapshotDir:      o.SnapshotDir,
+		moduleDir:        o.ModuleRelativeSnapshotDir,
+		callerSkip:       o.CallerSkip,
+		store:            resolveStore(o.Store),
+	}
+}
+
+func (o MatchOptions) pathOptions() pathOptions {
+	return pathOptions{
+		name:             o.SnapshotName,
+		ext:              snapshotExtension(o.FileExtension, o.encoding()),
+		version:          o.Version,
+		platformSpecific: o.PlatformSpecific,
+		flatLayout:       o.FlatLayout,
+		goldenLayout:     o.GoldenLayout,
+		snapshotDir:      o.SnapshotDir,
+		moduleDir:        o.ModuleRelativeSnapshotDir,
+		callerSkip:       o.CallerSkip,
+		shared:           o.Shared,
+		store:            resolveStore(o.Store),
+	}
+}
+
+// baseName returns the snapshot file name, without the extension or platform
+// suffix, with the version appended if set.
+func (po pathOptions) baseName() string {
+	if po.version == "" {
+		return po.name
+	}
+	return po.name + "." + po.version
+}
+
+// platformSuffix returns the suffix added to platform specific snapshot
+// file names.
+func (po pathOptions) platformSuffix() string {
+	if !po.platformSpecific {
+		return ""
+	}
+	return "." + runtime.GOOS
+}
+
+// latestPath returns the path of the copy of the most recently created
+// version of the snapshot at p, which replaces the version in p with "latest",
+// so that the copy is in the same layout as p.
+func (po pathOptions) latestPath(p string) string {
+	suffix := po.platformSuffix() + po.ext
+	return strings.TrimSuffix(p, "."+po.version+suffix) + ".latest" + suffix
+}
+
+// getFlatSnapshotFilePath returns the path of the snapshot file named name.ext
+// for the currently running test in the flat layout, which is stored alongside
+// testFile as <testfile>.<testname>.<name><ext>. Any "/" in the test name, as
+// added by subtests, is replaced by "_".
+func getFlatSnapshotFilePath(t testing.TB, testFile, name, ext string) string {
+	testName := strings.ReplaceAll(t.Name(), "/", "_")
+	base := strings.TrimSuffix(filepath.Base(testFile), ".go")
+	return filepath.Join(filepath.Dir(testFile), base+"."+testName+"."+name+ext)
+}
+
+// getGoldenSnapshotFilePath returns the path of the snapshot file named
+// name.ext for the currently running test in the golden layout, which is
+// stored in the testdata directory beside testFile as
+// testdata/<testname>.<name><ext>, or testdata/<testname><ext> if name is
+// empty.
+func getGoldenSnapshotFilePath(t testing.TB, testFile, name, ext string) string {
+	if name = strings.TrimPrefix(name, "."); name != "" {
+		name = "." + name
+	}
+	return filepath.Join(filepath.Dir(testFile), "testdata", t.Name()+name+ext)
+}
+
+// sharedSnapshotDir is the directory, beside the snapshot directories of each
+// test, which holds the snapshots shared by several tests. Test names always
+// begin with "Test", so it cannot clash with the directory of a test.
+const sharedSnapshotDir = "__shared__"
+
+// resolveSnapshotFilePath resolves the path of the snapshot file for the
+// currently running test, as for getSnapshotFilePath, applying the snapshot
+// directory, layout, versioning and platform options. Shared snapshots are
+// stored in the shared snapshot directory, whatever the layout. Platform
+// specific snapshots fall back to the base snapshot if only it exists, unless
+// create is true. The test fails if the project config file is invalid.
+func resolveSnapshotFilePath(t testing.TB, skip int, po pathOptions, create bool) string {
+	if _, err := loadProjectConfig(); err != nil {
+		t.Fatalf("failed to load the snapshot config file: %v", err.Error())
+	}
+	var p string
+	if po.shared {
+		p = filepath.Join(resolveSnapshotBaseDir(t, skip+1, po), sharedSnapshotDir, po.baseName()+po.platformSuffix()+po.ext)
+	} else {
+		p = resolveTestSnapshotFilePath(t, skip+1, po)
+	}
+	if !po.platformSpecific || create {
+		return p
+	}
+	base := strings.TrimSuffix(p, po.platformSuffix()+po.ext) + po.ext
+	if !po.store.Exists(p) && po.store.Exists(base) {
+		return base
+	}
+	return p
+}
+
+// resolveTestSnapshotFilePath returns the path of the snapshot file in the
+// directory of the currently running test, applying the snapshot directory,
+// layout and versioning options.
+func resolveTestSnapshotFilePath(t testing.TB, skip int, po pathOptions) string {
+	skip += po.callerSkip
+	if po.moduleDir != "" {
+		po.snapshotDir = moduleSnapshotDir(t, po.moduleDir)
+	}
+	switch {
+	case po.snapshotDir != "":
+		return filepath.Join(po.snapshotDir, t.Name(), po.baseName()+po.platformSuffix()+po.ext)
+	case po.goldenLayout:
+		return getGoldenSnapshotFilePath(t, getTestFile(t, skip+1), po.baseName()+po.platformSuffix(), po.ext)
+	case po.flatLayout:
+		return getFlatSnapshotFilePath(t, getTestFile(t, skip+1), po.baseName()+po.platformSuffix(), po.ext)
+	default:
+		return filepath.Clean(getSnapshotFilePath(t, skip+1, po.baseName()+po.platformSuffix(), po.ext))
+	}
+}
+
+// resolveSnapshotBaseDir resolves the directory containing the snapshot
+// directories of each test, as for getSnapshotBaseDir, applying the snapshot
+// directory option.
+func resolveSnapshotBaseDir(t testing.TB, skip int, po pathOptions) string {
+	if po.moduleDir != "" {
+		return moduleSnapshotDir(t, po.moduleDir)
+	}
+	if po.snapshotDir != "" {
+		return po.snapshotDir
+	}
+	return getSnapshotBaseDir(t, skip+po.callerSkip+1)
+}
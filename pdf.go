@@ -0,0 +1,78 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDFText returns the text of the PDF document b, one line per row of
+// text, ordered from the top of each page, with the whitespace within each
+// row collapsed to single spaces. Each page is terminated by a form feed, as
+// by pdftotext.
+func extractPDFText(b []byte) (text string, err error) {
+	// The PDF library panics on some malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+	r, err := pdf.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+	buf := new(strings.Builder)
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		rows, err := page.GetTextByRow()
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text of PDF page %d: %w", i, err)
+		}
+		for _, row := range rows {
+			words := make([]string, 0, len(row.Content))
+			for _, fragment := range row.Content {
+				words = append(words, fragment.S)
+			}
+			if line := strings.Join(strings.Fields(strings.Join(words, " ")), " "); line != "" {
+				buf.WriteString(line + "\n")
+			}
+		}
+		buf.WriteString("\f")
+	}
+	return buf.String(), nil
+}
+
+// PDFTextComparator creates a Comparator for PDF documents, which extracts the
+// text of both expected and actual and compares it using base, such as
+// StringComparator or GitHubDiffComparator to report a text diff on failure.
+// The text is extracted one line per row of text, ordered from the top of each
+// page, with the whitespace within each row collapsed, and each page
+// terminated by a form feed. Anything other than the text, such as the
+// dimensions of the pages, fonts, images and metadata, including the creation
+// time, is ignored by design. Encrypted documents are not supported.
+func PDFTextComparator(base Comparator) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		eBytes, aBytes, err := readBoth(expected, actual)
+		if err != nil {
+			msg = err.Error()
+			return
+		}
+		eText, err := extractPDFText(eBytes)
+		if err != nil {
+			msg = "expected: " + err.Error()
+			return
+		}
+		aText, err := extractPDFText(aBytes)
+		if err != nil {
+			msg = "actual: " + err.Error()
+			return
+		}
+		return base(strings.NewReader(eText), strings.NewReader(aText))
+	}
+}
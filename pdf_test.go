@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF returns a minimal PDF document with a page for each element of
+// pages, which holds the lines of text of the page. created is recorded as the
+// creation date in the document information dictionary, and width as the
+// width of each page.
+func buildPDF(created string, width int, pages ...[]string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // the page tree, which is filled in once the pages are numbered
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Producer (test) /CreationDate (D:%v) >>", created),
+	}
+	var kids []string
+	for _, lines := range pages {
+		content := new(strings.Builder)
+		for i, line := range lines {
+			fmt.Fprintf(content, "BT /F1 12 Tf 1 0 0 1 72 %d Tm (%v) Tj ET\n", 720-20*i, line)
+		}
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%vendstream", content.Len(), content))
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", width, len(objects)))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%v] /Count %d >>", strings.Join(kids, " "), len(kids))
+	buf := new(bytes.Buffer)
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(buf, "%d 0 obj\n%v\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(buf, "trailer\n<< /Size %d /Root 1 0 R /Info 4 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	text, err := extractPDFText(buildPDF("20240101000000Z", 612, []string{"Invoice  42", "Total: 10"}, []string{"Page two"}))
+	if err != nil {
+		t.Fatalf("failed to extract text: %v", err)
+	}
+	expected := "Invoice 42\nTotal: 10\n\fPage two\n\f"
+	if text != expected {
+		t.Errorf("expected %q, got %q", expected, text)
+	}
+	if _, err := extractPDFText([]byte("not a pdf")); err == nil || !strings.HasPrefix(err.Error(), "failed to read PDF") {
+		t.Errorf("expected invalid PDF to fail, got %v", err)
+	}
+}
+
+func TestPDFTextComparator(t *testing.T) {
+	cmp := PDFTextComparator(StringComparator)
+	expected := buildPDF("20240101000000Z", 612, []string{"Hello", "World"})
+	tests := []struct {
+		actual []byte
+		ok     bool
+		msg    string
+	}{
+		{buildPDF("20250601120000Z", 595, []string{"Hello", "World"}), true, ""},
+		{buildPDF("20240101000000Z", 612, []string{"Hello", "There"}), false, `expected "Hello\nWorld\n\f", got "Hello\nThere\n\f"`},
+		{buildPDF("20240101000000Z", 612, []string{"Hello"}, []string{"World"}), false, `expected "Hello\nWorld\n\f", got "Hello\n\fWorld\n\f"`},
+		{[]byte("%PDF-1.4 truncated"), false, "actual: failed to read PDF"},
+	}
+	for _, test := range tests {
+		ok, msg := cmp(bytes.NewReader(expected), bytes.NewReader(test.actual))
+		if ok != test.ok || !strings.HasPrefix(msg, test.msg) {
+			t.Errorf("expected %v, %q, got %v, %q", test.ok, test.msg, ok, msg)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package snapshot
+
+import (
+	"io"
+	"math/rand"
+)
+
+// DeterministicReader creates a SnapshotCreator which calls fn with a
+// *rand.Rand seeded with seed. Creators that draw all of their randomness from
+// the provided *rand.Rand produce the same snapshot each time they are
+// recorded, which makes recorded inputs reproducible.
+func DeterministicReader(fn func(r *rand.Rand) io.Reader, seed int64) SnapshotCreator {
+	return func() (io.Reader, error) {
+		return fn(rand.New(rand.NewSource(seed))), nil
+	}
+}
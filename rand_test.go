@@ -0,0 +1,28 @@
+package snapshot
+
+import (
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDeterministicReader(t *testing.T) {
+	fn := func(r *rand.Rand) io.Reader {
+		return strings.NewReader(strconv.Itoa(r.Int()))
+	}
+	read := func(seed int64) string {
+		in, err := DeterministicReader(fn, seed)()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return readAllUnchecked(in)
+	}
+	if first, second := read(42), read(42); first != second {
+		t.Errorf("expected the same seed to produce the same data, got %q and %q", first, second)
+	}
+	if first, second := read(42), read(43); first == second {
+		t.Errorf("expected different seeds to produce different data, got %q", first)
+	}
+}
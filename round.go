@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// floatLiteral matches decimal and scientific notation floating point
+// literals. Integers are not matched.
+var floatLiteral = regexp.MustCompile(`-?(?:\d+\.\d+(?:[eE][-+]?\d+)?|\d+[eE][-+]?\d+)`)
+
+// isNumberContext reports whether c, adjacent to a float literal, indicates
+// that the literal is part of a larger token, such as an identifier, version
+// number, date or time.
+func isNumberContext(c byte) bool {
+	return c == '_' || c == '.' || c == ':' || c == '/' || c == '-' ||
+		('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// roundFloat formats the float literal s rounded to decimals places,
+// preserving scientific notation. Negative zero is formatted as zero.
+func roundFloat(s string, decimals int) string {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	format := byte('f')
+	if strings.ContainsAny(s, "eE") {
+		format = 'e'
+	}
+	rounded := strconv.FormatFloat(v, format, decimals, 64)
+	mantissa := rounded
+	if i := strings.IndexByte(rounded, 'e'); i != -1 {
+		mantissa = rounded[:i]
+	}
+	if strings.Trim(mantissa, "-0.") == "" {
+		rounded = strings.TrimPrefix(rounded, "-")
+	}
+	return rounded
+}
+
+// RoundFloatsNormaliser creates a ReaderNormaliser which rounds the floating
+// point literals in text data to decimals places, so that insignificant
+// differences, e.g. across platforms, are ignored. Literals in scientific
+// notation, such as 1.2345e-05, are rounded in the same notation. Integers,
+// and literals that are part of a larger token such as an identifier, version
+// number, date or time, are left unmodified. A literal at the end of a
+// sentence, e.g. "took 1.5.", and both ends of a range, e.g. "1.5-2.5", are
+// rounded.
+func RoundFloatsNormaliser(decimals int) ReaderNormaliser {
+	return func(r io.Reader) io.Reader {
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return errReader{err}
+		}
+		out := new(bytes.Buffer)
+		last := 0
+		for _, loc := range floatLiteral.FindAllIndex(b, -1) {
+			start, end := loc[0], loc[1]
+			if b[start] == '-' && isRangeSeparator(b, start) {
+				// The "-" separates a range, e.g. "1.5-2.5", rather than
+				// being the sign of the literal.
+				start++
+			}
+			if (start > 0 && isNumberContext(b[start-1]) && !isRangeSeparator(b, start-1)) ||
+				(end < len(b) && isNumberContext(b[end]) && !isUnitSuffix(b, end) &&
+					!isSentencePeriod(b, end) && !isRangeSeparator(b, end)) {
+				continue
+			}
+			out.Write(b[last:start])
+			out.WriteString(roundFloat(string(b[start:end]), decimals))
+			last = end
+		}
+		out.Write(b[last:])
+		return out
+	}
+}
+
+// isUnitSuffix reports whether the float literal ending at end in b is
+// followed by a unit of letters only, e.g. "1.5ms", rather than forming part
+// of an identifier.
+func isUnitSuffix(b []byte, end int) bool {
+	i := end
+	for i < len(b) && (('a' <= b[i] && b[i] <= 'z') || ('A' <= b[i] && b[i] <= 'Z')) {
+		i++
+	}
+	return i > end && (i == len(b) || !isNumberContext(b[i]))
+}
+
+// isSentencePeriod reports whether the "." at i in b, following a float
+// literal, ends a sentence rather than continuing a token such as a version
+// number, i.e. whether it is followed by the end of the data or a character
+// which is not part of a number.
+func isSentencePeriod(b []byte, i int) bool {
+	return b[i] == '.' && (i+1 == len(b) || !isNumberContext(b[i+1]))
+}
+
+// isRangeSeparator reports whether the "-" at i in b is between two digits,
+// separating the numbers of a range such as "1.5-2.5".
+func isRangeSeparator(b []byte, i int) bool {
+	return b[i] == '-' && i > 0 && i+1 < len(b) && '0' <= b[i-1] && b[i-1] <= '9' && '0' <= b[i+1] && b[i+1] <= '9'
+}
@@ -0,0 +1,38 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoundFloatsNormaliser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		decimals int
+		expected string
+	}{
+		{"decimal", "took 1.23456 seconds", 2, "took 1.23 seconds"},
+		{"negative", "delta: -0.98765", 3, "delta: -0.988"},
+		{"negative zero", "delta: -0.0001", 2, "delta: 0.00"},
+		{"integer", "count 42", 2, "count 42"},
+		{"scientific", "rate 1.23456e-05 per second", 2, "rate 1.23e-05 per second"},
+		{"scientific integer mantissa", "n=3E8", 1, "n=3.0e+08"},
+		{"unit", "took 1.23456ms", 1, "took 1.2ms"},
+		{"identifier", "var_1.23456 x1.23456", 2, "var_1.23456 x1.23456"},
+		{"version", "v1.2.3 1.2.3", 0, "v1.2.3 1.2.3"},
+		{"date", "12.03.2024 2024-01-02", 0, "12.03.2024 2024-01-02"},
+		{"time", "10:30:15.123456", 2, "10:30:15.123456"},
+		{"multiple", "[1.005, 2.55555]", 1, "[1.0, 2.6]"},
+		{"end of sentence", "took 1.23456.\nThen 2.34567.", 2, "took 1.23.\nThen 2.35."},
+		{"range", "between 1.23456-2.34567 and -0.0001-0.0002", 2, "between 1.23-2.35 and 0.00-0.00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := ReadAll(RoundFloatsNormaliser(tt.decimals)(strings.NewReader(tt.input)))
+			if err != nil || str != tt.expected {
+				t.Errorf("expected %q, got %q, %v", tt.expected, str, err)
+			}
+		})
+	}
+}
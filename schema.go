@@ -0,0 +1,152 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// jsonSchemaURL is the URL the expected schema is compiled as, which
+// identifies it in validation errors.
+const jsonSchemaURL = "snapshot.schema.json"
+
+// inferredJSONSchemaDialect is the JSON Schema dialect of inferred schemas.
+const inferredJSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaComparator treats expected as a JSON Schema and validates the JSON
+// document in actual against it, rather than comparing the two for equality.
+// This asserts a contract, such as the shape of an evolving API response,
+// rather than an example of it. The dialect is taken from "$schema", and
+// defaults to the latest draft supported. On failure the location within
+// actual and the reason of each validation error are returned, one per line.
+// The schema can be inferred from actual when the snapshot is created with
+// WithInferredJSONSchema.
+func JSONSchemaComparator(expected, actual io.Reader) (ok bool, msg string) {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(jsonSchemaURL, expected); err != nil {
+		msg = "failed to decode expected JSON schema: " + err.Error()
+		return
+	}
+	schema, err := compiler.Compile(jsonSchemaURL)
+	if err != nil {
+		msg = "failed to compile expected JSON schema: " + err.Error()
+		return
+	}
+	aValue, err := decodeJSON(actual)
+	if err != nil {
+		msg = "failed to decode actual JSON: " + err.Error()
+		return
+	}
+	err = schema.Validate(aValue)
+	var verr *jsonschema.ValidationError
+	switch {
+	case err == nil:
+		ok = true
+	case errors.As(err, &verr):
+		msg = "actual does not conform to the JSON schema:\n" + strings.Join(jsonSchemaErrors(verr), "\n")
+	default:
+		msg = "failed to validate actual against the JSON schema: " + err.Error()
+	}
+	return
+}
+
+// jsonSchemaErrors returns the leaf errors of verr, which are the causes of
+// the failure, as "<instance location>: <message>", with the root location
+// reported as "/".
+func jsonSchemaErrors(verr *jsonschema.ValidationError) (out []string) {
+	if len(verr.Causes) == 0 {
+		location := verr.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		return []string{location + ": " + verr.Message}
+	}
+	for _, cause := range verr.Causes {
+		out = append(out, jsonSchemaErrors(cause)...)
+	}
+	return
+}
+
+// WithInferredJSONSchema compares the snapshot using JSONSchemaComparator,
+// stored with the file extension ".schema.json", and, when the snapshot is
+// created, stores a JSON Schema inferred from the actual JSON document rather
+// than the document itself. The inferred schema requires the types of all
+// values, and the presence of all object properties, in actual, but permits
+// additional properties. Items of arrays are only constrained if all of the
+// items of actual have the same schema. The schema is intended as a starting
+// point, to be reviewed and relaxed by hand.
+func WithInferredJSONSchema() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) {
+		o.InferJSONSchema = true
+		o.Comparator = JSONSchemaComparator
+		o.FileExtension = ".schema.json"
+	})
+}
+
+// inferJSONSchemaSnapshot reads the JSON document from actual and returns the
+// schema inferred from it, encoded in the same format as AsJSON, along with a
+// copy of actual.
+func inferJSONSchemaSnapshot(actual io.Reader) (schema, actualCopy io.Reader, err error) {
+	b, err := io.ReadAll(actual)
+	if err != nil {
+		err = fmt.Errorf("failed to read actual data from reader: %w", err)
+		return
+	}
+	v, err := decodeJSON(bytes.NewReader(b))
+	if err != nil {
+		err = fmt.Errorf("failed to decode actual JSON: %w", err)
+		return
+	}
+	s := inferJSONSchema(v)
+	s["$schema"] = inferredJSONSchemaDialect
+	if schema, err = AsJSON(s); err != nil {
+		return
+	}
+	actualCopy = bytes.NewReader(b)
+	return
+}
+
+// inferJSONSchema returns the JSON Schema of v, a value decoded by decodeJSON,
+// as described by WithInferredJSONSchema.
+func inferJSONSchema(v interface{}) map[string]interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		properties := make(map[string]interface{}, len(v))
+		required := make([]string, 0, len(v))
+		for k, child := range v {
+			properties[k] = inferJSONSchema(child)
+			required = append(required, k)
+		}
+		sort.Strings(required)
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	case []interface{}:
+		s := map[string]interface{}{"type": "array"}
+		var items map[string]interface{}
+		for i, child := range v {
+			childSchema := inferJSONSchema(child)
+			if i > 0 && !reflect.DeepEqual(items, childSchema) {
+				return s
+			}
+			items = childSchema
+		}
+		if items != nil {
+			s["items"] = items
+		}
+		return s
+	case json.Number:
+		return map[string]interface{}{"type": "number"}
+	case string:
+		return map[string]interface{}{"type": "string"}
+	case bool:
+		return map[string]interface{}{"type": "boolean"}
+	default:
+		return map[string]interface{}{"type": "null"}
+	}
+}
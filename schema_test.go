@@ -0,0 +1,74 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONSchemaComparator(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {"id": {"type": "integer"}, "name": {"type": "string"}},
+		"required": ["id", "name"]
+	}`
+	tests := []struct {
+		expected, actual string
+		ok               bool
+		msg              string
+	}{
+		{schema, `{"id": 1, "name": "a", "extra": true}`, true, ""},
+		{schema, `{"id": "1"}`, false, "actual does not conform to the JSON schema:\n/: missing properties: 'name'\n/id: expected integer, but got string"},
+		{schema, `{`, false, "failed to decode actual JSON: unexpected EOF"},
+		{`{`, `{}`, false, "failed to decode expected JSON schema: "},
+		{`{"type": 1}`, `{}`, false, "failed to compile expected JSON schema: "},
+	}
+	for _, test := range tests {
+		ok, msg := JSONSchemaComparator(strings.NewReader(test.expected), strings.NewReader(test.actual))
+		if ok != test.ok || !strings.HasPrefix(msg, test.msg) {
+			t.Errorf("expected %v, %q, got %v, %q", test.ok, test.msg, ok, msg)
+		}
+	}
+}
+
+func TestInferJSONSchema(t *testing.T) {
+	tests := []struct {
+		actual, expected string
+	}{
+		{`null`, `{"type":"null"}`},
+		{`[1, 2.5]`, `{"type":"array","items":{"type":"number"}}`},
+		{`[1, "a"]`, `{"type":"array"}`},
+		{`[]`, `{"type":"array"}`},
+		{`{"b": true, "a": "x"}`, `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"boolean"}},"required":["a","b"]}`},
+	}
+	for _, test := range tests {
+		v, err := decodeJSON(strings.NewReader(test.actual))
+		if err != nil {
+			t.Fatalf("failed to decode %q: %v", test.actual, err)
+		}
+		expected, _ := decodeJSON(strings.NewReader(test.expected))
+		actual, _ := AsJSON(inferJSONSchema(v))
+		if ok, msg := JSONComparator(strings.NewReader(test.expected), actual); !ok {
+			t.Errorf("unexpected schema for %q, expected %v: %v", test.actual, expected, msg)
+		}
+	}
+}
+
+func TestMatchInferredJSONSchema(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	if ok, msg := Match(t, strings.NewReader(`{"id": 1, "tags": ["a"]}`), WithInferredJSONSchema(), dirOpt); !ok {
+		t.Fatalf("expected created schema to match: %v", msg)
+	}
+	schemaP := strings.TrimSuffix(outputP, ".txt") + ".schema.json"
+	if str := readAllUnchecked(openUnchecked(t, schemaP)); !strings.Contains(str, `"$schema": "https://json-schema.org/draft/2020-12/schema"`) {
+		t.Errorf("expected an inferred schema to be stored, got %q", str)
+	}
+	if ok, msg := Match(t, strings.NewReader(`{"id": 2, "tags": [], "new": 1}`), WithInferredJSONSchema(), dirOpt); !ok {
+		t.Errorf("expected conforming document to match: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader(`{"id": "2", "tags": []}`), WithInferredJSONSchema(), dirOpt); ok || !strings.Contains(msg, "/id: expected number, but got string") {
+		t.Errorf("expected non-conforming document to fail, got %v, %q", ok, msg)
+	}
+	if ok, msg := Match(t, strings.NewReader(`[`), WithInferredJSONSchema(), WithSnapshotName("invalid"), dirOpt); ok || !strings.HasPrefix(msg, "failed to infer JSON schema: ") {
+		t.Errorf("expected invalid JSON to fail, got %v, %q", ok, msg)
+	}
+}
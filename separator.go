@@ -0,0 +1,50 @@
+package snapshot
+
+import (
+	"bytes"
+	"io"
+)
+
+// PathSeparatorNormaliser is a ReaderNormaliser that converts Windows path
+// separators to "/", so that output containing file paths, which render with
+// "\" on Windows and "/" elsewhere, has the same snapshot on every platform.
+// Outside of double quoted strings every "\" is converted. Within them, as in
+// JSON or Go string literals, an escaped "\\" is converted to a single "/" and
+// other escape sequences, such as "\n" and "\"", are kept. Quoted strings end
+// at the end of the line, so that an unbalanced quote does not affect the rest
+// of the data.
+//
+// The conversion is naive: it does not know which backslashes are part of a
+// path, so any other backslash outside of a quoted string, such as an escape
+// sequence in unquoted output or a regular expression, is also converted. If
+// the data cannot be read, the returned io.Reader fails with the error.
+func PathSeparatorNormaliser(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errReader{err}
+	}
+	out := make([]byte, 0, len(b))
+	quoted := false
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '\n':
+			quoted = false
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && !quoted:
+			out = append(out, '/')
+			continue
+		case c == '\\' && i+1 < len(b) && b[i+1] == '\\':
+			out = append(out, '/')
+			i++
+			continue
+		case c == '\\' && i+1 < len(b) && b[i+1] != '\n':
+			// Keep other escape sequences, including an escaped quote.
+			out = append(out, b[i:i+2]...)
+			i++
+			continue
+		}
+		out = append(out, b[i])
+	}
+	return bytes.NewReader(out)
+}
@@ -0,0 +1,41 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPathSeparatorNormaliser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"unquoted", `wrote C:\out\report.txt`, `wrote C:/out/report.txt`},
+		{"unc", `\\server\share`, `//server/share`},
+		{"unix", "wrote /out/report.txt", "wrote /out/report.txt"},
+		{"json", `{"path": "C:\\out\\report.txt"}`, `{"path": "C:/out/report.txt"}`},
+		{"escapes", `"line\n\"C:\\tmp\"\t"`, `"line\n\"C:/tmp\"\t"`},
+		{"unbalanced quote", "say \"hi\nC:\\tmp", "say \"hi\nC:/tmp"},
+		{"trailing backslash", `"dir\`, `"dir\`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := ReadAll(PathSeparatorNormaliser(strings.NewReader(tt.input)))
+			if err != nil || str != tt.expected {
+				t.Errorf("expected %q, got %q, %v", tt.expected, str, err)
+			}
+		})
+	}
+}
+
+func TestMatchPathSeparatorNormaliser(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	normOpt := WithReaderNormaliser(PathSeparatorNormaliser)
+	if ok, msg := Match(t, strings.NewReader(`built pkg\cmd\tool.exe`), dirOpt, normOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("built pkg/cmd/tool.exe"), dirOpt, normOpt); !ok {
+		t.Errorf("expected path separators to be ignored: %v", msg)
+	}
+}
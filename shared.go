@@ -0,0 +1,34 @@
+package snapshot
+
+import (
+	"io"
+	"testing"
+)
+
+// MatchShared behaves as Match, but stores the snapshot named sharedName in
+// the __shared__ directory beside the snapshot directories of each test, e.g.
+// __snapshots__/__shared__/<sharedName>.txt, rather than in the directory of
+// the current test, so that several tests can assert that they produce the
+// same output. The first test to run creates the snapshot and the others are
+// compared with it. Concurrent calls, e.g. from parallel tests, are serialised
+// as for Match, so that only one of them creates the snapshot. The snapshot is
+// shared by the tests whose files are in the same directory, or which use the
+// same SnapshotDir, and the layouts of WithFlatLayout and WithGoldenConvention
+// are ignored.
+// For example:
+//
+//	func TestRenderFromFile(t *testing.T) {
+//		ok, msg := snapshot.MatchShared(t, renderFile("page.md"), "page")
+//		...
+//	}
+//
+//	func TestRenderFromString(t *testing.T) {
+//		ok, msg := snapshot.MatchShared(t, renderString(page), "page")
+//		...
+//	}
+func MatchShared(t *testing.T, actual io.Reader, sharedName string, optFns ...MatchOption) (ok bool, msg string) {
+	optFns = append(optFns, WithSnapshotName(sharedName), MatchOptionFunc(func(o *MatchOptions) {
+		o.Shared = true
+	}))
+	return match(t, 1, actual, optFns...)
+}
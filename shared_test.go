@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchShared(t *testing.T) {
+	dir := t.TempDir()
+	dirOpt := WithSnapshotDir(dir)
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"first", "second", "third"} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				if ok, msg := MatchShared(t, strings.NewReader("hello"), "greeting", dirOpt); !ok {
+					t.Errorf("expected shared match to succeed: %v", msg)
+				}
+			})
+		}
+	})
+	b, err := os.ReadFile(filepath.Join(dir, "__shared__", "greeting.txt"))
+	if err != nil || string(b) != "hello" {
+		t.Fatalf("unexpected shared snapshot: %q, %v", b, err)
+	}
+	t.Run("different", func(t *testing.T) {
+		if ok, _ := MatchShared(t, strings.NewReader("goodbye"), "greeting", dirOpt); ok {
+			t.Errorf("expected different output not to match the shared snapshot")
+		}
+	})
+	if _, err := os.Stat(filepath.Join(dir, t.Name())); !os.IsNotExist(err) {
+		t.Errorf("expected no per-test snapshot directory: %v", err)
+	}
+}
+
+func TestMatchSharedGoldenConvention(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMemStore()
+	if ok, msg := MatchShared(t, strings.NewReader("page"), "page", WithGoldenConvention(), WithStore(store)); !ok {
+		t.Fatalf("expected shared match to succeed: %v", msg)
+	}
+	expected := []string{filepath.Join(wd, "__snapshots__", "__shared__", "page.golden")}
+	if paths := store.paths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+}
@@ -0,0 +1,61 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// snapshotSizeError returns the error for a snapshot that exceeds the size
+// configured with WithMaxSnapshotSize.
+func snapshotSizeError(n int64) error {
+	return fmt.Errorf("snapshot exceeded %d bytes", n)
+}
+
+// sizeLimitedReader reads from r, failing once more than n bytes are read.
+type sizeLimitedReader struct {
+	r    io.Reader
+	n    int64
+	read int64
+}
+
+func (lr *sizeLimitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.n {
+		return n, snapshotSizeError(lr.n)
+	}
+	return n, err
+}
+
+// limitSnapshotSize returns a reader that fails with a clear error once more
+// than n bytes have been read from r. If n is not positive, r is returned
+// unmodified.
+func limitSnapshotSize(r io.Reader, n int64) io.Reader {
+	if n <= 0 {
+		return r
+	}
+	return &sizeLimitedReader{r: io.LimitReader(r, n+1), n: n}
+}
+
+// limitExistingSnapshot returns an error if the snapshot file read by r is
+// larger than n bytes, when r can report its size, such as an *os.File.
+// Otherwise r is returned wrapped as for limitSnapshotSize, so that the limit
+// is enforced as it is read. If n is not positive, r is returned unmodified.
+func limitExistingSnapshot(r io.Reader, n int64) (io.Reader, error) {
+	if n <= 0 {
+		return r, nil
+	}
+	file, ok := r.(interface{ Stat() (os.FileInfo, error) })
+	if !ok {
+		return limitSnapshotSize(r, n), nil
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > n {
+		return nil, snapshotSizeError(n)
+	}
+	return r, nil
+}
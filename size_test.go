@@ -0,0 +1,57 @@
+package snapshot
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLimitSnapshotSize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		limit int64
+		err   bool
+	}{
+		{name: "under limit", input: "hello", limit: 10},
+		{name: "at limit", input: "hello", limit: 5},
+		{name: "over limit", input: "hello world", limit: 5, err: true},
+		{name: "no limit", input: "hello world", limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := ReadAll(limitSnapshotSize(strings.NewReader(tt.input), tt.limit))
+			if tt.err {
+				if err == nil || err.Error() != "snapshot exceeded 5 bytes" {
+					t.Fatalf("expected size error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if str != tt.input {
+				t.Fatalf("expected %q, got %q", tt.input, str)
+			}
+		})
+	}
+}
+
+func TestMaxSnapshotSizeUnboundedReader(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	r, w := io.Pipe()
+	go func() {
+		for {
+			if _, err := w.Write([]byte("hello")); err != nil {
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { _ = r.Close() })
+	expectedMsg := "failed to read actual data from reader: snapshot exceeded 100 bytes"
+	if ok, msg := Match(t, r, WithMaxSnapshotSize(100), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected match to fail with %q, got %v, %q", expectedMsg, ok, msg)
+	}
+}
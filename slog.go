@@ -0,0 +1,63 @@
+package snapshot
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// WithRedactedLogAttrs replaces the values of the log attributes named by keys
+// with IgnoredPlaceholder in the records captured by MatchSlog, for volatile
+// attributes such as "pid". Attributes within groups are named by their dot
+// separated path, e.g. "request.id".
+func WithRedactedLogAttrs(keys ...string) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) {
+		o.RedactedLogAttrs = append(o.RedactedLogAttrs, keys...)
+	})
+}
+
+// newSlogHandler creates a slog.Handler which writes JSON records to buf, with
+// the time of each record replaced by TimePlaceholder and the attributes in
+// redacted replaced by IgnoredPlaceholder.
+func newSlogHandler(buf *bytes.Buffer, redacted []string) slog.Handler {
+	redact := make(map[string]bool, len(redacted))
+	for _, key := range redacted {
+		redact[key] = true
+	}
+	return slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			switch {
+			case len(groups) == 0 && a.Key == slog.TimeKey:
+				return slog.String(a.Key, TimePlaceholder)
+			case redact[strings.Join(append(groups, a.Key), ".")]:
+				return slog.String(a.Key, IgnoredPlaceholder)
+			}
+			return a
+		},
+	})
+}
+
+// MatchSlog behaves as Match, comparing the log records written by fn to the
+// slog.Logger it is passed with the output snapshot. The logger writes each
+// record as a line of JSON, at all levels including debug, with the time of
+// each record replaced by TimePlaceholder so that the snapshot is
+// deterministic. Other volatile attributes can be redacted with
+// WithRedactedLogAttrs. By default, the snapshot is stored with the ".jsonl"
+// file extension and compared with JSONLComparator, which may be overridden
+// by optFns. If fn returns an error, Match is not called and the error is
+// returned in msg.
+func MatchSlog(t *testing.T, fn func(logger *slog.Logger) error, optFns ...MatchOption) (ok bool, msg string) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(newSlogHandler(buf, newMatchOptions(optFns).RedactedLogAttrs))
+	if err := fn(logger); err != nil {
+		msg = "captured function failed: " + err.Error()
+		return
+	}
+	optFns = append([]MatchOption{
+		WithSnapshotFileExtension(".jsonl"),
+		WithComparator(JSONLComparator),
+	}, optFns...)
+	return match(t, 1, buf, optFns...)
+}
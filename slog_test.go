@@ -0,0 +1,41 @@
+package snapshot
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMatchSlog(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	run := func(user string) func(*slog.Logger) error {
+		return func(logger *slog.Logger) error {
+			logger.Debug("starting", "pid", os.Getpid())
+			logger.Info("request", slog.Group("request", "id", user+"-1", "user", user))
+			return nil
+		}
+	}
+	redact := WithRedactedLogAttrs("pid", "request.id")
+	if ok, msg := MatchSlog(t, run("alice"), redact, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	expected := `{"time":"<TIME>","level":"DEBUG","msg":"starting","pid":"<IGNORED>"}` + "\n" +
+		`{"time":"<TIME>","level":"INFO","msg":"request","request":{"id":"<IGNORED>","user":"alice"}}` + "\n"
+	p := strings.TrimSuffix(outputP, ".txt") + ".jsonl"
+	if str := readAllUnchecked(openUnchecked(t, p)); str != expected {
+		t.Fatalf("unexpected snapshot. expected %q, got %q", expected, str)
+	}
+	if ok, msg := MatchSlog(t, run("alice"), redact, dirOpt); !ok {
+		t.Errorf("expected same logs to match: %v", msg)
+	}
+	if ok, msg := MatchSlog(t, run("bob"), redact, dirOpt); ok || !strings.Contains(msg, "record 2 differs") {
+		t.Errorf("expected second record to differ, got %v, %q", ok, msg)
+	}
+	expectedMsg := "captured function failed: boom"
+	ok, msg := MatchSlog(t, func(*slog.Logger) error { return errors.New("boom") }, dirOpt)
+	if ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
@@ -4,34 +4,100 @@ package snapshot
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 // getSnapshotFilePath returns a path to file named name.ext  located within
 // directory __snapshots__ directory at the same level as the file that
 // contains the currently running test. This directory is named after the test
-// name and is therefore unique to each test.
-func getSnapshotFilePath(t *testing.T, name, ext string) string {
-	_, file, _, _ := runtime.Caller(2)
-	return filepath.Join(filepath.Dir(file), "__snapshots__", t.Name(), name+ext)
+// name and is therefore unique to each test. The test file is determined from
+// the caller of the function calling getSnapshotFilePath, skipping a further
+// skip stack frames for calls made through wrappers within this package.
+func getSnapshotFilePath(t testing.TB, skip int, name, ext string) string {
+	return filepath.Join(getSnapshotBaseDir(t, skip+1), t.Name(), name+ext)
 }
 
+// getSnapshotBaseDir returns the path of the __snapshots__ directory at the
+// same level as the file that contains the currently running test, which is
+// determined as for getSnapshotFilePath.
+func getSnapshotBaseDir(t testing.TB, skip int) string {
+	return filepath.Join(filepath.Dir(getTestFile(t, skip+1)), "__snapshots__")
+}
+
+// getTestFile returns the path of the file that contains the currently
+// running test, which is determined as for getSnapshotFilePath. The test fails
+// if the caller cannot be determined.
+func getTestFile(t testing.TB, skip int) string {
+	_, file, _, ok := runtime.Caller(2 + skip)
+	if !ok || file == "" {
+		t.Fatalf("failed to determine the file containing the test from the call stack, " +
+			"so the snapshot directory is unknown. Use WithCallerSkip to skip any helper functions " +
+			"between the test and the call to this package, or WithSnapshotDir to set the snapshot directory")
+	}
+	return file
+}
+
+// openNewSnapshotFile creates the snapshot file at p in s, overwriting any
+// existing file. With the default Store, any missing parent directories are
+// created, and the file is opened write-only, so that reading it back always
+// goes through a separate read-only handle. The caller must close the file,
+// which completes it.
+func openNewSnapshotFile(s Store, p string) (io.WriteCloser, error) {
+	file, err := s.Create(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open newly created snapshot file: %v: %v", p, err.Error())
+	}
+	return file, nil
+}
+
+// closeSnapshotFiles closes each of files, returning the first error.
+func closeSnapshotFiles(files []io.WriteCloser) (err error) {
+	for _, file := range files {
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
+// DefaultNoAutoCreate is the default value of the NoAutoCreate option of
+// GetTestInput and Match. Setting this to true, e.g. from TestMain, disables
+// the automatic creation of missing snapshots for the whole package.
+var DefaultNoAutoCreate = false
+
+// noAutoCreateMessage is the failure message when a snapshot file does not
+// exist and automatic snapshot creation is disabled.
+const noAutoCreateMessage = "snapshot file %q does not exist and automatic snapshot creation is disabled. " +
+	"Generate the snapshot deliberately using Generate, or by running the test once with automatic " +
+	"creation enabled (without WithNoAutoCreate and with DefaultNoAutoCreate unset, or with -update-snapshots=new)"
+
 // A SnapshotCreator is a function that can be provided to GetTestInput which
 // will be used used in the case where an input snapshot file does not exist.
 type SnapshotCreator func() (io.Reader, error)
 
+// A SnapshotDirCreator is a function that can be provided to GetTestInputDir
+// which populates dir with the input files when the input snapshot directory
+// does not exist.
+type SnapshotDirCreator func(dir string) error
+
 // GetTestInputOptions are the set of options to configure the behaviour of
 // GetTestInput.
 type GetTestInputOptions struct {
 	// SnapshotName is the name of the snapshot to load, without the
 	// extension. This defaults to the value "input".
 	SnapshotName string
+	// InputFallbackNames are the snapshot names which are tried in order if
+	// the snapshot named SnapshotName does not exist, before calling
+	// CreateSnapshot. The first which exists is used. This defaults to nil.
+	InputFallbackNames []string
 	// FileExtension is the file extension of the snapshot. This defaults
 	// to ".txt".
 	FileExtension string
@@ -41,6 +107,92 @@ type GetTestInputOptions struct {
 	// This is useful in cases where input data may be volatile or random
 	// and would therefore usually be unsuitable for snapshot tests.
 	CreateSnapshot SnapshotCreator
+	// CreateInputDir will be called by GetTestInputDir to populate the
+	// input snapshot directory if it does not exist. This defaults to nil.
+	CreateInputDir SnapshotDirCreator
+	// CreateAttempts is the number of times CreateSnapshot is called
+	// before giving up, if it returns an error. This defaults to 1.
+	CreateAttempts int
+	// CreateBackoff is the delay before the first retry of CreateSnapshot,
+	// which doubles after each subsequent attempt. This defaults to 0.
+	CreateBackoff time.Duration
+	// CreateTimeout is the maximum time for CreateSnapshot to return, and
+	// for its data to then be read in full, after which reading the input
+	// fails and no snapshot file is left behind. This defaults to 0, which
+	// disables the timeout.
+	CreateTimeout time.Duration
+	// TimeFormat is the layout used to format times when values are
+	// serialised as JSON, as for FormatTimes. This defaults to the empty
+	// string, which serialises times unmodified.
+	TimeFormat string
+	// Version is appended to the snapshot name, as <name>.<version>.<ext>.
+	// When a versioned snapshot is created, it is also copied to
+	// <name>.latest.<ext>. This defaults to the empty string, which
+	// disables versioning.
+	Version string
+	// PlatformSpecific causes the snapshot file to be named
+	// <name>.<GOOS>.<ext>, falling back to <name>.<ext> if only the base
+	// snapshot exists. New snapshots are always platform specific. This
+	// defaults to false.
+	PlatformSpecific bool
+	// FlatLayout stores the snapshot file alongside the test file, named
+	// <testfile>.<testname>.<name><ext>, rather than within the
+	// __snapshots__ directory. This defaults to false.
+	FlatLayout bool
+	// GoldenLayout stores the snapshot file in the testdata directory
+	// beside the test file, named testdata/<testname>.<name><ext>, or
+	// testdata/<testname><ext> if the snapshot name is empty. This takes
+	// precedence over FlatLayout. This defaults to false.
+	GoldenLayout bool
+	// Base64Encoding stores the snapshot file base64 encoded, with ".b64"
+	// appended to FileExtension. This defaults to false.
+	Base64Encoding bool
+	// EncryptionKey is the AES key the snapshot file is encrypted with,
+	// using AES-GCM, with ".enc" appended to FileExtension. This defaults
+	// to nil, which stores the snapshot in plaintext.
+	EncryptionKey []byte
+	// SnapshotDir is the directory containing the snapshot directories of
+	// each test, in place of the __snapshots__ directory beside the test
+	// file. This takes precedence over FlatLayout. This defaults to the
+	// empty string, which uses the __snapshots__ directory.
+	SnapshotDir string
+	// ModuleRelativeSnapshotDir is the directory containing the snapshot
+	// directories of each test, relative to the root of the Go module. It is
+	// used as SnapshotDir, which must be empty. This defaults to the
+	// snapshot_dir of the .snapshotrc project config file, or the empty
+	// string.
+	ModuleRelativeSnapshotDir string
+	// ContentStore is the directory in which the content of snapshots is
+	// stored, named by its hash, with the snapshot file holding a pointer
+	// to it. This defaults to the empty string, which stores the content
+	// in the snapshot file.
+	ContentStore string
+	// Store holds the snapshot files, as for WithStore. This defaults to
+	// nil, which stores them on the local filesystem.
+	Store Store
+	// Checksum records the checksum of the snapshot file in a sidecar file
+	// when it is created, and verifies it when it is read, as for
+	// WithChecksum. This defaults to false.
+	Checksum bool
+	// CallerSkip is the number of additional stack frames to skip when
+	// determining the file that contains the test, which locates the
+	// snapshot directory. This is useful when snapshot functions are
+	// called from helper functions in another file. This defaults to 0.
+	CallerSkip int
+	// NoAutoCreate causes the test to fail when the snapshot file does not
+	// exist, rather than creating it, unless the -update-snapshots flag is
+	// new or all. This defaults to DefaultNoAutoCreate, or true if set by
+	// the no_auto_create of the .snapshotrc project config file.
+	NoAutoCreate bool
+	// MaxSnapshotSize is the maximum size of the snapshot data in bytes.
+	// Reading or writing more data than this fails the test, rather than
+	// blocking on an unbounded reader. This defaults to 0, which disables
+	// the limit.
+	MaxSnapshotSize int64
+	// Logger receives the informational messages, such as the resolved
+	// snapshot filename. This defaults to nil, which logs to the test with
+	// t.Logf.
+	Logger Logger
 }
 
 // GetTestInputOption may be an argument to GetTestInput in order to change
@@ -52,56 +204,292 @@ type GetTestInputOption interface {
 // GetTestInput gets loads the input snapshot for a particular test case.  By
 // default, this looks for the file at the location
 // <test-directory>/__snapshots__/<test-name>/input.txt. The base directory
-// cannot be changed, however the file name can be overridden using with the
-// relevant GetTestInputOption arguments.  If the input snapshot file does not
+// can be overridden with WithSnapshotDir, and the file name can be overridden
+// using the relevant GetTestInputOption arguments.  If the input snapshot file does not
 // exist, the test immediately fails, unless a SnapshotCreator is provided
 // as an argument. In this case the resulting reader for the SnapshotCreator is
 // used as the input data for the current test run and persisted to disk for
-// use in subsequent test runs.
+// use in subsequent test runs. The SnapshotCreator is only called when the
+// returned reader is first read, so an expensive creator is skipped if the
+// test never reads its input, and any error from it is returned by that Read.
+// The returned reader is read-only, and cannot be
+// type asserted to the underlying *os.File, so that the snapshot cannot be
+// modified by accident. As for Match, the test is skipped if
+// snapshot testing is disabled by SNAPSHOTS=off.
 func GetTestInput(t *testing.T, optFns ...GetTestInputOption) (out io.Reader) {
+	return getTestInput(t, 1, optFns...)
+}
+
+// getTestInput implements GetTestInput, skipping a further skip stack frames
+// when determining the file that contains the test.
+func getTestInput(t *testing.T, skip int, optFns ...GetTestInputOption) (out io.Reader) {
+	skipIfSnapshotsDisabled(t)
+	opts := newGetTestInputOptions(optFns)
+	p := resolveInputFilePath(t, skip, opts)
+	existing, in := openTestInput(t, p, opts)
+	if existing != nil {
+		out = readOnlyReader{existing}
+		return
+	}
+	out = in
+	return
+}
+
+// GetTestInputSeeker behaves as GetTestInput, but returns an io.ReadSeeker for
+// consumers that require random access to the input data. When the input
+// snapshot is created, the SnapshotCreator's data is written to disk in full
+// and served from memory.
+func GetTestInputSeeker(t *testing.T, optFns ...GetTestInputOption) (out io.ReadSeeker) {
+	skipIfSnapshotsDisabled(t)
+	opts := newGetTestInputOptions(optFns)
+	p := resolveInputFilePath(t, 0, opts)
+	existing, in := openTestInput(t, p, opts)
+	if rs, ok := existing.(io.ReadSeeker); ok {
+		out = readSeeker{rs}
+		return
+	}
+	if existing != nil {
+		in = existing
+	}
+	b, err := io.ReadAll(in)
+	if err != nil {
+		t.Fatalf("failed to create input snapshot file %v: %v", p, err.Error())
+	}
+	out = bytes.NewReader(b)
+	return
+}
+
+// resolveInputFilePath resolves the path of the input snapshot file for the
+// currently running test, as for resolveSnapshotFilePath. If the snapshot
+// does not exist, the path of the first of InputFallbackNames which exists is
+// returned instead. The fallbacks are not tried when an existing snapshot
+// would be recreated by -update-snapshots=all, so that only the snapshot named
+// SnapshotName is overwritten.
+func resolveInputFilePath(t *testing.T, skip int, opts GetTestInputOptions) string {
+	po := opts.pathOptions()
+	p := resolveSnapshotFilePath(t, skip+1, po, false)
+	if len(opts.InputFallbackNames) == 0 || po.store.Exists(p) {
+		return p
+	}
+	if opts.CreateSnapshot != nil && snapshotUpdateMode(t) == updateAll {
+		return p
+	}
+	for _, name := range opts.InputFallbackNames {
+		po.name = name
+		if fallback := resolveSnapshotFilePath(t, skip+1, po, false); po.store.Exists(fallback) {
+			resolveLogger(t, opts.Logger)("input snapshot %q does not exist, falling back to %q", opts.SnapshotName, name)
+			return fallback
+		}
+	}
+	return p
+}
+
+// readOnlyReader hides any methods other than Read of the wrapped io.Reader,
+// so that a snapshot file cannot be written or closed through it.
+type readOnlyReader struct {
+	io.Reader
+}
+
+// lazyInput is the io.Reader of an input snapshot which does not exist. The
+// SnapshotCreator is only called on the first Read, so that an expensive
+// creator is not called if the test never reads its input, and its data is
+// written to the snapshot file as it is read. The file is closed once the data
+// is exhausted. Any error from the creator is returned by Read, and the
+// partially written file is removed if reading or writing its data fails.
+type lazyInput struct {
+	t     *testing.T
+	p     string
+	opts  GetTestInputOptions
+	r     io.Reader
+	w     io.WriteCloser
+	files []io.WriteCloser
+	paths []string
+	err   error
+}
+
+func (l *lazyInput) Read(p []byte) (n int, err error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if l.r == nil {
+		if l.err = l.create(); l.err != nil {
+			return 0, l.err
+		}
+	}
+	n, err = l.r.Read(p)
+	if err == io.EOF {
+		if cerr := l.close(); cerr != nil {
+			err = cerr
+			l.err = err
+			l.remove()
+		}
+	} else if err != nil {
+		l.err = err
+		l.remove()
+	}
+	return
+}
+
+// create calls the SnapshotCreator and creates the snapshot file, which the
+// creator's data is copied to as it is read.
+func (l *lazyInput) create() error {
+	if err := checkEncryptionKey(l.opts.EncryptionKey); err != nil {
+		return err
+	}
+	in, err := callSnapshotCreatorWithTimeout(l.t, l.opts)
+	if err != nil {
+		return fmt.Errorf("snapshot creator failed with an error: %w", err)
+	}
+	resolveLogger(l.t, l.opts.Logger)("creating new input snapshot")
+	po := l.opts.pathOptions()
+	paths := []string{l.p}
+	if l.opts.Version != "" {
+		paths = append(paths, po.latestPath(l.p))
+	}
+	writers := make([]io.Writer, 0, len(paths))
+	for _, p := range paths {
+		file, err := openNewSnapshotFile(po.store, p)
+		if err != nil {
+			l.remove()
+			return err
+		}
+		l.files = append(l.files, file)
+		l.paths = append(l.paths, p)
+		writers = append(writers, file)
+	}
+	l.w = encodeSnapshot(io.MultiWriter(writers...), l.opts.encoding())
+	l.r = io.TeeReader(limitSnapshotSize(in, l.opts.MaxSnapshotSize), l.w)
+	recordResult(l.t.Name(), l.p, true, true, "")
+	return nil
+}
+
+// close flushes and closes the snapshot files, if they have been created,
+// returning the first error. The files are then interned if ContentStore is
+// set.
+func (l *lazyInput) close() (err error) {
+	if l.w == nil {
+		return nil
+	}
+	err = l.w.Close()
+	l.w = nil
+	if cerr := closeSnapshotFiles(l.files); err == nil {
+		err = cerr
+	}
+	l.files = nil
+	if err == nil && l.opts.ContentStore != "" {
+		err = internSnapshots(l.p, l.opts.pathOptions(), l.opts.ContentStore)
+	}
+	if err == nil && l.opts.Checksum {
+		err = writeChecksum(resolveStore(l.opts.Store), l.p)
+	}
+	return
+}
+
+// remove closes and removes the snapshot files, if they have been created,
+// without flushing them, so that no partial snapshot is left behind.
+func (l *lazyInput) remove() {
+	l.w = nil
+	_ = closeSnapshotFiles(l.files)
+	l.files = nil
+	store := resolveStore(l.opts.Store)
+	for _, p := range l.paths {
+		_ = removeFromStore(store, p)
+	}
+	l.paths = nil
+}
+
+// readSeeker hides any methods other than Read and Seek of the wrapped
+// io.ReadSeeker, so that a snapshot file cannot be written or closed through
+// it.
+type readSeeker struct {
+	io.ReadSeeker
+}
+
+// newGetTestInputOptions applies optFns to the default GetTestInputOptions.
+func newGetTestInputOptions(optFns []GetTestInputOption) GetTestInputOptions {
+	cfg := configDefaults()
 	opts := GetTestInputOptions{
-		SnapshotName:   "input",
-		FileExtension:  ".txt",
-		CreateSnapshot: nil,
+		SnapshotName:              "input",
+		FileExtension:             ".txt",
+		CreateSnapshot:            nil,
+		CreateAttempts:            1,
+		NoAutoCreate:              DefaultNoAutoCreate || cfg.noAutoCreate,
+		ModuleRelativeSnapshotDir: cfg.snapshotDir,
 	}
 	for _, opt := range optFns {
 		opt.ApplyInputOption(&opts)
 	}
+	return opts
+}
 
-	p := filepath.Clean(getSnapshotFilePath(t, opts.SnapshotName, opts.FileExtension))
-	file, err := os.Open(p)
-	t.Logf("input snapshot filename: %v", p)
+// openTestInput opens the input snapshot file at p. If the file exists it is
+// returned as existing. Otherwise a lazyInput is returned as in, which calls
+// the SnapshotCreator and creates the file when it is first read.
+func openTestInput(t *testing.T, p string, opts GetTestInputOptions) (existing, in io.Reader) {
+	logf := resolveLogger(t, opts.Logger)
+	file, err := openSnapshotFileForUpdate(t, resolveStore(opts.Store), p, opts.ContentStore, opts.CreateSnapshot != nil)
+	logf("input snapshot filename: %v", p)
 	if err == nil {
 		t.Cleanup(func() { _ = file.Close() })
-		t.Logf("using existing snapshot")
-		out = io.NopCloser(file)
+		logf("using existing snapshot")
+		if opts.Checksum {
+			if err = verifyChecksum(resolveStore(opts.Store), p); err != nil {
+				t.Fatal(err.Error())
+			}
+		}
+		if existing, err = limitExistingSnapshot(file, opts.MaxSnapshotSize); err != nil {
+			t.Fatalf("failed to read input snapshot file %v: %v", p, err.Error())
+		}
+		if opts.Base64Encoding || opts.EncryptionKey != nil {
+			b, err := io.ReadAll(decodeSnapshot(existing, opts.encoding()))
+			if err != nil {
+				t.Fatalf("failed to decode input snapshot file %v: %v", p, err.Error())
+			}
+			existing = bytes.NewReader(b)
+		}
+		recordResult(t.Name(), p, false, true, "")
 		return
 	}
-	if os.IsNotExist(err) {
+	if errors.Is(err, fs.ErrNotExist) {
+		checkSnapshotChange(t, p, "created")
+		if autoCreateDisabled(t, opts.NoAutoCreate) {
+			t.Fatalf(noAutoCreateMessage, p)
+		}
 		if opts.CreateSnapshot == nil {
 			t.Fatalf("snapshot file %q does not exist and no CreateSnapshot option was provided", p)
 		}
-		in, err := opts.CreateSnapshot()
-		if err != nil {
-			t.Fatalf("snapshot creator failed with an error %v", err)
-		}
-		t.Log("creating new input snapshot")
-		err = os.MkdirAll(filepath.Dir(p), 0750)
-		if err != nil {
-			t.Fatalf("failed to create input snapshot file %v: %v", p, err.Error())
-		}
-		file, err := os.Create(p)
-		if err != nil {
-			t.Fatalf("failed to open newly created snapshot file: %v: %v", p, err.Error())
-		}
-		t.Cleanup(func() { _ = file.Close() })
-		out = io.TeeReader(in, file)
+		lazy := &lazyInput{t: t, p: p, opts: opts}
+		t.Cleanup(func() { _ = lazy.close() })
+		in = lazy
 	} else {
-		t.Fatalf("error opening input snapshot file")
+		t.Fatalf("error opening input snapshot file %v: %v", p, err.Error())
 	}
 	return
 }
 
+// callSnapshotCreator calls the CreateSnapshot function of opts, retrying up
+// to CreateAttempts times with exponential backoff if it returns an error,
+// logging each retry to logf. The error from the last attempt is returned.
+// Retrying stops once stop is closed, if it is not nil.
+func callSnapshotCreator(logf Logger, opts GetTestInputOptions, stop <-chan struct{}) (in io.Reader, err error) {
+	backoff := opts.CreateBackoff
+	for attempt := 1; ; attempt++ {
+		in, err = opts.CreateSnapshot()
+		if err == nil || attempt >= opts.CreateAttempts {
+			return
+		}
+		logf("snapshot creator attempt %d of %d failed, retrying in %v: %v", attempt, opts.CreateAttempts, backoff, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return
+		}
+		backoff *= 2
+	}
+}
+
 // A Comparator can be used to override the default snapshot comparison. This
 // function should compare the expected and actual io.Readers and return ok as
 // true if they are deemed equal. The return value msg should be a human
@@ -111,6 +499,14 @@ type Comparator func(expected, actual io.Reader) (ok bool, msg string)
 // A ReaderNormaliser is a function that takes an io.Reader and returns a new
 // io.Reader after some processing. This function is applied to the actual and
 // expected io.Readers before they are passed to the Comparator
+//
+// A ReaderNormaliser may be streaming, returning an io.Reader which processes
+// the data as it is read rather than reading it in full up front, such as
+// NopReaderNormaliser and LineEndingNormaliser. With WithStreaming, a
+// streaming ReaderNormaliser and a streaming Comparator, such as
+// StreamComparator, compare snapshots without holding either in memory.
+// Unless documented as streaming, the ReaderNormalisers of this package read
+// the data in full, as most, such as DedentNormaliser, need all of it.
 type ReaderNormaliser func(io.Reader) io.Reader
 
 // MatchOptions are the set of options to configure the behaviour of Match.
@@ -122,13 +518,158 @@ type MatchOptions struct {
 	// to ".txt".
 	FileExtension string
 	// Comparator is a function to compare the actual and expected
-	// io.Readers.
+	// io.Readers. This defaults to the comparator registered for
+	// FileExtension with SetComparatorForExtension, the comparator of the
+	// .snapshotrc project config file, or StringComparator.
 	Comparator Comparator
+	// StructuredComparator compares the actual and expected io.Readers in
+	// place of Comparator, returning each difference found, as for
+	// WithStructuredComparator. This defaults to nil, which uses Comparator.
+	StructuredComparator StructuredComparator
 	// ReaderNormaliser is applied to the actual and expected io.Readers before
 	// being passed to the comparator. This can be used to perform some clean
 	// or modifications (i.e. sorting) of the snapshot/actual data before
-	// comparison.
+	// comparison. This defaults to the normaliser registered for
+	// FileExtension with SetNormaliserForExtension, or NopReaderNormaliser.
 	ReaderNormaliser ReaderNormaliser
+	// CaseInsensitive lowercases expected and actual, after the
+	// ReaderNormaliser, so that differences in case are ignored. This
+	// defaults to false.
+	CaseInsensitive bool
+	// JSONSubtree is a JSON path, e.g. "$.data.items", which restricts
+	// the comparison to a subtree of a JSON snapshot. This defaults to
+	// the empty string, which compares the whole snapshot.
+	JSONSubtree string
+	// IgnoreJSONFields are the names of object fields which are removed
+	// from JSON snapshots wherever they appear, from both actual and
+	// expected, before comparison. This defaults to nil.
+	IgnoreJSONFields []string
+	// WriteActualOnFailure writes the actual data to
+	// <name>.actual.<ext> beside the snapshot file when the comparison
+	// fails, and removes it when the comparison succeeds. This defaults
+	// to false.
+	WriteActualOnFailure bool
+	// ExpectedFailure is the reason that the snapshot is expected not to
+	// match. When set, a mismatch is logged and reported as ok, and a
+	// match is reported as a failure. A newly created snapshot is not
+	// affected. This defaults to the empty string, which reports the
+	// comparison as normal.
+	ExpectedFailure string
+	// RequireVerification writes an unverified sentinel file beside newly
+	// created snapshots, and fails the comparison while it exists. This
+	// defaults to false.
+	RequireVerification bool
+	// Clock returns the current time wherever Match records a time, such
+	// as in the unverified sentinel file of WithVerification. This
+	// defaults to time.Now.
+	Clock func() time.Time
+	// AttachmentSink receives the artifacts of a failed comparison, as for
+	// WithAttachmentSink. This defaults to nil, which discards them.
+	AttachmentSink AttachmentSink
+	// Streaming passes the expected and actual data to the
+	// ReaderNormaliser and Comparator as they are read, rather than
+	// first reading them into memory. This is useful for very large
+	// snapshots when paired with a streaming Comparator, such as
+	// StreamComparator. This defaults to false.
+	Streaming bool
+	// InferJSONSchema stores a JSON Schema inferred from the actual JSON
+	// document, rather than the document itself, when the snapshot is
+	// created, as for WithInferredJSONSchema. This defaults to false.
+	InferJSONSchema bool
+	// TimeFormat is the layout used to format times when values are
+	// serialised as JSON, as for FormatTimes. This defaults to the empty
+	// string, which serialises times unmodified.
+	TimeFormat string
+	// ValueTransform is the func(T) T applied to the decoded expected and
+	// actual values by MatchJSONAs, where T is the type of the value. This
+	// defaults to nil, which compares the values unmodified.
+	ValueTransform interface{}
+	// DrainTimeout is the maximum time MatchChannel waits for the channel to
+	// be closed, after which the values received so far are matched. This
+	// defaults to 0, which waits until the channel is closed.
+	DrainTimeout time.Duration
+	// RedactedLogAttrs are the keys of the log attributes whose values are
+	// replaced with IgnoredPlaceholder by MatchSlog. Attributes within
+	// groups are named by their dot separated path, e.g. "request.id".
+	// This defaults to nil, which only masks the time of each record.
+	RedactedLogAttrs []string
+	// Version is appended to the snapshot name, as <name>.<version>.<ext>.
+	// When a versioned snapshot is created, it is also copied to
+	// <name>.latest.<ext>. This defaults to the empty string, which
+	// disables versioning.
+	Version string
+	// PlatformSpecific causes the snapshot file to be named
+	// <name>.<GOOS>.<ext>, falling back to <name>.<ext> if only the base
+	// snapshot exists. New snapshots are always platform specific. This
+	// defaults to false.
+	PlatformSpecific bool
+	// FlatLayout stores the snapshot file alongside the test file, named
+	// <testfile>.<testname>.<name><ext>, rather than within the
+	// __snapshots__ directory. This defaults to false.
+	FlatLayout bool
+	// GoldenLayout stores the snapshot file in the testdata directory
+	// beside the test file, named testdata/<testname>.<name><ext>, or
+	// testdata/<testname><ext> if the snapshot name is empty. This takes
+	// precedence over FlatLayout. This defaults to false.
+	GoldenLayout bool
+	// Base64Encoding stores the snapshot file base64 encoded, with ".b64"
+	// appended to FileExtension. This defaults to false.
+	Base64Encoding bool
+	// EncryptionKey is the AES key the snapshot file is encrypted with,
+	// using AES-GCM, with ".enc" appended to FileExtension. This defaults
+	// to nil, which stores the snapshot in plaintext.
+	EncryptionKey []byte
+	// SnapshotDir is the directory containing the snapshot directories of
+	// each test, in place of the __snapshots__ directory beside the test
+	// file. This takes precedence over FlatLayout. This defaults to the
+	// empty string, which uses the __snapshots__ directory.
+	SnapshotDir string
+	// ModuleRelativeSnapshotDir is the directory containing the snapshot
+	// directories of each test, relative to the root of the Go module. It is
+	// used as SnapshotDir, which must be empty. This defaults to the
+	// snapshot_dir of the .snapshotrc project config file, or the empty
+	// string.
+	ModuleRelativeSnapshotDir string
+	// Shared stores the snapshot in the __shared__ directory beside the
+	// snapshot directories of each test, rather than in the directory of
+	// the current test, so that several tests can match the same snapshot,
+	// as for MatchShared. This defaults to false.
+	Shared bool
+	// ContentStore is the directory in which the content of snapshots is
+	// stored, named by its hash, with the snapshot file holding a pointer
+	// to it. This defaults to the empty string, which stores the content
+	// in the snapshot file.
+	ContentStore string
+	// Store holds the snapshot files, as for WithStore. This defaults to
+	// nil, which stores them on the local filesystem.
+	Store Store
+	// Checksum records the checksum of the snapshot file in a sidecar file
+	// when it is created, and verifies it when it is read, as for
+	// WithChecksum. This defaults to false.
+	Checksum bool
+	// CallerSkip is the number of additional stack frames to skip when
+	// determining the file that contains the test, which locates the
+	// snapshot directory. This is useful when snapshot functions are
+	// called from helper functions in another file. This defaults to 0.
+	CallerSkip int
+	// NoAutoCreate causes the test to fail when the snapshot file does not
+	// exist, rather than creating it, unless the -update-snapshots flag is
+	// new or all. This defaults to DefaultNoAutoCreate, or true if set by
+	// the no_auto_create of the .snapshotrc project config file.
+	NoAutoCreate bool
+	// MaxSnapshotSize is the maximum size of the snapshot data in bytes.
+	// Reading or writing more data than this fails the test, rather than
+	// blocking on an unbounded reader. This defaults to 0, which disables
+	// the limit.
+	MaxSnapshotSize int64
+	// Logger receives the informational messages, such as the resolved
+	// snapshot filename. This defaults to nil, which logs to the test with
+	// t.Logf.
+	Logger Logger
+
+	// namedSnapshot records that SnapshotName was set explicitly, rather
+	// than being the default, so that WithGoldenConvention keeps it.
+	namedSnapshot bool
 }
 
 // MatchOption may be an argument to Match in order to change MatchOptions.
@@ -136,88 +677,358 @@ type MatchOption interface {
 	ApplyMatchOption(*MatchOptions)
 }
 
-// readToString reads r into a string.
-func readToString(r io.Reader) (string, error) {
+// ReadAll reads r into a string.
+func ReadAll(r io.Reader) (string, error) {
 	buf := new(strings.Builder)
 	_, err := io.Copy(buf, r)
 	return buf.String(), err
 }
 
-// StringComparator reads expected and actual into strings and performs an
-// equality check.
-func StringComparator(expected, actual io.Reader) (ok bool, msg string) {
-	eStr, err := readToString(expected)
+// readBoth reads expected and actual in full. The returned error describes
+// which of the two readers failed.
+func readBoth(expected, actual io.Reader) (eBytes, aBytes []byte, err error) {
+	eBytes, err = io.ReadAll(expected)
 	if err != nil {
-		msg = "failed to read expected data from reader: " + err.Error()
+		err = fmt.Errorf("failed to read expected data from reader: %w", err)
 		return
 	}
-	aStr, err := readToString(actual)
+	aBytes, err = io.ReadAll(actual)
 	if err != nil {
-		msg = "failed to read actual data from reader: " + err.Error()
+		err = fmt.Errorf("failed to read actual data from reader: %w", err)
+	}
+	return
+}
+
+// compareAsStrings reads expected and actual into strings and compares them
+// using compare.
+func compareAsStrings(expected, actual io.Reader, compare func(expected, actual string) (bool, string)) (ok bool, msg string) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		msg = err.Error()
 		return
 	}
-	ok = eStr == aStr
+	return compare(string(eBytes), string(aBytes))
+}
+
+// CompareStrings performs an equality check of expected and actual. On failure
+// msg contains both quoted strings.
+func CompareStrings(expected, actual string) (ok bool, msg string) {
+	ok = expected == actual
 	if !ok {
-		msg = fmt.Sprintf("expected %q, got %q", eStr, aStr)
+		msg = fmt.Sprintf("expected %q, got %q", expected, actual)
 	}
 	return
 }
 
+// StringComparator reads expected and actual into strings and performs an
+// equality check using CompareStrings.
+func StringComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return compareAsStrings(expected, actual, CompareStrings)
+}
+
 // NopReaderNormaliser is the default ReaderNormaliser. It passes the input
-// io.Reader through unmodified
+// io.Reader through unmodified, so it is streaming.
 func NopReaderNormaliser(r io.Reader) io.Reader { return r }
 
 // Match loads the output snapshot for a particular test case.  By
 // default, this looks for the file at the location
 // <test-directory>/__snapshots__/<test-name>/output.txt. The base directory
-// cannot be changed, however the file name can be overridden using with the
-// relevant MatchOption arguments.  If the output snapshot file does not
+// can be overridden with WithSnapshotDir, and the file name can be overridden
+// using the relevant MatchOption arguments.  If the output snapshot file does not
 // exist, the input actual is used in its place and the test is likely to
 // succeed. In this case actual is also persisted to the disk for use in
-// subsequent test runs.
+// subsequent test runs. As for GetTestInput, the expected reader passed to the
+// ReaderNormaliser and Comparator is read-only, so that the snapshot cannot be
+// modified by accident. Concurrent calls for the same snapshot file are
+// serialised, so that only one of them creates it, while calls for distinct
+// snapshot files proceed concurrently. If snapshot testing is
+// disabled by the SNAPSHOTS=off environment variable, the test is skipped.
+//
+// The first line of an existing output snapshot may be a directive of the form
+// "# snapshot: <directive> [reason]", which is stripped before comparison. The
+// supported directives are:
+//
+//	skip - Match passes without comparing, logging the optional reason.
 func Match(t *testing.T, actual io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	return match(t, 1, actual, optFns...)
+}
+
+// MatchResult is the result of MatchWithResult.
+type MatchResult struct {
+	// OK and Msg are as returned by Match.
+	OK  bool
+	Msg string
+	// Expected and Actual are the raw expected and actual data which were
+	// compared, before the ReaderNormaliser was applied. They are nil if the
+	// comparison did not take place, e.g. due to a skip directive, or if
+	// WithStreaming was used, as the data is then not buffered.
+	Expected []byte
+	Actual   []byte
+	// Differences are the differences found by the StructuredComparator,
+	// if one was given with WithStructuredComparator. They are nil
+	// otherwise, or if the comparison did not take place.
+	Differences []Difference
+}
+
+// MatchWithResult behaves as Match, but also returns the expected and actual
+// data that were compared, so that further assertions can be made on actual
+// without producing it again.
+func MatchWithResult(t *testing.T, actual io.Reader, optFns ...MatchOption) MatchResult {
+	ok, msg, eBytes, aBytes, diffs := matchBytes(t, 1, actual, optFns...)
+	return MatchResult{OK: ok, Msg: msg, Expected: eBytes, Actual: aBytes, Differences: diffs}
+}
+
+// defaultOutputSnapshotName is the default SnapshotName of Match.
+const defaultOutputSnapshotName = "output"
+
+// newMatchOptions applies optFns to the default MatchOptions.
+func newMatchOptions(optFns []MatchOption) MatchOptions {
+	cfg := configDefaults()
 	opts := MatchOptions{
-		SnapshotName:     "output",
-		FileExtension:    ".txt",
-		Comparator:       StringComparator,
-		ReaderNormaliser: NopReaderNormaliser,
+		SnapshotName:              defaultOutputSnapshotName,
+		FileExtension:             ".txt",
+		NoAutoCreate:              DefaultNoAutoCreate || cfg.noAutoCreate,
+		ModuleRelativeSnapshotDir: cfg.snapshotDir,
+		Clock:                     time.Now,
 	}
 	for _, opt := range optFns {
 		opt.ApplyMatchOption(&opts)
 	}
-	p := filepath.Clean(getSnapshotFilePath(t, opts.SnapshotName, opts.FileExtension))
-	t.Logf("output snapshot filename: %v", p)
+	if opts.Comparator == nil {
+		opts.Comparator = comparatorForExtension(opts.FileExtension)
+	}
+	if opts.ReaderNormaliser == nil {
+		opts.ReaderNormaliser = normaliserForExtension(opts.FileExtension)
+	}
+	return opts
+}
+
+// prepareActual applies the transformations configured in opts that affect
+// the actual data stored in the output snapshot, such as WithJSONSubtree, and
+// limits its size to MaxSnapshotSize.
+func prepareActual(actual io.Reader, opts MatchOptions) (io.Reader, error) {
+	actual = limitSnapshotSize(actual, opts.MaxSnapshotSize)
+	if opts.JSONSubtree != "" {
+		subtree, err := extractJSONSubtree(actual, opts.JSONSubtree)
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract JSON subtree from actual: %w", err)
+		}
+		actual = subtree
+	}
+	if len(opts.IgnoreJSONFields) > 0 {
+		removed, err := removeJSONFields(actual, fieldSet(opts.IgnoreJSONFields))
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove ignored JSON fields from actual: %w", err)
+		}
+		actual = removed
+	}
+	return actual, nil
+}
+
+// writeOutputSnapshot creates the output snapshot file at p, overwriting any
+// existing file, and copies actual to it. The file is removed if it cannot be
+// written. A copy of the data written is
+// returned, unless Streaming is set, in which case nil is returned so that the
+// data is not held in memory. The file is written through a write-only handle,
+// so it must be reopened to be read back.
+func writeOutputSnapshot(t testing.TB, p string, actual io.Reader, opts MatchOptions) (actualCopy *bytes.Buffer) {
+	po := opts.pathOptions()
+	paths := []string{p}
+	if opts.Version != "" {
+		paths = append(paths, po.latestPath(p))
+	}
+	files := make([]io.WriteCloser, 0, len(paths))
+	writers := make([]io.Writer, 0, len(paths))
+	for _, path := range paths {
+		file, err := openNewSnapshotFile(po.store, path)
+		if err != nil {
+			_ = closeSnapshotFiles(files)
+			t.Fatal(err.Error())
+		}
+		files = append(files, file)
+		writers = append(writers, file)
+	}
+	enc := encodeSnapshot(io.MultiWriter(writers...), opts.encoding())
+	var dst io.Writer = enc
+	if !opts.Streaming {
+		actualCopy = new(bytes.Buffer)
+		dst = io.MultiWriter(enc, actualCopy)
+	}
+	_, err := io.Copy(dst, actual)
+	if err == nil {
+		err = enc.Close()
+	}
+	if cerr := closeSnapshotFiles(files); err == nil {
+		err = cerr
+	}
+	if err == nil && opts.ContentStore != "" {
+		err = internSnapshots(p, po, opts.ContentStore)
+	}
+	if err == nil && opts.Checksum {
+		err = writeChecksum(po.store, p)
+	}
+	if err != nil {
+		// The partial snapshot is removed, so that it is not mistaken for an
+		// existing snapshot by later runs.
+		for _, path := range paths {
+			_ = removeFromStore(po.store, path)
+		}
+		t.Fatalf("failed to write to newly created snapshot file: %v: %v", p, err.Error())
+	}
+	return
+}
+
+// match implements Match. skip is the number of stack frames between match
+// and the test, not including the caller of match.
+func match(t testing.TB, skip int, actual io.Reader, optFns ...MatchOption) (ok bool, msg string) {
+	ok, msg, _, _, _ = matchBytes(t, skip+1, actual, optFns...)
+	return
+}
+
+// matchBytes implements match, additionally returning the expected and actual
+// data that were compared, unless Streaming is set, and the differences found
+// by the StructuredComparator, if set. skip is as for match.
+func matchBytes(t testing.TB, skip int, actual io.Reader, optFns ...MatchOption) (ok bool, msg string, eBytes, aBytes []byte, diffs []Difference) {
+	skipIfSnapshotsDisabled(t)
+	opts := newMatchOptions(optFns)
+	p := resolveSnapshotFilePath(t, skip, opts.pathOptions(), false)
+	logf := resolveLogger(t, opts.Logger)
+	logf("output snapshot filename: %v", p)
+	created := false
+	defer func() { recordResult(t.Name(), p, created, ok, msg) }()
+	if opts.AttachmentSink != nil {
+		defer func() {
+			if !ok {
+				attachFailure(opts.AttachmentSink, opts.pathOptions(), normaliseExtension(opts.FileExtension), eBytes, aBytes, msg)
+			}
+		}()
+	}
+	if err := checkEncryptionKey(opts.EncryptionKey); err != nil {
+		msg = err.Error()
+		return
+	}
+	actual, err := prepareActual(actual, opts)
+	if err != nil {
+		msg = err.Error()
+		return
+	}
+	if opts.WriteActualOnFailure {
+		actualBytes, err := io.ReadAll(actual)
+		if err != nil {
+			msg = "failed to read actual data from reader: " + err.Error()
+			return
+		}
+		actual = bytes.NewReader(actualBytes)
+		defer func() {
+			writeActualOnFailure(t, logf, opts.pathOptions().store, actualSnapshotPath(p, opts.pathOptions().ext), actualBytes, ok, opts.encoding())
+		}()
+	}
 	var expected io.Reader
-	if file, err := os.Open(p); err == nil {
-		t.Logf("using existing snapshot")
-		expected = file
+	defer lockSnapshotPath(p)()
+	store := resolveStore(opts.Store)
+	if file, err := openSnapshotFileForUpdate(t, store, p, opts.ContentStore, true); err == nil {
+		logf("using existing snapshot")
 		t.Cleanup(func() { _ = file.Close() })
-	} else if os.IsNotExist(err) {
-		t.Log("creating new output snapshot")
-		err = os.MkdirAll(filepath.Dir(p), 0750)
+		if opts.Checksum {
+			if err = verifyChecksum(store, p); err != nil {
+				msg = err.Error()
+				return
+			}
+		}
+		limited, err := limitExistingSnapshot(file, opts.MaxSnapshotSize)
 		if err != nil {
-			t.Fatalf("failed to create output snapshot file %v: %v", p, err.Error())
+			msg = fmt.Sprintf("failed to read output snapshot file %v: %v", p, err.Error())
+			return
+		}
+		directive, reason, rest, err := readDirective(limited)
+		if errors.Is(err, errMalformedDirective) {
+			msg = fmt.Sprintf("%v in %v", err, p)
+			return
+		} else if err != nil {
+			t.Fatalf("failed to read output snapshot file %v: %v", p, err.Error())
+		}
+		switch directive {
+		case "":
+		case DirectiveSkip:
+			logf("skipping snapshot comparison due to directive: %v", reason)
+			ok = true
+			return
+		default:
+			msg = fmt.Sprintf("unknown snapshot directive %q in %v", directive, p)
+			return
+		}
+		expected = readOnlyReader{decodeSnapshot(rest, opts.encoding())}
+		if len(opts.IgnoreJSONFields) > 0 {
+			expected, err = removeJSONFields(expected, fieldSet(opts.IgnoreJSONFields))
+			if err != nil {
+				msg = "failed to remove ignored JSON fields from expected: " + err.Error()
+				return
+			}
+		}
+	} else if errors.Is(err, fs.ErrNotExist) {
+		checkSnapshotChange(t, p, "created")
+		if autoCreateDisabled(t, opts.NoAutoCreate) {
+			t.Fatalf(noAutoCreateMessage, p)
 		}
-		file, err := os.Create(p)
+		logf("creating new output snapshot")
+		snapshotData := actual
+		if opts.InferJSONSchema {
+			if snapshotData, actual, err = inferJSONSchemaSnapshot(actual); err != nil {
+				msg = "failed to infer JSON schema: " + err.Error()
+				return
+			}
+		}
+		actualCopy := writeOutputSnapshot(t, p, snapshotData, opts)
+		file, err := openSnapshotFile(store, p, opts.ContentStore)
 		if err != nil {
-			t.Fatalf("failed to open newly created snapshot file: %v: %v", p, err.Error())
+			t.Fatalf("failed to reopen newly created snapshot file: %v: %v", p, err.Error())
 		}
 		t.Cleanup(func() { _ = file.Close() })
-		actualCopy := new(bytes.Buffer)
-		_, err = io.Copy(io.MultiWriter(file, actualCopy), actual)
-		if err != nil {
-			t.Fatalf("failed to write to newly created snapshot file: %v: %v", p, err.Error())
+		expected = readOnlyReader{decodeSnapshot(file, opts.encoding())}
+		switch {
+		case opts.InferJSONSchema:
+		case actualCopy == nil:
+			// The newly created snapshot is read again as the actual data,
+			// rather than holding a copy of it in memory.
+			actualFile, err := openSnapshotFile(store, p, opts.ContentStore)
+			if err != nil {
+				t.Fatalf("failed to reopen newly created snapshot file: %v: %v", p, err.Error())
+			}
+			t.Cleanup(func() { _ = actualFile.Close() })
+			actual = readOnlyReader{decodeSnapshot(actualFile, opts.encoding())}
+		default:
+			actual = actualCopy
+		}
+		created = true
+		if opts.RequireVerification {
+			if err = writeUnverifiedSentinel(store, p, t.Name(), opts.Clock()); err != nil {
+				t.Fatalf("failed to write unverified snapshot sentinel: %v", err.Error())
+			}
+			logf("recorded new snapshot, which is unverified until %v is removed", unverifiedSentinelPath(p))
 		}
-		_, err = file.Seek(0, 0)
+	} else {
+		msg = fmt.Sprintf("failed to open output snapshot file %v: %v", p, err.Error())
+		return
+	}
+	if !opts.Streaming {
+		eBytes, aBytes, err = readBoth(expected, actual)
 		if err != nil {
-			t.Fatalf("failed to seek to beginning for snapshot file: %v", err.Error())
+			msg = err.Error()
+			return
+		}
+		expected, actual = bytes.NewReader(eBytes), bytes.NewReader(aBytes)
+	}
+	ok, msg, diffs = compareDifferences(expected, actual, opts)
+	if ok {
+		for _, d := range diffs {
+			logf("snapshot difference: %v", d)
 		}
-		expected = file
-		actual = actualCopy
 	}
-	ok, msg = opts.Comparator(
-		opts.ReaderNormaliser(expected),
-		opts.ReaderNormaliser(actual),
-	)
+	if opts.ExpectedFailure != "" && !created {
+		ok, msg = invertExpectedFailure(logf, opts.ExpectedFailure, ok, msg)
+	}
+	if opts.RequireVerification && !created {
+		ok, msg = checkVerified(store, p, ok, msg)
+	}
 	return
 }
@@ -1,26 +1,35 @@
 package snapshot
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
-func getInputOutputPathsAndClean(t *testing.T) (input, output string) {
-	input = getSnapshotFilePath(t, "input", ".txt")
-	output = getSnapshotFilePath(t, "output", ".txt")
-	if err := os.RemoveAll(filepath.Dir(input)); err != nil && !os.IsNotExist(err) {
-		t.Fatalf("failed to remove test snapshots: %v", err)
-	}
+// getInputOutputPaths returns a SnapshotOption which stores the snapshots of
+// the test in a temporary directory, along with the paths of the default input
+// and output snapshots within it.
+func getInputOutputPaths(t *testing.T) (dirOpt SnapshotOption, input, output string) {
+	dir := t.TempDir()
+	dirOpt = WithSnapshotDir(dir)
+	input = filepath.Join(dir, t.Name(), "input.txt")
+	output = filepath.Join(dir, t.Name(), "output.txt")
 	return
 }
 
 func TestRoundTrip(t *testing.T) {
-	inputP, outputP := getInputOutputPathsAndClean(t)
-	input := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("hello")))
-	ok, msg := Match(t, input)
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	input := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("hello")), dirOpt)
+	ok, msg := Match(t, input, dirOpt)
 
 	if !ok {
 		t.Fatalf("expectd match but didn't: %v", msg)
@@ -29,53 +38,592 @@ func TestRoundTrip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to open input snapshot: %v", err)
 	}
-	if str := readToStringUnchecked(inputF); str != "hello" {
+	if str := readAllUnchecked(inputF); str != "hello" {
 		t.Fatalf("unexpected input snapshot. expected %q, got %q", "hello", str)
 	}
 	outputF, err := os.Open(outputP)
 	if err != nil {
 		t.Fatalf("failed to open output snapshot: %v", err)
 	}
-	if str := readToStringUnchecked(outputF); str != "hello" {
+	if str := readAllUnchecked(outputF); str != "hello" {
 		t.Fatalf("unexpected output snapshot. expected %q, got %q", "hello", str)
 	}
 }
 
 func TestOutputChanged(t *testing.T) {
-	_, _ = getInputOutputPathsAndClean(t)
+	dirOpt, _, _ := getInputOutputPaths(t)
 	firstOutput := strings.NewReader("hello")
-	if ok, msg := Match(t, firstOutput); !ok {
+	if ok, msg := Match(t, firstOutput, dirOpt); !ok {
 		t.Errorf("expected first match to succeed: %v", msg)
 	}
 	firstOutput = strings.NewReader("hello")
-	if ok, msg := Match(t, firstOutput); !ok {
+	if ok, msg := Match(t, firstOutput, dirOpt); !ok {
 		t.Errorf("expected first match to succeed a second time: %v", msg)
 	}
 	secondOutput := strings.NewReader("world")
 	expectedMsg := `expected "hello", got "world"`
-	if ok, msg := Match(t, secondOutput); ok || msg != expectedMsg {
+	if ok, msg := Match(t, secondOutput, dirOpt); ok || msg != expectedMsg {
 		t.Errorf("expected second match to be false, got: %v", ok)
 		t.Errorf("expected message to be %q, got %q", expectedMsg, msg)
 	}
 }
 
-func readToStringUnchecked(r io.Reader) (s string) {
-	s, _ = readToString(r)
+func readAllUnchecked(r io.Reader) (s string) {
+	s, _ = ReadAll(r)
 	return
 }
 
 func TestInputChanged(t *testing.T) {
-	_, _ = getInputOutputPathsAndClean(t)
-	firstInput := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("hello")))
-	if str := readToStringUnchecked(firstInput); str != "hello" {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	firstInput := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("hello")), dirOpt)
+	if str := readAllUnchecked(firstInput); str != "hello" {
+		t.Errorf("expected %q, got %q", "hello", str)
+	}
+	secondInput := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("world")), dirOpt)
+	if str := readAllUnchecked(secondInput); str != "hello" {
+		t.Errorf("expected %q, got %q", "hello", str)
+	}
+	thirdInput := GetTestInput(t, WithSnapshotName("different"), WithCreateSnapshotFromReader(strings.NewReader("world")), dirOpt)
+	if str := readAllUnchecked(thirdInput); str != "world" {
+		t.Errorf("expected %q, got %q", "world", str)
+	}
+}
+
+func writeSnapshotFile(t *testing.T, p, content string) {
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		t.Fatalf("failed to create snapshot directory: %v", err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write output snapshot: %v", err)
+	}
+}
+
+func TestSkipDirective(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	writeSnapshotFile(t, outputP, "# snapshot: skip TODO: flaky\nhello")
+	if ok, msg := Match(t, strings.NewReader("world"), dirOpt); !ok {
+		t.Errorf("expected skip directive to pass: %v", msg)
+	}
+}
+
+func TestUnknownDirective(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	writeSnapshotFile(t, outputP, "# snapshot: bogus\nhello")
+	if ok, _ := Match(t, strings.NewReader("hello"), dirOpt); ok {
+		t.Errorf("expected unknown directive to fail")
+	}
+}
+
+func TestGetTestInputSeeker(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	for _, name := range []string{"created", "existing"} {
+		input := GetTestInputSeeker(t, WithCreateSnapshotFromReader(strings.NewReader("hello")), dirOpt)
+		if str := readAllUnchecked(input); str != "hello" {
+			t.Errorf("%v: expected %q, got %q", name, "hello", str)
+		}
+		if _, err := input.Seek(1, io.SeekStart); err != nil {
+			t.Fatalf("%v: failed to seek: %v", name, err)
+		}
+		if str := readAllUnchecked(input); str != "ello" {
+			t.Errorf("%v: expected %q after seeking, got %q", name, "ello", str)
+		}
+	}
+}
+
+func TestVersionedSnapshot(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	dir := filepath.Dir(outputP)
+	if ok, msg := Match(t, strings.NewReader("hello"), WithVersionedSnapshot("v1"), dirOpt); !ok {
+		t.Fatalf("expected first version to match: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("world"), WithVersionedSnapshot("v2"), dirOpt); !ok {
+		t.Fatalf("expected second version to match: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithVersionedSnapshot("v1"), dirOpt); !ok {
+		t.Errorf("expected first version to still match: %v", msg)
+	}
+	for name, expected := range map[string]string{
+		"output.v1.txt":     "hello",
+		"output.v2.txt":     "world",
+		"output.latest.txt": "world",
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read %v: %v", name, err)
+		}
+		if string(b) != expected {
+			t.Errorf("unexpected %v contents. expected %q, got %q", name, expected, string(b))
+		}
+	}
+}
+
+func TestVersionedFlatLayout(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	store := newMemStore()
+	for _, name := range []string{"A", "B"} {
+		t.Run(name, func(t *testing.T) {
+			opts := []MatchOption{WithFlatLayout(), WithVersionedSnapshot("v1"), WithStore(store)}
+			if ok, msg := Match(t, strings.NewReader(name), opts...); !ok {
+				t.Fatalf("expected match to succeed: %v", msg)
+			}
+		})
+	}
+	for _, name := range []string{"A", "B"} {
+		prefix := filepath.Join(filepath.Dir(file), "snapshot_test.TestVersionedFlatLayout_"+name+".output.")
+		for _, p := range []string{prefix + "v1.txt", prefix + "latest.txt"} {
+			if str := string(store.files[p]); str != name {
+				t.Errorf("unexpected snapshot %v. expected %q, got %q", p, name, str)
+			}
+		}
+	}
+	if paths := store.paths(); len(paths) != 4 {
+		t.Errorf("expected a versioned and latest snapshot for each test, got %v", paths)
+	}
+}
+
+func openUnchecked(t *testing.T, p string) *os.File {
+	file, err := os.Open(p)
+	if err != nil {
+		t.Fatalf("failed to open %v: %v", p, err)
+	}
+	t.Cleanup(func() { _ = file.Close() })
+	return file
+}
+
+func TestGenerate(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	Generate(t, strings.NewReader("world"), dirOpt)
+	if str := readAllUnchecked(openUnchecked(t, outputP)); str != "world" {
+		t.Fatalf("expected generate to overwrite the snapshot. expected %q, got %q", "world", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("world"), WithNoAutoCreate(), dirOpt); !ok {
+		t.Errorf("expected generated snapshot to match: %v", msg)
+	}
+}
+
+// requireExistingOutputDirEnv is set to the snapshot directory when
+// TestRequireExistingOutput runs itself in a subprocess, to exercise the
+// missing snapshot failure.
+const requireExistingOutputDirEnv = "SNAPSHOT_TEST_REQUIRE_EXISTING_OUTPUT_DIR"
+
+func TestRequireExistingOutput(t *testing.T) {
+	if dir := os.Getenv(requireExistingOutputDirEnv); dir != "" {
+		Match(t, strings.NewReader("hello"), WithRequireExistingOutput(), WithSnapshotDir(dir))
+		return
+	}
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRequireExistingOutput$")
+	cmd.Env = append(os.Environ(), requireExistingOutputDirEnv+"="+dir)
+	out, err := cmd.CombinedOutput()
+	p := filepath.Join(dir, t.Name(), "output.txt")
+	if expected := fmt.Sprintf(noAutoCreateMessage, p); err == nil || !strings.Contains(string(out), expected) {
+		t.Errorf("expected a missing snapshot to fail with %q, got %v: %s", expected, err, out)
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshot to be created: %v", err)
+	}
+
+	if opts := newMatchOptions([]MatchOption{WithRequireExistingOutput()}); !opts.NoAutoCreate {
+		t.Errorf("expected WithRequireExistingOutput to disable automatic creation")
+	}
+	dirOpt, _, _ := getInputOutputPaths(t)
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	if ok, msg := Match(t, strings.NewReader("hello"), WithRequireExistingOutput(), dirOpt); !ok {
+		t.Errorf("expected existing snapshot to match: %v", msg)
+	}
+}
+
+// invalidCallerSkipEnv is set when TestWithCallerSkip runs itself in a
+// subprocess, to exercise the failure for a caller skip beyond the stack.
+const invalidCallerSkipEnv = "SNAPSHOT_TEST_INVALID_CALLER_SKIP"
+
+func TestWithCallerSkip(t *testing.T) {
+	if os.Getenv(invalidCallerSkipEnv) != "" {
+		matchFlatInStore(t, newMemStore(), WithCallerSkip(1000))
+		return
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		optFns   []MatchOption
+		expected string
+	}{
+		{"helper", nil, filepath.Join(wd, "store_test.TestWithCallerSkip_helper.output.txt")},
+		{"skipped", []MatchOption{WithCallerSkip(1)}, filepath.Join(wd, "snapshot_test.TestWithCallerSkip_skipped.output.txt")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemStore()
+			if ok, msg := matchFlatInStore(t, store, tt.optFns...); !ok {
+				t.Fatalf("expected match to succeed: %v", msg)
+			}
+			if paths := store.paths(); len(paths) != 1 || paths[0] != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, paths)
+			}
+		})
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWithCallerSkip$")
+	cmd.Env = append(os.Environ(), invalidCallerSkipEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	if expected := "failed to determine the file containing the test from the call stack"; err == nil || !strings.Contains(string(out), expected) {
+		t.Errorf("expected an invalid caller skip to fail with %q, got %v: %s", expected, err, out)
+	}
+}
+
+func TestInputFallbackNames(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	dir := filepath.Dir(inputP)
+	writeSnapshotFile(t, filepath.Join(dir, "default.txt"), "default")
+	writeSnapshotFile(t, filepath.Join(dir, "other.txt"), "other")
+	creator := WithCreateSnapshot(func() (io.Reader, error) {
+		t.Errorf("expected the creator not to be called")
+		return strings.NewReader("created"), nil
+	})
+	fallbacks := WithInputFallbackNames("missing", "default", "other")
+	if str := readAllUnchecked(GetTestInput(t, fallbacks, creator, dirOpt)); str != "default" {
+		t.Errorf("expected first existing fallback %q, got %q", "default", str)
+	}
+	if str := readAllUnchecked(GetTestInputSeeker(t, fallbacks, creator, dirOpt)); str != "default" {
+		t.Errorf("expected first existing fallback %q from seeker, got %q", "default", str)
+	}
+	writeSnapshotFile(t, inputP, "input")
+	if str := readAllUnchecked(GetTestInput(t, fallbacks, creator, dirOpt)); str != "input" {
+		t.Errorf("expected existing snapshot %q to take precedence, got %q", "input", str)
+	}
+
+	input := GetTestInput(t, WithInputFallbackNames("missing"), WithSnapshotName("new"),
+		WithCreateSnapshotFromReader(strings.NewReader("created")), dirOpt)
+	if str := readAllUnchecked(input); str != "created" {
+		t.Errorf("expected creator to be called without an existing fallback, got %q", str)
+	}
+	if str := readAllUnchecked(openUnchecked(t, filepath.Join(dir, "new.txt"))); str != "created" {
+		t.Errorf("unexpected input snapshot. expected %q, got %q", "created", str)
+	}
+}
+
+func TestCreateRetry(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	attempts := 0
+	creator := func() (io.Reader, error) {
+		attempts++
+		if attempts < 3 {
+			return nil, fmt.Errorf("transient failure %d", attempts)
+		}
+		return strings.NewReader("hello"), nil
+	}
+	input := GetTestInput(t, WithCreateSnapshot(creator), WithCreateRetry(3, time.Millisecond), dirOpt)
+	if str := readAllUnchecked(input); str != "hello" {
+		t.Errorf("expected %q, got %q", "hello", str)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if str := readAllUnchecked(openUnchecked(t, inputP)); str != "hello" {
+		t.Errorf("unexpected input snapshot. expected %q, got %q", "hello", str)
+	}
+}
+
+func TestCreateTimeout(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	release := make(chan struct{})
+	defer close(release)
+	hanging := WithCreateSnapshot(func() (io.Reader, error) {
+		<-release
+		return strings.NewReader("late"), nil
+	})
+	_, err := io.ReadAll(GetTestInput(t, hanging, WithCreateTimeout(10*time.Millisecond), dirOpt))
+	if err == nil || !strings.Contains(err.Error(), "snapshot creator timed out after 10ms") {
+		t.Errorf("expected hanging creator to time out, got %v", err)
+	}
+	if _, err := os.Stat(inputP); !os.IsNotExist(err) {
+		t.Errorf("expected no snapshot file after creator timeout, got %v", err)
+	}
+
+	pipe := WithCreateSnapshot(func() (io.Reader, error) {
+		r, w := io.Pipe()
+		go func() { _, _ = w.Write([]byte("partial")) }()
+		return r, nil
+	})
+	_, err = io.ReadAll(GetTestInput(t, pipe, WithCreateTimeout(10*time.Millisecond), dirOpt))
+	if err == nil || !strings.Contains(err.Error(), "snapshot creator timed out after 10ms") {
+		t.Errorf("expected unclosed pipe to time out, got %v", err)
+	}
+	if _, err := os.Stat(inputP); !os.IsNotExist(err) {
+		t.Errorf("expected partial snapshot file to be removed, got %v", err)
+	}
+
+	var calls atomic.Int32
+	failing := WithCreateSnapshot(func() (io.Reader, error) {
+		calls.Add(1)
+		return nil, errors.New("unavailable")
+	})
+	_, err = io.ReadAll(GetTestInput(t, failing, WithCreateRetry(1000, time.Millisecond), WithCreateTimeout(10*time.Millisecond), dirOpt))
+	if err == nil || err.Error() != "snapshot creator failed with an error: snapshot creator timed out after 10ms" {
+		t.Errorf("expected retrying creator to time out, got %v", err)
+	}
+	afterTimeout := calls.Load()
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n > afterTimeout+1 {
+		t.Errorf("expected retries to stop after the timeout, got %d calls after %d", n, afterTimeout)
+	}
+
+	input := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("hello")), WithCreateTimeout(time.Minute), dirOpt)
+	if str := readAllUnchecked(input); str != "hello" {
 		t.Errorf("expected %q, got %q", "hello", str)
 	}
-	secondInput := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("world")))
-	if str := readToStringUnchecked(secondInput); str != "hello" {
+	if str := readAllUnchecked(openUnchecked(t, inputP)); str != "hello" {
+		t.Errorf("unexpected input snapshot. expected %q, got %q", "hello", str)
+	}
+}
+
+func TestMatchWithResult(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	res := MatchWithResult(t, strings.NewReader("hello"), dirOpt)
+	if !res.OK || string(res.Expected) != "hello" || string(res.Actual) != "hello" {
+		t.Fatalf("expected created snapshot to match with data, got %+v", res)
+	}
+	res = MatchWithResult(t, strings.NewReader("world"), dirOpt)
+	if res.OK || res.Msg == "" || string(res.Expected) != "hello" || string(res.Actual) != "world" {
+		t.Errorf("expected mismatch with data, got %+v", res)
+	}
+	res = MatchWithResult(t, strings.NewReader("hello"), WithStreaming(), dirOpt)
+	if !res.OK || res.Expected != nil || res.Actual != nil {
+		t.Errorf("expected no data when streaming, got %+v", res)
+	}
+}
+
+func TestLazyCreateSnapshot(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	calls := 0
+	creator := func() (io.Reader, error) {
+		calls++
+		return strings.NewReader("hello"), nil
+	}
+	input := GetTestInput(t, WithCreateSnapshot(creator), dirOpt)
+	if calls != 0 {
+		t.Fatalf("expected creator not to be called before the input is read, got %d calls", calls)
+	}
+	if _, err := os.Stat(inputP); !os.IsNotExist(err) {
+		t.Fatalf("expected no input snapshot before the input is read, got %v", err)
+	}
+	if str := readAllUnchecked(input); str != "hello" || calls != 1 {
+		t.Errorf("expected %q from 1 call, got %q from %d calls", "hello", str, calls)
+	}
+	if str := readAllUnchecked(openUnchecked(t, inputP)); str != "hello" {
+		t.Errorf("unexpected input snapshot. expected %q, got %q", "hello", str)
+	}
+
+	failing := GetTestInput(t, WithCreateSnapshot(func() (io.Reader, error) {
+		return nil, fmt.Errorf("unavailable")
+	}), WithSnapshotName("failing"), dirOpt)
+	expectedErr := "snapshot creator failed with an error: unavailable"
+	for i := 0; i < 2; i++ {
+		if _, err := failing.Read(make([]byte, 1)); err == nil || err.Error() != expectedErr {
+			t.Errorf("expected read %d to fail with %q, got %v", i, expectedErr, err)
+		}
+	}
+}
+
+func TestPlatformSpecificSnapshots(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	platformP := strings.TrimSuffix(outputP, ".txt") + "." + runtime.GOOS + ".txt"
+	writeSnapshotFile(t, outputP, "base")
+	if ok, msg := Match(t, strings.NewReader("base"), WithPlatformSpecificSnapshots(), dirOpt); !ok {
+		t.Errorf("expected fallback to the base snapshot: %v", msg)
+	}
+	Generate(t, strings.NewReader("platform"), WithPlatformSpecificSnapshots(), dirOpt)
+	if str := readAllUnchecked(openUnchecked(t, platformP)); str != "platform" {
+		t.Fatalf("expected platform specific snapshot to be generated, got %q", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("platform"), WithPlatformSpecificSnapshots(), dirOpt); !ok {
+		t.Errorf("expected platform specific snapshot to be preferred: %v", msg)
+	}
+}
+
+func TestFlatLayout(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	inputP := filepath.Join(filepath.Dir(file), "snapshot_test.TestFlatLayout.input.txt")
+	outputP := filepath.Join(filepath.Dir(file), "snapshot_test.TestFlatLayout.output.txt")
+	removeFlat := func() {
+		for _, p := range []string{inputP, outputP} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				t.Fatalf("failed to remove flat snapshot: %v", err)
+			}
+		}
+	}
+	removeFlat()
+	t.Cleanup(removeFlat)
+
+	input := GetTestInput(t, WithFlatLayout(), WithCreateSnapshotFromReader(strings.NewReader("hello")))
+	if ok, msg := Match(t, input, WithFlatLayout()); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	for _, p := range []string{inputP, outputP} {
+		if str := readAllUnchecked(openUnchecked(t, p)); str != "hello" {
+			t.Errorf("unexpected snapshot %v. expected %q, got %q", p, "hello", str)
+		}
+	}
+}
+
+func TestGoldenConvention(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "testdata")
+	inputP := filepath.Join(dir, "TestGoldenConvention.input.golden")
+	outputP := filepath.Join(dir, "TestGoldenConvention.golden")
+	store := newMemStore()
+
+	input := GetTestInput(t, WithGoldenConvention(), WithDefaultInput("hello"), WithStore(store))
+	if ok, msg := Match(t, input, WithGoldenConvention(), WithStore(store)); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	if paths := store.paths(); !reflect.DeepEqual(paths, []string{outputP, inputP}) {
+		t.Errorf("expected golden snapshots %v, got %v", []string{outputP, inputP}, paths)
+	}
+	for _, p := range []string{inputP, outputP} {
+		if str := string(store.files[p]); str != "hello" {
+			t.Errorf("unexpected snapshot %v. expected %q, got %q", p, "hello", str)
+		}
+	}
+	for _, name := range []string{"custom", defaultOutputSnapshotName} {
+		opts := newMatchOptions([]MatchOption{WithSnapshotName(name), WithGoldenConvention()})
+		if opts.SnapshotName != name || opts.FileExtension != ".golden" {
+			t.Errorf("expected snapshot name %q to be kept, got %q, %q", name, opts.SnapshotName, opts.FileExtension)
+		}
+	}
+}
+
+func TestVersionedGoldenConvention(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	dir := filepath.Join(filepath.Dir(file), "testdata")
+	store := newMemStore()
+	if ok, msg := Match(t, strings.NewReader("hello"), WithGoldenConvention(), WithVersionedSnapshot("v1"), WithStore(store)); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	expected := []string{
+		filepath.Join(dir, "TestVersionedGoldenConvention.latest.golden"),
+		filepath.Join(dir, "TestVersionedGoldenConvention.v1.golden"),
+	}
+	if paths := store.paths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected golden snapshots %v, got %v", expected, paths)
+	}
+}
+
+func TestDefaultInput(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	if str := readAllUnchecked(GetTestInput(t, WithDefaultInput("hello"), dirOpt)); str != "hello" {
 		t.Errorf("expected %q, got %q", "hello", str)
 	}
-	thirdInput := GetTestInput(t, WithSnapshotName("different"), WithCreateSnapshotFromReader(strings.NewReader("world")))
-	if str := readToStringUnchecked(thirdInput); str != "world" {
+	if str := readAllUnchecked(GetTestInput(t, WithDefaultInput("world"), dirOpt)); str != "hello" {
+		t.Errorf("expected existing snapshot %q, got %q", "hello", str)
+	}
+	bytesInput := GetTestInput(t, WithSnapshotName("bytes"), WithDefaultInputBytes([]byte("world")), dirOpt)
+	if str := readAllUnchecked(bytesInput); str != "world" {
 		t.Errorf("expected %q, got %q", "world", str)
 	}
 }
+
+func TestWriteActualOnFailure(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	actualP := strings.TrimSuffix(outputP, ".txt") + ".actual.txt"
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	if ok, _ := Match(t, strings.NewReader("world"), WithWriteActualOnFailure(), dirOpt); ok {
+		t.Fatalf("expected match to fail")
+	}
+	if str := readAllUnchecked(openUnchecked(t, actualP)); str != "world" {
+		t.Errorf("unexpected actual file contents. expected %q, got %q", "world", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithWriteActualOnFailure(), dirOpt); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	if _, err := os.Stat(actualP); !os.IsNotExist(err) {
+		t.Errorf("expected actual file to be removed on success, got %v", err)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	var logged []string
+	logger := func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithLogger(logger), dirOpt); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	expected := []string{"output snapshot filename: " + outputP, "creating new output snapshot"}
+	if strings.Join(logged, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %q, got %q", expected, logged)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithQuiet(), dirOpt); !ok {
+		t.Errorf("expected quiet match to succeed: %v", msg)
+	}
+}
+
+func TestExpectedFailure(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	if ok, msg := Match(t, strings.NewReader("hello"), WithExpectedFailure("drifted"), dirOpt); !ok {
+		t.Fatalf("expected newly created snapshot to match: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("world"), WithExpectedFailure("drifted"), dirOpt); !ok || msg != "" {
+		t.Errorf("expected mismatch to be reported as ok, got %v, %q", ok, msg)
+	}
+	expectedMsg := "snapshot matched but was expected to fail: drifted"
+	if ok, msg := Match(t, strings.NewReader("hello"), WithExpectedFailure("drifted"), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
+
+func TestSnapshotReadersAreReadOnly(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	isWritable := func(r io.Reader) bool {
+		_, isWriter := r.(io.Writer)
+		_, isFile := r.(*os.File)
+		return isWriter || isFile
+	}
+	_ = readAllUnchecked(GetTestInput(t, WithDefaultInput("hello"), dirOpt))
+	if isWritable(GetTestInput(t, dirOpt)) {
+		t.Errorf("expected existing input snapshot to be read-only")
+	}
+	checkExpected := WithComparator(func(expected, actual io.Reader) (bool, string) {
+		if isWritable(expected) {
+			return false, "expected reader is writable"
+		}
+		return StringComparator(expected, actual)
+	})
+	for _, name := range []string{"created", "existing"} {
+		if ok, msg := Match(t, strings.NewReader("hello"), checkExpected, WithStreaming(), dirOpt); !ok {
+			t.Errorf("%v: %v", name, msg)
+		}
+	}
+}
+
+func TestMatchCreateReadsSnapshot(t *testing.T) {
+	for _, streaming := range []bool{false, true} {
+		t.Run(fmt.Sprintf("streaming=%v", streaming), func(t *testing.T) {
+			dirOpt, _, _ := getInputOutputPaths(t)
+			var read string
+			recordExpected := WithComparator(func(expected, actual io.Reader) (bool, string) {
+				e, a, err := readBoth(expected, actual)
+				if err != nil {
+					return false, err.Error()
+				}
+				read = string(e)
+				return CompareStrings(read, string(a))
+			})
+			optFns := []MatchOption{recordExpected, dirOpt}
+			if streaming {
+				optFns = append(optFns, WithStreaming())
+			}
+			if ok, msg := Match(t, strings.NewReader("hello"), optFns...); !ok {
+				t.Fatalf("expected newly created snapshot to match: %v", msg)
+			}
+			if read != "hello" {
+				t.Errorf("expected comparator to read %q from the created snapshot, got %q", "hello", read)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// sqlKeywords are the words which SQLNormaliser writes in upper case. Function
+// names, such as count, and identifiers are left as written.
+var sqlKeywords = toSet(strings.Fields(`
+	ADD ALL ALTER AND ANY AS ASC BETWEEN BY CASCADE CASE CHECK COLUMN CONFLICT
+	CONSTRAINT CREATE CROSS CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP DEFAULT
+	DELETE DESC DISTINCT DO DROP ELSE END ESCAPE EXCEPT EXISTS FALSE FETCH FIRST
+	FOR FOREIGN FROM FULL GROUP HAVING IF IGNORE ILIKE IN INDEX INNER INSERT
+	INTERSECT INTO IS JOIN KEY LAST LATERAL LEFT LIKE LIMIT NATURAL NEXT NOT
+	NOTHING NULL NULLS OFFSET ON ONLY OR ORDER OUTER OVER PARTITION PRIMARY
+	RECURSIVE REFERENCES REPLACE RETURNING RIGHT ROW ROWS SELECT SET TABLE THEN
+	TO TRUE UNION UNIQUE UPDATE USING VALUES VIEW WHEN WHERE WINDOW WITH
+`))
+
+// toSet returns the set of the elements of s.
+func toSet(s []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(s))
+	for _, e := range s {
+		set[e] = struct{}{}
+	}
+	return set
+}
+
+// sqlTokenKind is the kind of a sqlToken.
+type sqlTokenKind int
+
+const (
+	sqlWord sqlTokenKind = iota
+	sqlQuoted
+	sqlNumber
+	sqlPunct
+	sqlLineComment
+	sqlBlockComment
+)
+
+// A sqlToken is a lexical token of a SQL statement.
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// isSQLWordByte reports whether c may appear in an unquoted identifier or
+// keyword after its first character.
+func isSQLWordByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// isSQLOperatorByte reports whether c may appear in an operator.
+func isSQLOperatorByte(c byte) bool {
+	return strings.IndexByte("<>=!+-*/%|&^~:#?@", c) >= 0
+}
+
+// tokenizeSQL splits s into tokens, discarding whitespace. It fails if a
+// string, quoted identifier or block comment is not terminated, or if the
+// parentheses are unbalanced.
+func tokenizeSQL(s string) (tokens []sqlToken, err error) {
+	depth := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+		case strings.HasPrefix(s[i:], "--"):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				end = len(s) - i
+			}
+			i += end
+			tokens = append(tokens, sqlToken{sqlLineComment, strings.TrimRight(s[start:i], " \t\r")})
+			continue
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at offset %d", start)
+			}
+			i += end + 4
+			tokens = append(tokens, sqlToken{sqlBlockComment, s[start:i]})
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// A quote is escaped by doubling it, as in standard SQL.
+			for i++; ; i++ {
+				if i >= len(s) {
+					return nil, fmt.Errorf("unterminated %c quote at offset %d", c, start)
+				}
+				if s[i] == c {
+					if i+1 < len(s) && s[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			i++
+			tokens = append(tokens, sqlToken{sqlQuoted, s[start:i]})
+			continue
+		case '0' <= c && c <= '9' || c == '.' && i+1 < len(s) && '0' <= s[i+1] && s[i+1] <= '9':
+			for i++; i < len(s) && (isSQLWordByte(s[i]) || s[i] == '.'); i++ {
+			}
+			tokens = append(tokens, sqlToken{sqlNumber, s[start:i]})
+			continue
+		case isSQLWordByte(c), (c == ':' || c == '@') && i+1 < len(s) && isSQLWordByte(s[i+1]) && s[i+1] != '$':
+			// Named parameters, such as :name and @name, are single words.
+			for i++; i < len(s) && isSQLWordByte(s[i]); i++ {
+			}
+			tokens = append(tokens, sqlToken{sqlWord, s[start:i]})
+			continue
+		case isSQLOperatorByte(c):
+			for i++; i < len(s) && isSQLOperatorByte(s[i]) && !strings.HasPrefix(s[i:], "--") && !strings.HasPrefix(s[i:], "/*"); i++ {
+			}
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth--; depth < 0 {
+				return nil, fmt.Errorf("unbalanced ')' at offset %d", start)
+			}
+			i++
+		default:
+			i++
+		}
+		tokens = append(tokens, sqlToken{sqlPunct, s[start:i]})
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("%d unclosed '('", depth)
+	}
+	return tokens, nil
+}
+
+// canonicalSQL tokenizes b as SQL and re-serialises it with keywords in upper
+// case and each statement on a single line, with tokens separated by a single
+// space, except around "." and inside parentheses, and no space before "," or
+// ";" or between a function name and its arguments.
+func canonicalSQL(b []byte) ([]byte, error) {
+	tokens, err := tokenizeSQL(string(b))
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	lineStart := true
+	for i, tok := range tokens {
+		text := tok.text
+		if tok.kind == sqlWord {
+			// A word qualified by "." is an identifier, even if it is also a
+			// keyword, e.g. the column of t.order.
+			qualified := i > 0 && tokens[i-1].text == "." || i+1 < len(tokens) && tokens[i+1].text == "."
+			if _, ok := sqlKeywords[strings.ToUpper(text)]; ok && !qualified {
+				text = strings.ToUpper(text)
+			}
+		}
+		if !lineStart && sqlNeedsSpace(tokens[i-1], tok) {
+			buf.WriteByte(' ')
+		}
+		buf.WriteString(text)
+		lineStart = tok.kind == sqlLineComment || text == ";"
+		if lineStart {
+			buf.WriteByte('\n')
+		}
+	}
+	if !lineStart {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// sqlNeedsSpace reports whether a space is written between the adjacent tokens
+// prev and next.
+func sqlNeedsSpace(prev, next sqlToken) bool {
+	switch {
+	case prev.text == "." || next.text == "." || prev.text == "(" || next.text == ")":
+		return false
+	case next.text == "," || next.text == ";":
+		return false
+	case next.text == "(" && prev.kind == sqlWord:
+		_, keyword := sqlKeywords[strings.ToUpper(prev.text)]
+		return keyword
+	}
+	return true
+}
+
+// SQLNormaliser is a ReaderNormaliser that formats the data as SQL in a
+// canonical form, so that generated queries which differ only in whitespace or
+// the case of keywords compare as equal. Keywords are written in upper case,
+// and each statement is written on a single line with tokens separated by a
+// single space. Identifiers, function names, literals and comments are kept as
+// written. If the data cannot be tokenized, e.g. because a string or comment is
+// not terminated or parentheses are unbalanced, it is passed through
+// unmodified.
+//
+// SQLNormaliser does not depend on a particular dialect, but it understands
+// only the lexical syntax common to most of them: quotes are escaped by
+// doubling, as in standard SQL, not by backslashes as in MySQL, and
+// PostgreSQL dollar-quoted strings and SQL Server bracketed identifiers are
+// not recognised. Since the statements are not parsed, equivalent queries
+// with a different structure, such as a different order of columns or
+// redundant parentheses, do not compare as equal.
+//
+// The statements are tokenized rather than parsed with a SQL parser library,
+// as such parsers implement the grammar of a single dialect and reject
+// statements in other dialects, which would leave them unnormalised. Only
+// tokenizing also avoids adding a dependency for a single normaliser.
+func SQLNormaliser(r io.Reader) io.Reader {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return errReader{err}
+	}
+	canonical, err := canonicalSQL(b)
+	if err != nil {
+		return bytes.NewReader(b)
+	}
+	return bytes.NewReader(canonical)
+}
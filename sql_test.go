@@ -0,0 +1,55 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSQLNormaliser(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"keywords", "select id, name from users where id = 1", "SELECT id, name FROM users WHERE id = 1\n"},
+		{"whitespace", "SELECT  id,name\n\tFROM users\n  WHERE id=$1 ;", "SELECT id, name FROM users WHERE id = $1;\n"},
+		{"functions", "select COUNT( * ) from t group by lower(name)", "SELECT COUNT(*) FROM t GROUP BY lower(name)\n"},
+		{"in list", "select 1 where x in(1,2)", "SELECT 1 WHERE x IN (1, 2)\n"},
+		{"qualified", "select t.order, \"Select\" from s.t as t", "SELECT t.order, \"Select\" FROM s.t AS t\n"},
+		{"strings", "select 'it''s  from' from t", "SELECT 'it''s  from' FROM t\n"},
+		{"parameters", "update t set a = :a, b = @b, c = ? where d >= 0.5", "UPDATE t SET a = :a, b = @b, c = ? WHERE d >= 0.5\n"},
+		{"comments", "-- list users  \nselect /* all */ * from users", "-- list users\nSELECT /* all */ * FROM users\n"},
+		{"statements", "insert into t values (1);delete from t;", "INSERT INTO t VALUES (1);\nDELETE FROM t;\n"},
+		{"keywords in strings", "select 'select  from -- x /* y */' from t", "SELECT 'select  from -- x /* y */' FROM t\n"},
+		{"quoted identifiers", "select \"from  to\", `Order` , \"a\"\"b\" from t", "SELECT \"from  to\", `Order`, \"a\"\"b\" FROM t\n"},
+		{"multiline block comment", "select /* select\n  from */ 1", "SELECT /* select\n  from */ 1\n"},
+		{"comment after operator", "select 1--one\n+ 2", "SELECT 1 --one\n+ 2\n"},
+		{"comment at end", "select 1 -- done", "SELECT 1 -- done\n"},
+		{"unterminated string", "select 'oops", "select 'oops"},
+		{"unterminated quoted identifier", "select \"oops from t", "select \"oops from t"},
+		{"unterminated comment", "select /* oops", "select /* oops"},
+		{"unbalanced", "select (1", "select (1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			str, err := ReadAll(SQLNormaliser(strings.NewReader(tt.input)))
+			if err != nil || str != tt.expected {
+				t.Errorf("expected %q, got %q, %v", tt.expected, str, err)
+			}
+		})
+	}
+}
+
+func TestMatchSQLNormaliser(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	opts := []MatchOption{dirOpt, WithSnapshotFileExtension(".sql"), WithReaderNormaliser(SQLNormaliser)}
+	if ok, msg := Match(t, strings.NewReader("SELECT id FROM users WHERE active"), opts...); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("select id\nfrom users\nwhere active\n"), opts...); !ok {
+		t.Errorf("expected formatting differences to be ignored: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader("select name from users where active"), opts...); ok {
+		t.Errorf("expected different SQL to fail")
+	}
+}
@@ -0,0 +1,67 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+// captureOutput replaces *f, e.g. os.Stdout, with a pipe while fn runs and
+// returns everything written to it. *f is restored even if fn panics.
+func captureOutput(f **os.File, fn func() error) (out []byte, err error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipe: %w", err)
+	}
+	type result struct {
+		b   []byte
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		b, err := io.ReadAll(r)
+		_ = r.Close()
+		done <- result{b, err}
+	}()
+	saved := *f
+	*f = w
+	defer func() {
+		*f = saved
+		_ = w.Close()
+		res := <-done
+		out = res.b
+		if err == nil && res.err != nil {
+			err = fmt.Errorf("failed to read captured output: %w", res.err)
+		}
+	}()
+	return nil, fn()
+}
+
+// matchOutput captures the output written to *f by fn and compares it with
+// the output snapshot, as for match. skip is as for match.
+func matchOutput(t *testing.T, skip int, f **os.File, fn func() error, optFns ...MatchOption) (ok bool, msg string) {
+	out, err := captureOutput(f, fn)
+	if err != nil {
+		msg = "captured function failed: " + err.Error()
+		return
+	}
+	return match(t, skip+1, bytes.NewReader(out), optFns...)
+}
+
+// MatchStdout behaves as Match, comparing everything written to os.Stdout by
+// fn with the output snapshot. os.Stdout is redirected to a pipe while fn runs,
+// and restored afterwards, even if fn panics. If fn returns an error, Match is
+// not called and the error is returned in msg. This allows output written
+// with fmt.Println and similar to be snapshotted without refactoring the code
+// to take an io.Writer. As os.Stdout is global, fn must not run in parallel
+// with other code that writes to it.
+func MatchStdout(t *testing.T, fn func() error, optFns ...MatchOption) (ok bool, msg string) {
+	return matchOutput(t, 1, &os.Stdout, fn, optFns...)
+}
+
+// MatchStderr behaves as MatchStdout, but captures os.Stderr.
+func MatchStderr(t *testing.T, fn func() error, optFns ...MatchOption) (ok bool, msg string) {
+	return matchOutput(t, 1, &os.Stderr, fn, optFns...)
+}
@@ -0,0 +1,44 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestMatchStdout(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	greet := func() error {
+		fmt.Println("hello")
+		fmt.Fprintln(os.Stderr, "not captured")
+		return nil
+	}
+	if ok, msg := MatchStdout(t, greet, dirOpt); !ok {
+		t.Fatalf("expected match to succeed: %v", msg)
+	}
+	if str := readAllUnchecked(openUnchecked(t, outputP)); str != "hello\n" {
+		t.Errorf("unexpected output snapshot. expected %q, got %q", "hello\n", str)
+	}
+	fail := func() error { return errors.New("boom") }
+	expectedMsg := "captured function failed: boom"
+	if ok, msg := MatchStdout(t, fail, dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
+
+func TestMatchStdoutRestoresOnPanic(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	stdout := os.Stdout
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected panic %q, got %v", "boom", r)
+			}
+		}()
+		MatchStdout(t, func() error { panic("boom") }, dirOpt)
+	}()
+	if os.Stdout != stdout {
+		t.Errorf("expected os.Stdout to be restored")
+	}
+}
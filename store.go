@@ -0,0 +1,114 @@
+package snapshot
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A Store holds snapshot files, for example on the local filesystem or in
+// object storage. Paths are resolved as for the local filesystem, e.g.
+// "__snapshots__/TestName/output.txt", and a Store may map them to keys of
+// its own. Open must return an error satisfying errors.Is(err,
+// fs.ErrNotExist) if path does not exist, and Create must create path, or
+// replace it if it exists, once the returned io.WriteCloser is closed. If a
+// Store also has a Remove(path string) error method, it is used to remove
+// partially written snapshots and stale files written by
+// WithWriteActualOnFailure.
+type Store interface {
+	Open(path string) (io.ReadCloser, error)
+	Create(path string) (io.WriteCloser, error)
+	Exists(path string) bool
+}
+
+// remover is implemented by Stores which can remove files, as described by
+// Store.
+type remover interface {
+	Remove(path string) error
+}
+
+// WithStore reads and writes snapshot files through s, rather than the local
+// filesystem, so that, for example, large fixtures may be kept in object
+// storage. This applies to the snapshot files of GetTestInput, Match and
+// Generate, along with their content addressed, versioned, actual and
+// unverified files. Directory snapshots, such as those of MatchFS and
+// GetTestInputDir, and the functions which walk snapshot directories, such as
+// VerifySnapshots, always use the local filesystem.
+func WithStore(s Store) SnapshotOption {
+	return withStore{s}
+}
+
+type withStore struct {
+	s Store
+}
+
+func (wo withStore) ApplyInputOption(o *GetTestInputOptions) {
+	o.Store = wo.s
+}
+
+func (wo withStore) ApplyMatchOption(o *MatchOptions) {
+	o.Store = wo.s
+}
+
+// resolveStore returns s, or the local filesystem if s is nil.
+func resolveStore(s Store) Store {
+	if s == nil {
+		return osStore{}
+	}
+	return s
+}
+
+// osStore is the default Store, which holds snapshot files on the local
+// filesystem.
+type osStore struct{}
+
+func (osStore) Open(p string) (io.ReadCloser, error) {
+	return os.Open(p)
+}
+
+// Create creates the file at p, along with any missing parent directories.
+// The file is opened write-only, so that reading it back always goes through
+// a separate read-only handle.
+func (osStore) Create(p string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+func (osStore) Exists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func (osStore) Remove(p string) error {
+	return os.Remove(p)
+}
+
+// removeFromStore removes the file at p from s, if s can remove files. A file
+// which does not exist is not an error.
+func removeFromStore(s Store, p string) error {
+	r, ok := s.(remover)
+	if !ok {
+		return nil
+	}
+	if err := r.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeStoreFile writes b to the file at p in s.
+func writeStoreFile(s Store, p string, b []byte) error {
+	w, err := s.Create(p)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memStore is a Store which holds snapshot files in memory. Files are only
+// stored once they are closed, as for object storage.
+type memStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{files: map[string][]byte{}}
+}
+
+func (s *memStore) Open(p string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.files[p]
+	if !ok {
+		return nil, fmt.Errorf("open %v: %w", p, fs.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+func (s *memStore) Create(p string) (io.WriteCloser, error) {
+	return &memStoreWriter{s: s, p: p}, nil
+}
+
+func (s *memStore) Exists(p string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.files[p]
+	return ok
+}
+
+func (s *memStore) paths() (out []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p := range s.files {
+		out = append(out, p)
+	}
+	sort.Strings(out)
+	return
+}
+
+type memStoreWriter struct {
+	bytes.Buffer
+	s *memStore
+	p string
+}
+
+func (w *memStoreWriter) Close() error {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	w.s.files[w.p] = w.Bytes()
+	return nil
+}
+
+func TestWithStore(t *testing.T) {
+	store := newMemStore()
+	dir := t.TempDir()
+	storeOpt, dirOpt := WithStore(store), WithSnapshotDir(dir)
+	input := GetTestInput(t, WithCreateSnapshotFromReader(strings.NewReader("hello")), storeOpt, dirOpt)
+	if ok, msg := Match(t, input, storeOpt, dirOpt); !ok {
+		t.Fatalf("expected created snapshot to match: %v", msg)
+	}
+	if str := readAllUnchecked(GetTestInputSeeker(t, storeOpt, dirOpt)); str != "hello" {
+		t.Errorf("expected stored input %q, got %q", "hello", str)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), storeOpt, dirOpt, WithVersionedSnapshot("v1")); !ok {
+		t.Errorf("expected created versioned snapshot to match: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader("world"), storeOpt, dirOpt, WithWriteActualOnFailure()); ok {
+		t.Errorf("expected stored snapshot to differ")
+	}
+	prefix := filepath.Join(dir, t.Name()) + string(filepath.Separator)
+	expected := []string{"input.txt", "output.actual.txt", "output.latest.txt", "output.txt", "output.v1.txt"}
+	var paths []string
+	for _, p := range store.paths() {
+		paths = append(paths, strings.TrimPrefix(p, prefix))
+	}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected stored files %q, got %q", expected, paths)
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("expected no files on the local filesystem, got %v, %v", entries, err)
+	}
+}
+
+func TestWithStoreContentAddressed(t *testing.T) {
+	store := newMemStore()
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	casDir := t.TempDir()
+	opts := []MatchOption{WithStore(store), WithContentAddressedStore(casDir), dirOpt}
+	for i := 0; i < 2; i++ {
+		if ok, msg := Match(t, strings.NewReader("hello"), opts...); !ok {
+			t.Errorf("expected match %d to pass: %v", i, msg)
+		}
+	}
+	b, err := readStoreFile(store, outputP)
+	if err != nil || !strings.HasPrefix(string(b), casPointerPrefix) {
+		t.Errorf("expected a pointer file in the store, got %q, %v", b, err)
+	}
+	if len(store.paths()) != 2 {
+		t.Errorf("expected the pointer and content in the store, got %q", store.paths())
+	}
+}
+
+// matchFlatInStore calls Match with the flat layout in store. It is declared in
+// a different file to its callers, so that the file which is resolved as
+// containing the test shows whether WithCallerSkip skipped it.
+func matchFlatInStore(t *testing.T, store Store, optFns ...MatchOption) (ok bool, msg string) {
+	return Match(t, strings.NewReader("hello"), append([]MatchOption{WithFlatLayout(), WithStore(store)}, optFns...)...)
+}
@@ -0,0 +1,111 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+)
+
+// streamChunkSize is the number of bytes read from each side at a time by
+// StreamComparator.
+const streamChunkSize = 32 * 1024
+
+// StreamComparator compares the bytes of expected and actual as they are
+// read, in fixed size chunks, without reading either into memory in full. It
+// returns as soon as a difference is found, reporting the byte offset of the
+// first difference. This is suited to very large snapshots where a diff is not
+// required, and is best used together with WithStreaming.
+func StreamComparator(expected, actual io.Reader) (ok bool, msg string) {
+	eBuf := make([]byte, streamChunkSize)
+	aBuf := make([]byte, streamChunkSize)
+	var offset int64
+	for {
+		eN, eErr := io.ReadFull(expected, eBuf)
+		if eErr != nil && eErr != io.EOF && eErr != io.ErrUnexpectedEOF {
+			msg = "failed to read expected data from reader: " + eErr.Error()
+			return
+		}
+		aN, aErr := io.ReadFull(actual, aBuf)
+		if aErr != nil && aErr != io.EOF && aErr != io.ErrUnexpectedEOF {
+			msg = "failed to read actual data from reader: " + aErr.Error()
+			return
+		}
+		n := eN
+		if aN < n {
+			n = aN
+		}
+		for i := 0; i < n; i++ {
+			if eBuf[i] != aBuf[i] {
+				msg = fmt.Sprintf("first difference at byte offset %d", offset+int64(i))
+				return
+			}
+		}
+		if eN != aN {
+			msg = fmt.Sprintf("lengths differ, first difference at byte offset %d", offset+int64(n))
+			return
+		}
+		offset += int64(eN)
+		if eErr != nil {
+			ok = true
+			return
+		}
+	}
+}
+
+// LineEndingNormaliser is a streaming ReaderNormaliser that converts Windows
+// line endings, "\r\n", to "\n", so that snapshots recorded on different
+// platforms compare equal. The data is processed in chunks of streamChunkSize
+// as it is read, so it is suited to WithStreaming.
+func LineEndingNormaliser(r io.Reader) io.Reader {
+	return &lineEndingReader{r: r, chunk: make([]byte, streamChunkSize+1)}
+}
+
+// lineEndingReader implements LineEndingNormaliser.
+type lineEndingReader struct {
+	r io.Reader
+	// chunk holds the data read from r, after a leading byte reserved for
+	// a "\r" held over from the previous chunk.
+	chunk []byte
+	// out is the normalised data of chunk yet to be read.
+	out []byte
+	// cr is true if the previous chunk ended with a "\r", which is held
+	// back until it is known whether a "\n" follows it.
+	cr  bool
+	err error
+}
+
+func (l *lineEndingReader) Read(p []byte) (int, error) {
+	for len(l.out) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		l.fill()
+	}
+	n := copy(p, l.out)
+	l.out = l.out[n:]
+	return n, nil
+}
+
+// fill reads the next chunk from r and normalises it into out.
+func (l *lineEndingReader) fill() {
+	n, err := l.r.Read(l.chunk[1:])
+	l.err = err
+	data := l.chunk[1 : 1+n]
+	if l.cr {
+		l.chunk[0] = '\r'
+		data = l.chunk[:1+n]
+		l.cr = false
+	}
+	if err == nil && len(data) > 0 && data[len(data)-1] == '\r' {
+		l.cr = true
+		data = data[:len(data)-1]
+	}
+	j := 0
+	for i := 0; i < len(data); i++ {
+		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+			continue
+		}
+		data[j] = data[i]
+		j++
+	}
+	l.out = data[:j]
+}
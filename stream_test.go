@@ -0,0 +1,146 @@
+package snapshot
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamComparator(t *testing.T) {
+	large := strings.Repeat("a", 3*streamChunkSize+10)
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		ok       bool
+		msg      string
+	}{
+		{
+			name:     "equal",
+			expected: large,
+			actual:   large,
+			ok:       true,
+		},
+		{
+			name:     "empty",
+			expected: "",
+			actual:   "",
+			ok:       true,
+		},
+		{
+			name:     "difference in later chunk",
+			expected: large,
+			actual:   large[:2*streamChunkSize+5] + "b" + large[2*streamChunkSize+6:],
+			msg:      "first difference at byte offset 65541",
+		},
+		{
+			name:     "actual is shorter",
+			expected: large,
+			actual:   large[:streamChunkSize],
+			msg:      "lengths differ, first difference at byte offset 32768",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := StreamComparator(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Fatalf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
+
+func TestMatchBuffersReaders(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	Generate(t, strings.NewReader("hello"), dirOpt)
+	rereading := func(expected, actual io.Reader) (bool, string) {
+		for _, r := range []io.Reader{expected, actual} {
+			_, _ = ReadAll(r)
+			seeker, ok := r.(io.Seeker)
+			if !ok {
+				return false, "reader is not buffered"
+			}
+			if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+				return false, err.Error()
+			}
+		}
+		return StringComparator(expected, actual)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithComparator(rereading), dirOpt); !ok {
+		t.Errorf("expected buffered readers to be readable twice: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithStreaming(), WithComparator(StreamComparator), dirOpt); !ok {
+		t.Errorf("expected streaming match to succeed: %v", msg)
+	}
+}
+
+func TestLineEndingNormaliser(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{"", ""},
+		{"a\r\nb\r\n", "a\nb\n"},
+		{"a\rb\r", "a\rb\r"},
+		{"a\r\r\nb", "a\r\nb"},
+		{strings.Repeat("x", streamChunkSize-1) + "\r\ny", strings.Repeat("x", streamChunkSize-1) + "\ny"},
+	}
+	for _, test := range tests {
+		for _, r := range []io.Reader{strings.NewReader(test.in), iotest.OneByteReader(strings.NewReader(test.in))} {
+			if str := readAllUnchecked(LineEndingNormaliser(r)); str != test.out {
+				t.Errorf("expected %q to normalise to %q, got %q", test.in, test.out, str)
+			}
+		}
+	}
+	r := LineEndingNormaliser(iotest.ErrReader(io.ErrUnexpectedEOF))
+	if _, err := io.ReadAll(r); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected read error to be returned, got %v", err)
+	}
+}
+
+func TestMatchStreamingCreate(t *testing.T) {
+	if actualCopy := writeOutputSnapshot(t, filepath.Join(t.TempDir(), "output.txt"), strings.NewReader("hello"), newMatchOptions([]MatchOption{WithStreaming()})); actualCopy != nil {
+		t.Errorf("expected streaming snapshot creation not to buffer the actual data")
+	}
+	dirOpt, _, _ := getInputOutputPaths(t)
+	opts := []MatchOption{WithStreaming(), WithReaderNormaliser(LineEndingNormaliser), WithComparator(StreamComparator), WithEncryption(make([]byte, 16)), dirOpt}
+	if ok, msg := Match(t, strings.NewReader("hello\r\nworld"), opts...); !ok {
+		t.Errorf("expected created snapshot to match: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello\nworld"), opts...); !ok {
+		t.Errorf("expected normalised line endings to match: %v", msg)
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (n int, err error) {
+	n, err = c.r.Read(p)
+	c.n += n
+	return
+}
+
+func TestWithFastEqual(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	large := strings.Repeat("a", 8*streamChunkSize)
+	if ok, msg := Match(t, strings.NewReader(large), WithFastEqual(), dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader(large), WithFastEqual(), dirOpt); !ok {
+		t.Errorf("expected equal data to match: %v", msg)
+	}
+	actual := &countingReader{r: strings.NewReader("aaab" + large[4:])}
+	ok, msg := Match(t, actual, WithFastEqual(), dirOpt)
+	if expectedMsg := "first difference at byte offset 3"; ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+	if actual.n >= len(large) {
+		t.Errorf("expected the comparison to stop at the first difference, but read %d bytes", actual.n)
+	}
+}
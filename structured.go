@@ -0,0 +1,105 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Severity classifies a Difference found by a StructuredComparator.
+type Severity int
+
+const (
+	// SeverityError is a difference which fails the comparison. This is the
+	// zero value, so that a Difference fails unless classified otherwise.
+	SeverityError Severity = iota
+	// SeverityWarning is a difference which is reported, but which does not
+	// fail the comparison on its own.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// A Difference is a single difference between the expected and actual data
+// found by a StructuredComparator.
+type Difference struct {
+	// Path locates the difference within the data, e.g. a JSON path or
+	// line number. It is empty if the difference applies to the whole of
+	// the data.
+	Path string
+	// Expected and Actual are the differing parts of the expected and
+	// actual data.
+	Expected string
+	Actual   string
+	// Message describes the difference, in place of Expected and Actual,
+	// if it is not empty.
+	Message  string
+	Severity Severity
+}
+
+// String formats d as a line of a failure message, e.g.
+// `$.name: expected "a", got "b"`, prefixed by "warning: " for warnings.
+func (d Difference) String() string {
+	s := d.Message
+	if s == "" {
+		s = fmt.Sprintf("expected %q, got %q", d.Expected, d.Actual)
+	}
+	if d.Path != "" {
+		s = d.Path + ": " + s
+	}
+	if d.Severity != SeverityError {
+		s = d.Severity.String() + ": " + s
+	}
+	return s
+}
+
+// A StructuredComparator compares the actual and expected io.Readers as a
+// Comparator, but returns each difference found, rather than a single
+// message, so that they can be counted, classified and rendered by
+// MatchWithResult callers and reporters. The comparison passes if none of the
+// differences has SeverityError. A failure to read or decode the data should
+// be returned as a Difference with a Message.
+type StructuredComparator func(expected, actual io.Reader) []Difference
+
+// Comparator adapts sc to a Comparator, for use wherever a Comparator is
+// expected. The failure message lists the differences, one per line.
+func (sc StructuredComparator) Comparator() Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		return differencesResult(sc(expected, actual))
+	}
+}
+
+// differencesResult returns whether diffs passes, as it has no difference with
+// SeverityError, and, if not, a failure message listing all of the
+// differences, one per line.
+func differencesResult(diffs []Difference) (ok bool, msg string) {
+	ok = true
+	lines := make([]string, len(diffs))
+	for i, d := range diffs {
+		if d.Severity == SeverityError {
+			ok = false
+		}
+		lines[i] = d.String()
+	}
+	if !ok {
+		msg = strings.Join(lines, "\n")
+	}
+	return
+}
+
+// WithStructuredComparator compares the actual and expected data with sc, in
+// place of the Comparator, so that the differences are returned in the
+// Differences of MatchWithResult. Differences with SeverityWarning are logged
+// when the comparison passes.
+func WithStructuredComparator(sc StructuredComparator) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.StructuredComparator = sc })
+}
@@ -0,0 +1,77 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lineDifferences is a StructuredComparator which reports each differing line,
+// as a warning if the lines differ only in surrounding whitespace.
+func lineDifferences(expected, actual io.Reader) (diffs []Difference) {
+	eBytes, aBytes, err := readBoth(expected, actual)
+	if err != nil {
+		return []Difference{{Message: err.Error()}}
+	}
+	eLines, aLines := splitLines(string(eBytes)), splitLines(string(aBytes))
+	for i := 0; i < len(eLines) || i < len(aLines); i++ {
+		var e, a string
+		if i < len(eLines) {
+			e = eLines[i]
+		}
+		if i < len(aLines) {
+			a = aLines[i]
+		}
+		if e == a {
+			continue
+		}
+		d := Difference{Path: fmt.Sprintf("line %d", i+1), Expected: e, Actual: a}
+		if strings.TrimSpace(e) == strings.TrimSpace(a) {
+			d.Severity = SeverityWarning
+		}
+		diffs = append(diffs, d)
+	}
+	return
+}
+
+func TestDifferenceString(t *testing.T) {
+	tests := []struct {
+		d        Difference
+		expected string
+	}{
+		{Difference{Path: "$.a", Expected: "1", Actual: "2"}, `$.a: expected "1", got "2"`},
+		{Difference{Expected: "x", Actual: "y"}, `expected "x", got "y"`},
+		{Difference{Path: "line 3", Message: "trailing space", Severity: SeverityWarning}, "warning: line 3: trailing space"},
+	}
+	for _, tt := range tests {
+		if str := tt.d.String(); str != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, str)
+		}
+	}
+}
+
+func TestStructuredComparator(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	cmpOpt := WithStructuredComparator(lineDifferences)
+	if ok, msg := Match(t, strings.NewReader("a\nb\nc\n"), dirOpt, cmpOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+
+	res := MatchWithResult(t, strings.NewReader("a\nb \nc\n"), dirOpt, cmpOpt)
+	expectedDiffs := []Difference{{Path: "line 2", Expected: "b", Actual: "b ", Severity: SeverityWarning}}
+	if !res.OK || res.Msg != "" || !reflect.DeepEqual(res.Differences, expectedDiffs) {
+		t.Errorf("expected warnings to pass, got %v, %q, %v", res.OK, res.Msg, res.Differences)
+	}
+
+	res = MatchWithResult(t, strings.NewReader("a\nb \nd\n"), dirOpt, cmpOpt)
+	expectedMsg := "warning: line 2: expected \"b\", got \"b \"\nline 3: expected \"c\", got \"d\""
+	if res.OK || res.Msg != expectedMsg || len(res.Differences) != 2 {
+		t.Errorf("expected %q, got %v, %q, %v", expectedMsg, res.OK, res.Msg, res.Differences)
+	}
+
+	if ok, msg := Compare(strings.NewReader("a"), strings.NewReader("b"), WithComparator(StructuredComparator(lineDifferences).Comparator())); ok || msg != `line 1: expected "a", got "b"` {
+		t.Errorf("unexpected adapted comparator result: %v, %q", ok, msg)
+	}
+}
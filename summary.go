@@ -0,0 +1,124 @@
+package snapshot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+)
+
+// A snapshotResult is the outcome of a single snapshot operation.
+type snapshotResult struct {
+	test    string
+	path    string
+	created bool
+	ok      bool
+	msg     string
+}
+
+// summary accumulates the outcomes of snapshot operations across all tests in
+// the test binary. It is guarded by a mutex as tests may run in parallel.
+var summary struct {
+	mu      sync.Mutex
+	results []snapshotResult
+}
+
+// recordResult records the outcome of a snapshot operation on the snapshot
+// file at p by the named test, along with the failure message if any.
+func recordResult(test, p string, created, ok bool, msg string) {
+	summary.mu.Lock()
+	defer summary.mu.Unlock()
+	summary.results = append(summary.results, snapshotResult{test: test, path: p, created: created, ok: ok, msg: msg})
+}
+
+// recordedResults returns a copy of the results recorded so far.
+func recordedResults() []snapshotResult {
+	summary.mu.Lock()
+	defer summary.mu.Unlock()
+	return append([]snapshotResult(nil), summary.results...)
+}
+
+// PrintSummary writes a one line summary of how many snapshots were matched,
+// created and failed to w. Input snapshots from GetTestInput and its variants
+// are included, with existing input snapshots counted as matched and new ones
+// as created. The snapshots which would have been created
+// or overwritten under SNAPSHOTS=verify-clean are then listed, one per line.
+// This is intended to be called from TestMain after the tests have run, e.g.
+//
+//	func TestMain(m *testing.M) {
+//		code := m.Run()
+//		snapshot.PrintSummary(os.Stdout)
+//		os.Exit(code)
+//	}
+func PrintSummary(w io.Writer) {
+	var matched, created, failed int
+	for _, r := range recordedResults() {
+		switch {
+		case !r.ok:
+			failed++
+		case r.created:
+			created++
+		default:
+			matched++
+		}
+	}
+	_, _ = fmt.Fprintf(w, "snapshots: %d matched, %d created, %d failed\n", matched, created, failed)
+	if paths := forbiddenChangePaths(); len(paths) > 0 {
+		_, _ = fmt.Fprintf(w, "snapshots which would change with %v=%v:\n", snapshotsEnv, snapshotsVerifyClean)
+		for _, p := range paths {
+			_, _ = fmt.Fprintf(w, "  %v\n", p)
+		}
+	}
+}
+
+// junitTestSuite is the root element of a JUnit XML report.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is a single snapshot operation in a JUnit XML report.
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure describes a failed snapshot operation in a JUnit XML report.
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes a JUnit XML report to w, with one testcase for each
+// snapshot operation performed so far, in the order they completed. Each
+// testcase is named after the snapshot file, with the test name as its class
+// name, and the failure message, such as the diff, is included in the body of
+// failed testcases. This is intended to be called from TestMain after the
+// tests have run, as for PrintSummary.
+func WriteJUnitReport(w io.Writer) error {
+	suite := junitTestSuite{Name: "snapshot"}
+	for _, r := range recordedResults() {
+		tc := junitTestCase{ClassName: r.test, Name: filepath.Base(r.path)}
+		if !r.ok {
+			tc.Failure = &junitFailure{Message: "snapshot did not match " + r.path, Body: r.msg}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suite); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func resetSummary(t *testing.T) {
+	summary.mu.Lock()
+	saved := summary.results
+	summary.results = nil
+	summary.mu.Unlock()
+	t.Cleanup(func() {
+		summary.mu.Lock()
+		summary.results = append(saved, summary.results...)
+		summary.mu.Unlock()
+	})
+}
+
+func TestPrintSummary(t *testing.T) {
+	resetSummary(t)
+
+	recordResult(t.Name(), "a/output.txt", false, true, "")
+	recordResult(t.Name(), "b/output.txt", false, true, "")
+	recordResult(t.Name(), "c/input.txt", true, true, "")
+	recordResult(t.Name(), "d/output.txt", true, false, "mismatch")
+
+	buf := new(strings.Builder)
+	PrintSummary(buf)
+	expected := "snapshots: 2 matched, 1 created, 1 failed\n"
+	if str := buf.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+}
+
+func TestPrintSummaryInputs(t *testing.T) {
+	resetSummary(t)
+	dirOpt, _, _ := getInputOutputPaths(t)
+
+	readAllUnchecked(GetTestInput(t, WithDefaultInput("hello"), dirOpt))
+	readAllUnchecked(GetTestInput(t, dirOpt))
+	GetTestInputSeeker(t, dirOpt)
+
+	buf := new(strings.Builder)
+	PrintSummary(buf)
+	expected := "snapshots: 2 matched, 1 created, 0 failed\n"
+	if str := buf.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+}
+
+func TestWriteJUnitReport(t *testing.T) {
+	resetSummary(t)
+
+	recordResult("TestA", "__snapshots__/TestA/output.txt", false, true, "")
+	recordResult("TestB", "__snapshots__/TestB/output.txt", false, false, `expected "a", got "<b>"`)
+
+	buf := new(strings.Builder)
+	if err := WriteJUnitReport(buf); err != nil {
+		t.Fatalf("failed to write report: %v", err)
+	}
+	expected := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="snapshot" tests="2" failures="1">
+  <testcase classname="TestA" name="output.txt"></testcase>
+  <testcase classname="TestB" name="output.txt">
+    <failure message="snapshot did not match __snapshots__/TestB/output.txt">expected &#34;a&#34;, got &#34;&lt;b&gt;&#34;</failure>
+  </testcase>
+</testsuite>
+`
+	if str := buf.String(); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+}
@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// createTimeoutError returns the error of a SnapshotCreator which did not
+// complete within d, as for WithCreateTimeout.
+func createTimeoutError(d time.Duration) error {
+	return fmt.Errorf("snapshot creator timed out after %v", d)
+}
+
+// callSnapshotCreatorWithTimeout calls the SnapshotCreator as for
+// callSnapshotCreator. If CreateTimeout is set, the creator is called in a
+// goroutine, and an error is returned if it does not return within the
+// timeout, after which no further attempts are made and nothing more is
+// logged, as the test may have completed. The returned reader then fails
+// with the same error if its data is not read in full before the timeout.
+func callSnapshotCreatorWithTimeout(t *testing.T, opts GetTestInputOptions) (in io.Reader, err error) {
+	logf := resolveLogger(t, opts.Logger)
+	if opts.CreateTimeout <= 0 {
+		return callSnapshotCreator(logf, opts, nil)
+	}
+	deadline := time.Now().Add(opts.CreateTimeout)
+	var mu sync.Mutex
+	stop := make(chan struct{})
+	stoppable := func(format string, args ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		select {
+		case <-stop:
+		default:
+			logf(format, args...)
+		}
+	}
+	type result struct {
+		in  io.Reader
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		in, err := callSnapshotCreator(stoppable, opts, stop)
+		done <- result{in, err}
+	}()
+	timer := time.NewTimer(opts.CreateTimeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return nil, res.err
+		}
+		return newDeadlineReader(res.in, deadline, opts.CreateTimeout), nil
+	case <-timer.C:
+		mu.Lock()
+		close(stop)
+		mu.Unlock()
+		return nil, createTimeoutError(opts.CreateTimeout)
+	}
+}
+
+// deadlineReadBufferSize is the size of the buffer a deadlineReader reads
+// into.
+const deadlineReadBufferSize = 32 * 1024
+
+// deadlineReader reads from r, failing with the error of createTimeoutError if
+// a Read does not return before deadline. The data is read by a single
+// goroutine for the whole stream, which exits at the deadline if it is idle.
+// On timeout, r is closed if it is an io.Closer, such as an io.PipeReader,
+// which releases the blocked Read. Otherwise the goroutine leaks until the
+// Read of r returns, which may be never.
+type deadlineReader struct {
+	r        io.Reader
+	deadline time.Time
+	timeout  time.Duration
+	reqs     chan struct{}
+	results  chan deadlineResult
+	// buf is written by the goroutine after each request, and read by Read
+	// once the result is received, until pending is drained.
+	buf     []byte
+	pending []byte
+	err     error
+}
+
+type deadlineResult struct {
+	n   int
+	err error
+}
+
+// newDeadlineReader returns a deadlineReader for r and starts its goroutine.
+func newDeadlineReader(r io.Reader, deadline time.Time, timeout time.Duration) *deadlineReader {
+	d := &deadlineReader{
+		r:        r,
+		deadline: deadline,
+		timeout:  timeout,
+		reqs:     make(chan struct{}),
+		results:  make(chan deadlineResult, 1),
+		buf:      make([]byte, deadlineReadBufferSize),
+	}
+	go d.readLoop()
+	return d
+}
+
+// readLoop reads from r into buf on each request, until r fails or the
+// deadline passes.
+func (d *deadlineReader) readLoop() {
+	timer := time.NewTimer(time.Until(d.deadline))
+	defer timer.Stop()
+	for {
+		select {
+		case <-d.reqs:
+		case <-timer.C:
+			return
+		}
+		n, err := d.r.Read(d.buf)
+		d.results <- deadlineResult{n, err}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (d *deadlineReader) Read(p []byte) (int, error) {
+	if len(d.pending) > 0 {
+		n := copy(p, d.pending)
+		d.pending = d.pending[n:]
+		return n, nil
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	timer := time.NewTimer(time.Until(d.deadline))
+	defer timer.Stop()
+	select {
+	case d.reqs <- struct{}{}:
+	case <-timer.C:
+		return 0, d.timedOut()
+	}
+	select {
+	case res := <-d.results:
+		n := copy(p, d.buf[:res.n])
+		d.pending = d.buf[n:res.n]
+		d.err = res.err
+		if len(d.pending) > 0 {
+			return n, nil
+		}
+		return n, res.err
+	case <-timer.C:
+		return 0, d.timedOut()
+	}
+}
+
+// timedOut fails every later Read with the timeout error, and closes r if it
+// is an io.Closer.
+func (d *deadlineReader) timedOut() error {
+	d.err = createTimeoutError(d.timeout)
+	if c, ok := d.r.(io.Closer); ok {
+		_ = c.Close()
+	}
+	return d.err
+}
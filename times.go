@@ -0,0 +1,163 @@
+package snapshot
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TimePlaceholder is a layout for WithTimeFormat and FormatTimes which
+// replaces every time.Time and time.Duration with "<TIME>".
+const TimePlaceholder = "<TIME>"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// WithTimeFormat formats every time.Time, at any depth and including through
+// pointers, using layout when values are serialised as JSON by
+// WithCreateSnapshotAsJSON and MatchJSONAs, as for FormatTimes. This produces
+// stable snapshots of structs containing times which vary on each run. As the
+// formatted times may not decode into the original type, MatchJSONAs compares
+// with JSONComparator when this option is used.
+func WithTimeFormat(layout string) SnapshotOption {
+	return withTimeFormat{layout}
+}
+
+type withTimeFormat struct {
+	layout string
+}
+
+func (wo withTimeFormat) ApplyInputOption(o *GetTestInputOptions) {
+	o.TimeFormat = wo.layout
+}
+
+func (wo withTimeFormat) ApplyMatchOption(o *MatchOptions) {
+	o.TimeFormat = wo.layout
+}
+
+// FormatTimes returns a copy of i, for serialisation as JSON, in which every
+// time.Time is replaced by its string formatted with layout and every
+// time.Duration is replaced by its String form, e.g. "1.5s". If layout is
+// TimePlaceholder, both are replaced with "<TIME>". Structs are converted to
+// maps, respecting the names, "-" and omitempty of their json tags, and
+// values which implement json.Marshaler are left unmodified.
+func FormatTimes(i interface{}, layout string) interface{} {
+	if i == nil {
+		return nil
+	}
+	return formatTimes(reflect.ValueOf(i), layout)
+}
+
+// formatTimes implements FormatTimes for v.
+func formatTimes(v reflect.Value, layout string) interface{} {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		return formatTimes(v.Elem(), layout)
+	}
+	switch v.Type() {
+	case timeType:
+		if layout == TimePlaceholder {
+			return TimePlaceholder
+		}
+		return v.Interface().(time.Time).Format(layout)
+	case durationType:
+		if layout == TimePlaceholder {
+			return TimePlaceholder
+		}
+		return v.Interface().(time.Duration).String()
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		m := make(map[string]interface{})
+		formatStructTimes(m, v, layout)
+		return m
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = formatTimes(iter.Value(), layout)
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return v.Interface()
+		}
+		s := make([]interface{}, v.Len())
+		for i := range s {
+			s[i] = formatTimes(v.Index(i), layout)
+		}
+		return s
+	}
+	return v.Interface()
+}
+
+// formatStructTimes adds the exported fields of the struct v to m, as for
+// FormatTimes. The fields of embedded structs without a json tag name are
+// added to m directly, as by encoding/json.
+func formatStructTimes(m map[string]interface{}, v reflect.Value, layout string) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if field.Anonymous && name == "" {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct && fv.Type() != timeType {
+				formatStructTimes(m, fv, layout)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyJSONValue(fv) {
+			continue
+		}
+		m[name] = formatTimes(fv, layout)
+	}
+}
+
+// isEmptyJSONValue reports whether v is omitted by the omitempty json tag
+// option.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
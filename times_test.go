@@ -0,0 +1,97 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type timedEvent struct {
+	Name     string         `json:"name"`
+	At       time.Time      `json:"at"`
+	Took     time.Duration  `json:"took"`
+	Next     *time.Time     `json:"next,omitempty"`
+	Skipped  *time.Time     `json:"skipped,omitempty"`
+	Children []timedEvent   `json:"children,omitempty"`
+	Labels   map[string]any `json:"labels,omitempty"`
+	Ignored  string         `json:"-"`
+	internal int
+}
+
+func TestFormatTimes(t *testing.T) {
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	event := timedEvent{
+		Name:     "parent",
+		At:       at,
+		Took:     1500 * time.Millisecond,
+		Next:     &at,
+		Children: []timedEvent{{Name: "child", At: at}},
+		Labels:   map[string]any{"when": at},
+		Ignored:  "ignored",
+		internal: 1,
+	}
+	tests := []struct {
+		name     string
+		layout   string
+		expected interface{}
+	}{
+		{
+			name:   "layout",
+			layout: "2006-01-02",
+			expected: map[string]interface{}{
+				"name": "parent",
+				"at":   "2024-01-02",
+				"took": "1.5s",
+				"next": "2024-01-02",
+				"children": []interface{}{
+					map[string]interface{}{"name": "child", "at": "2024-01-02", "took": "0s"},
+				},
+				"labels": map[string]interface{}{"when": "2024-01-02"},
+			},
+		},
+		{
+			name:   "placeholder",
+			layout: TimePlaceholder,
+			expected: map[string]interface{}{
+				"name": "parent",
+				"at":   "<TIME>",
+				"took": "<TIME>",
+				"next": "<TIME>",
+				"children": []interface{}{
+					map[string]interface{}{"name": "child", "at": "<TIME>", "took": "<TIME>"},
+				},
+				"labels": map[string]interface{}{"when": "<TIME>"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.expected, FormatTimes(&event, tt.layout)); diff != "" {
+				t.Errorf("unexpected result: %v", diff)
+			}
+		})
+	}
+}
+
+func TestWithTimeFormat(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	event := func() timedEvent { return timedEvent{Name: "event", At: time.Now(), Took: time.Second} }
+	expected := "{\n  \"at\": \"<TIME>\",\n  \"name\": \"event\",\n  \"took\": \"<TIME>\"\n}\n"
+
+	input := GetTestInput(t, WithCreateSnapshotAsJSON(event), WithTimeFormat(TimePlaceholder), dirOpt)
+	if str := readAllUnchecked(input); str != expected {
+		t.Errorf("expected %q, got %q", expected, str)
+	}
+	for i := 0; i < 2; i++ {
+		if ok, msg := MatchJSONAs(t, event(), WithTimeFormat(TimePlaceholder), dirOpt); !ok {
+			t.Fatalf("expected match %d to succeed: %v", i, msg)
+		}
+	}
+	for _, p := range []string{strings.TrimSuffix(inputP, ".txt") + ".json", strings.TrimSuffix(outputP, ".txt") + ".json"} {
+		if str := readAllUnchecked(openUnchecked(t, p)); str != expected {
+			t.Errorf("unexpected snapshot %v. expected %q, got %q", p, expected, str)
+		}
+	}
+}
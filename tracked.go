@@ -0,0 +1,74 @@
+package snapshot
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// errNotGitRepository is returned by gitCheckIgnore when the path is not within
+// a git repository, or git is not installed.
+var errNotGitRepository = errors.New("not a git repository")
+
+// existingAncestor returns the nearest ancestor directory of p that exists.
+func existingAncestor(p string) string {
+	dir := filepath.Dir(p)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// gitCheckIgnore reports whether the absolute path p would be ignored by git,
+// using `git check-ignore`. The path does not need to exist.
+func gitCheckIgnore(p string) (ignored bool, err error) {
+	if _, err = exec.LookPath("git"); err != nil {
+		err = errNotGitRepository
+		return
+	}
+	cmd := exec.Command("git", "check-ignore", "-q", "--", p)
+	cmd.Dir = existingAncestor(p)
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		ignored = true
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		err = nil
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 128:
+		err = errNotGitRepository
+	}
+	return
+}
+
+// RequireTrackedSnapshots fails the test if the output snapshot file for the
+// test, as resolved by Match with the same optFns, would be ignored by git.
+// Ignored snapshots pass locally, but are missing when the tests run
+// elsewhere, such as in CI. The check is skipped if the test is not within a
+// git repository or git is not installed.
+func RequireTrackedSnapshots(t *testing.T, optFns ...MatchOption) {
+	opts := newMatchOptions(optFns)
+	p, err := filepath.Abs(resolveSnapshotFilePath(t, 0, opts.pathOptions(), false))
+	if err != nil {
+		t.Fatalf("failed to resolve snapshot path: %v", err.Error())
+	}
+	ignored, err := gitCheckIgnore(p)
+	if errors.Is(err, errNotGitRepository) {
+		resolveLogger(t, opts.Logger)("skipping tracked snapshot check for %v: %v", p, err)
+		return
+	}
+	if err != nil {
+		t.Fatalf("failed to check whether snapshot %v is ignored by git: %v", p, err.Error())
+	}
+	if ignored {
+		t.Errorf("snapshot file %v is ignored by git, so it will be missing when the tests run elsewhere", p)
+	}
+}
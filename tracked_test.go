@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitCheckIgnore(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	if _, err := gitCheckIgnore(filepath.Join(dir, "__snapshots__", "output.txt")); err != errNotGitRepository {
+		t.Fatalf("expected %v outside of a git repository, got %v", errNotGitRepository, err)
+	}
+	if out, err := exec.Command("git", "init", dir).CombinedOutput(); err != nil {
+		t.Fatalf("failed to initialise git repository: %v: %s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.golden\n"), 0600); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+	tests := []struct {
+		name    string
+		path    string
+		ignored bool
+	}{
+		{name: "tracked", path: filepath.Join(dir, "__snapshots__", "TestA", "output.txt")},
+		{name: "ignored", path: filepath.Join(dir, "__snapshots__", "TestA", "output.golden"), ignored: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ignored, err := gitCheckIgnore(tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ignored != tt.ignored {
+				t.Fatalf("expected ignored to be %v, got %v", tt.ignored, ignored)
+			}
+		})
+	}
+}
+
+// ignoredSnapshotDirEnv is set to the snapshot directory when
+// TestRequireTrackedSnapshots runs itself in a subprocess, to exercise the
+// failure for an ignored snapshot.
+const ignoredSnapshotDirEnv = "SNAPSHOT_TEST_IGNORED_SNAPSHOT_DIR"
+
+func TestRequireTrackedSnapshots(t *testing.T) {
+	if dir := os.Getenv(ignoredSnapshotDirEnv); dir != "" {
+		RequireTrackedSnapshots(t, WithSnapshotDir(dir), WithSnapshotFileExtension(".golden"))
+		return
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	if out, err := exec.Command("git", "init", repo).CombinedOutput(); err != nil {
+		t.Fatalf("failed to initialise git repository: %v: %s", err, out)
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".gitignore"), []byte("*.golden\n"), 0600); err != nil {
+		t.Fatalf("failed to write .gitignore: %v", err)
+	}
+	dir := filepath.Join(repo, "__snapshots__")
+
+	if !t.Run("tracked", func(t *testing.T) {
+		RequireTrackedSnapshots(t, WithSnapshotDir(dir))
+	}) {
+		t.Errorf("expected a tracked snapshot to pass")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRequireTrackedSnapshots$")
+	cmd.Env = append(os.Environ(), ignoredSnapshotDirEnv+"="+dir)
+	out, err := cmd.CombinedOutput()
+	p := filepath.Join(dir, t.Name(), "output.golden")
+	if expected := "snapshot file " + p + " is ignored by git"; err == nil || !strings.Contains(string(out), expected) {
+		t.Errorf("expected an ignored snapshot to fail with %q, got %v: %s", expected, err, out)
+	}
+}
@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// readTree reads the regular files of fsys, keyed by their slash separated
+// paths.
+func readTree(fsys fs.FS) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		files[p], err = fs.ReadFile(fsys, p)
+		return err
+	})
+	return files, err
+}
+
+// writeTree writes files, as returned by readTree, beneath dir.
+func writeTree(dir string, files map[string][]byte) error {
+	for p, b := range files {
+		p = filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0750); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, b, 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isBinary reports whether b appears to be binary data, rather than text,
+// because it contains a NUL byte or is not valid UTF-8.
+func isBinary(b []byte) bool {
+	return bytes.IndexByte(b, 0) >= 0 || !utf8.Valid(b)
+}
+
+// compareTrees compares the files of expected and actual, as returned by
+// readTree. Binary files are compared byte for byte, and text files using the
+// ReaderNormaliser and Comparator of opts. On failure the added, removed and
+// changed files are reported, sorted by path.
+func compareTrees(expected, actual map[string][]byte, opts MatchOptions) (ok bool, msg string) {
+	type difference struct {
+		name string
+		msg  string
+	}
+	var diffs []difference
+	for name, e := range expected {
+		a, found := actual[name]
+		switch {
+		case !found:
+			diffs = append(diffs, difference{name, "removed " + name})
+		case isBinary(e) || isBinary(a):
+			if !bytes.Equal(e, a) {
+				diffs = append(diffs, difference{name, fmt.Sprintf("changed %v: binary content differs, size %d -> %d", name, len(e), len(a))})
+			}
+		default:
+			if ok, msg := compare(bytes.NewReader(e), bytes.NewReader(a), opts); !ok {
+				diffs = append(diffs, difference{name, fmt.Sprintf("changed %v: %v", name, msg)})
+			}
+		}
+	}
+	for name, a := range actual {
+		if _, found := expected[name]; !found {
+			diffs = append(diffs, difference{name, fmt.Sprintf("added %v: size %d", name, len(a))})
+		}
+	}
+	ok = len(diffs) == 0
+	if !ok {
+		sort.Slice(diffs, func(i, j int) bool { return diffs[i].name < diffs[j].name })
+		buf := new(strings.Builder)
+		buf.WriteString("tree contents differ:")
+		for _, d := range diffs {
+			buf.WriteString("\n" + d.msg)
+		}
+		msg = buf.String()
+	}
+	return
+}
+
+// MatchFS matches the directory tree actual against the output snapshot
+// directory, for tests of tools which produce a whole directory of output. By
+// default, this is the directory <test-directory>/__snapshots__/<test-name>/tree,
+// which is named after the snapshot name, and the file extension is ignored.
+// Every regular file of actual is compared with the file at the same path in
+// the snapshot directory. Binary files, which contain a NUL byte or are not
+// valid UTF-8, are compared byte for byte, and text files using the
+// ReaderNormaliser and Comparator. On failure the added, removed and changed
+// files are reported. If the snapshot directory does not exist, the whole of
+// actual is written to it, as for Match, and as for Match the directory is
+// written again when the -update-snapshots mode is all.
+func MatchFS(t *testing.T, actual fs.FS, optFns ...MatchOption) (ok bool, msg string) {
+	skipIfSnapshotsDisabled(t)
+	opts := newMatchOptions(append([]MatchOption{WithSnapshotName("tree")}, optFns...))
+	po := opts.pathOptions()
+	po.ext = ""
+	dir := resolveSnapshotFilePath(t, 0, po, false)
+	logf := resolveLogger(t, opts.Logger)
+	logf("output snapshot directory: %v", dir)
+	created := false
+	defer func() { recordResult(t.Name(), dir, created, ok, msg) }()
+	aFiles, err := readTree(actual)
+	if err != nil {
+		msg = "failed to read actual tree: " + err.Error()
+		return
+	}
+	info, err := statSnapshotDirForUpdate(t, dir, true)
+	switch {
+	case err == nil && info.IsDir():
+		logf("using existing snapshot")
+	case err == nil:
+		msg = fmt.Sprintf("output snapshot %q is not a directory", dir)
+		return
+	case os.IsNotExist(err):
+		checkSnapshotChange(t, dir, "created")
+		if autoCreateDisabled(t, opts.NoAutoCreate) {
+			t.Fatalf(noAutoCreateMessage, dir)
+		}
+		logf("creating new output snapshot directory")
+		if err = writeTree(dir, aFiles); err != nil {
+			t.Fatalf("failed to write output snapshot directory %v: %v", dir, err.Error())
+		}
+		created = true
+	default:
+		msg = fmt.Sprintf("failed to open output snapshot directory %v: %v", dir, err.Error())
+		return
+	}
+	eFiles, err := readTree(os.DirFS(dir))
+	if err != nil {
+		msg = fmt.Sprintf("failed to read output snapshot directory %v: %v", dir, err.Error())
+		return
+	}
+	return compareTrees(eFiles, aFiles, opts)
+}
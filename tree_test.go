@@ -0,0 +1,58 @@
+package snapshot
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestMatchFS(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	tree := fstest.MapFS{
+		"README.md":       {Data: []byte("hello\n")},
+		"cmd/main.go":     {Data: []byte("package main\n")},
+		"assets/logo.png": {Data: []byte{0x89, 'P', 'N', 'G', 0}},
+	}
+	if ok, msg := MatchFS(t, tree, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	p := filepath.Join(filepath.Dir(outputP), "tree", "cmd", "main.go")
+	if str := readAllUnchecked(openUnchecked(t, p)); str != "package main\n" {
+		t.Fatalf("expected tree to be written, got %q", str)
+	}
+	if ok, msg := MatchFS(t, tree, dirOpt); !ok {
+		t.Errorf("expected same tree to match: %v", msg)
+	}
+	changed := fstest.MapFS{
+		"README.md":       {Data: []byte("goodbye\n")},
+		"assets/logo.png": {Data: []byte{0x89, 'P', 'N', 'G', 0, 1}},
+		"go.mod":          {Data: []byte("module example\n")},
+	}
+	expectedMsg := "tree contents differ:\n" +
+		`changed README.md: expected "hello\n", got "goodbye\n"` + "\n" +
+		"changed assets/logo.png: binary content differs, size 5 -> 6\n" +
+		"removed cmd/main.go\n" +
+		"added go.mod: size 15"
+	if ok, msg := MatchFS(t, changed, dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+}
+
+func TestMatchFSUpdateSnapshots(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	p := filepath.Join(filepath.Dir(outputP), "tree", "README.md")
+	setUpdateSnapshots(t, updateNew)
+	if ok, msg := MatchFS(t, fstest.MapFS{"README.md": {Data: []byte("hello\n")}}, WithNoAutoCreate(), dirOpt); !ok {
+		t.Fatalf("expected missing snapshot directory to be created: %v", msg)
+	}
+	if ok, _ := MatchFS(t, fstest.MapFS{"README.md": {Data: []byte("world\n")}}, dirOpt); ok {
+		t.Errorf("expected existing snapshot directory not to be overwritten")
+	}
+	setUpdateSnapshots(t, updateAll)
+	if ok, msg := MatchFS(t, fstest.MapFS{"README.md": {Data: []byte("world\n")}}, dirOpt); !ok {
+		t.Fatalf("expected existing snapshot directory to be overwritten: %v", msg)
+	}
+	if str := readAllUnchecked(openUnchecked(t, p)); str != "world\n" {
+		t.Errorf("unexpected snapshot. expected %q, got %q", "world\n", str)
+	}
+}
@@ -0,0 +1,142 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// decodeJSONAs decodes the JSON document in r into a value of type T.
+func decodeJSONAs[T any](r io.Reader) (v T, err error) {
+	err = json.NewDecoder(r).Decode(&v)
+	return
+}
+
+// TypedJSONComparator creates a Comparator that decodes expected and actual as
+// JSON into values of type T and compares them with cmp.Diff, returning the
+// diff on failure. This gives field aware diffs rather than string diffs.
+func TypedJSONComparator[T any]() Comparator {
+	return typedJSONComparator[T](nil)
+}
+
+// typedJSONComparator implements TypedJSONComparator, applying transform, if
+// it is not nil, to the decoded values before they are compared.
+func typedJSONComparator[T any](transform func(T) T) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		eValue, err := decodeJSONAs[T](expected)
+		if err != nil {
+			msg = "failed to decode expected JSON: " + err.Error()
+			return
+		}
+		aValue, err := decodeJSONAs[T](actual)
+		if err != nil {
+			msg = "failed to decode actual JSON: " + err.Error()
+			return
+		}
+		if transform != nil {
+			eValue, aValue = transform(eValue), transform(aValue)
+		}
+		msg = cmp.Diff(eValue, aValue, cmp.Exporter(func(reflect.Type) bool { return true }))
+		ok = msg == ""
+		return
+	}
+}
+
+// MatchJSONAs marshals actual using AsJSON and matches it against the output
+// snapshot as Match, storing the snapshot with the ".json" file extension. The
+// expected snapshot is decoded into a T and compared with actual using
+// TypedJSONComparator, so that the failure message is a cmp.Diff of the two
+// values. These defaults may be overridden by optFns. If WithTimeFormat is
+// given, times within actual are formatted before it is marshalled, and the
+// snapshot is compared using JSONComparator instead. A transform given by
+// WithValueTransform is applied to the decoded values before they are
+// compared, and cannot be combined with WithTimeFormat.
+func MatchJSONAs[T any](t *testing.T, actual T, optFns ...MatchOption) (ok bool, msg string) {
+	return matchJSONAs(t, 2, actual, optFns...)
+}
+
+// matchJSONAs implements MatchJSONAs. skip is passed to match.
+func matchJSONAs[T any](t *testing.T, skip int, actual T, optFns ...MatchOption) (ok bool, msg string) {
+	opts := newMatchOptions(optFns)
+	var transform func(T) T
+	if opts.ValueTransform != nil {
+		var isT bool
+		if transform, isT = opts.ValueTransform.(func(T) T); !isT {
+			t.Fatalf("WithValueTransform function %T does not match the type of the value %T", opts.ValueTransform, actual)
+		}
+		if opts.TimeFormat != "" {
+			t.Fatalf("WithValueTransform cannot be combined with WithTimeFormat")
+		}
+	}
+	r, err := asJSONWithTimeFormat(actual, opts.TimeFormat)
+	if err != nil {
+		t.Fatalf("failed to encode actual as JSON: %v", err.Error())
+	}
+	comparator := typedJSONComparator(transform)
+	if opts.TimeFormat != "" {
+		comparator = JSONComparator
+	}
+	optFns = append([]MatchOption{
+		WithSnapshotFileExtension(".json"),
+		WithComparator(comparator),
+	}, optFns...)
+	return match(t, skip, r, optFns...)
+}
+
+// WithValueTransform applies transform to both the decoded expected and actual
+// values compared by MatchJSONAs and Snapshot.Match, e.g. to zero a volatile
+// field, which is simpler than normalising the encoded bytes. The diff on
+// failure is of the transformed values, and the snapshot stores the actual
+// value unmodified. T must be the type of the value being matched.
+func WithValueTransform[T any](transform func(T) T) MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.ValueTransform = transform })
+}
+
+// Snapshot provides a type safe round trip of values of type T through JSON
+// snapshots, without encoding and decoding them by hand. Options holds the
+// options applied to every call, before those passed to Match or Input. The
+// zero value is ready to use. For example:
+//
+//	var s snapshot.Snapshot[Config]
+//	cfg := s.Input(t)
+//	s.Match(t, process(cfg))
+type Snapshot[T any] struct {
+	Options []SnapshotOption
+}
+
+// Match matches value against the output snapshot as MatchJSONAs.
+func (s Snapshot[T]) Match(t *testing.T, value T, optFns ...MatchOption) (ok bool, msg string) {
+	opts := make([]MatchOption, 0, len(s.Options)+len(optFns))
+	for _, o := range s.Options {
+		opts = append(opts, o)
+	}
+	return matchJSONAs(t, 2, value, append(opts, optFns...)...)
+}
+
+// Input loads the input snapshot as GetTestInput, with the ".json" file
+// extension, and decodes it as JSON into a T. The test fails immediately if
+// the snapshot cannot be decoded. When the input snapshot does not exist,
+// WithCreateSnapshotAsJSON may be used to create it from a default value.
+func (s Snapshot[T]) Input(t *testing.T, optFns ...GetTestInputOption) T {
+	opts := make([]GetTestInputOption, 0, len(s.Options)+len(optFns)+1)
+	opts = append(opts, WithSnapshotFileExtension(".json"))
+	for _, o := range s.Options {
+		opts = append(opts, o)
+	}
+	// The input is read in full, as for GetTestInputDecoded, so that a created
+	// snapshot is complete even if the decoder stops at the end of the
+	// document.
+	b, err := io.ReadAll(getTestInput(t, 1, append(opts, optFns...)...))
+	if err != nil {
+		t.Fatalf("failed to read input snapshot: %v", err.Error())
+	}
+	v, err := decodeJSONAs[T](bytes.NewReader(b))
+	if err != nil {
+		t.Fatalf("failed to decode input snapshot as JSON: %v", err.Error())
+	}
+	return v
+}
@@ -0,0 +1,110 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type typedTestStruct struct {
+	Name  string
+	Items []int
+}
+
+func TestMatchJSONAs(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	value := typedTestStruct{Name: "hello", Items: []int{1, 2}}
+	if ok, msg := MatchJSONAs(t, value, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if _, err := openUnchecked(t, strings.TrimSuffix(outputP, ".txt")+".json").Stat(); err != nil {
+		t.Fatalf("failed to stat snapshot: %v", err)
+	}
+	if ok, msg := MatchJSONAs(t, value, dirOpt); !ok {
+		t.Errorf("expected same value to match: %v", msg)
+	}
+	value.Items = append(value.Items, 3)
+	ok, msg := MatchJSONAs(t, value, dirOpt)
+	if ok || !strings.Contains(msg, "Items") {
+		t.Errorf("expected field aware diff, got %v, %q", ok, msg)
+	}
+}
+
+func TestSnapshotTyped(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	s := Snapshot[typedTestStruct]{Options: []SnapshotOption{dirOpt}}
+	want := typedTestStruct{Name: "hello", Items: []int{1, 2}}
+	got := s.Input(t, WithCreateSnapshotAsJSON(want))
+	if got.Name != want.Name || len(got.Items) != 2 {
+		t.Fatalf("expected %+v from created input, got %+v", want, got)
+	}
+	got = s.Input(t)
+	if got.Name != want.Name || len(got.Items) != 2 {
+		t.Fatalf("expected %+v from existing input, got %+v", want, got)
+	}
+	if ok, msg := s.Match(t, got); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	got.Name = "changed"
+	if ok, msg := s.Match(t, got); ok || !strings.Contains(msg, "Name") {
+		t.Errorf("expected field aware diff, got %v, %q", ok, msg)
+	}
+}
+
+func TestSnapshotTypedLocatesTestFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newMemStore()
+	s := Snapshot[typedTestStruct]{Options: []SnapshotOption{WithStore(store)}}
+	// The input snapshot is stored once the subtest completes.
+	t.Run("input", func(t *testing.T) {
+		s.Input(t, WithCreateSnapshotAsJSON(typedTestStruct{Name: "located"}))
+	})
+	expected := []string{filepath.Join(wd, "__snapshots__", t.Name(), "input", "input.json")}
+	if paths := store.paths(); !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected input snapshot beside the test file at %v, got %v", expected, paths)
+	}
+}
+
+func TestSnapshotTypedInputTrailingContent(t *testing.T) {
+	dirOpt, inputP, _ := getInputOutputPaths(t)
+	// The trailing whitespace is longer than the buffer of the JSON decoder,
+	// which stops reading at the end of the document.
+	data := "{\"Name\": \"hello\"}\n" + strings.Repeat(" ", 1<<16) + "\n"
+	s := Snapshot[typedTestStruct]{Options: []SnapshotOption{dirOpt}}
+	t.Run("input", func(t *testing.T) {
+		if got := s.Input(t, WithCreateSnapshotFromReader(strings.NewReader(data))); got.Name != "hello" {
+			t.Errorf("expected %q, got %+v", "hello", got)
+		}
+	})
+	p := filepath.Join(filepath.Dir(filepath.Dir(inputP)), t.Name(), "input", "input.json")
+	if str := readAllUnchecked(openUnchecked(t, p)); str != data {
+		t.Errorf("expected the complete input snapshot of %d bytes, got %d bytes", len(data), len(str))
+	}
+}
+
+func TestWithValueTransform(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	clearItems := WithValueTransform(func(v typedTestStruct) typedTestStruct {
+		v.Items = nil
+		return v
+	})
+	if ok, msg := MatchJSONAs(t, typedTestStruct{Name: "a", Items: []int{1}}, clearItems, dirOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if ok, msg := MatchJSONAs(t, typedTestStruct{Name: "a", Items: []int{2, 3}}, clearItems, dirOpt); !ok {
+		t.Errorf("expected transformed field to be ignored: %v", msg)
+	}
+	ok, msg := MatchJSONAs(t, typedTestStruct{Name: "b", Items: []int{1}}, clearItems, dirOpt)
+	if ok || !strings.Contains(msg, "Name") || strings.Contains(msg, "Items: []int{") {
+		t.Errorf("expected diff of transformed values, got %v, %q", ok, msg)
+	}
+	s := Snapshot[typedTestStruct]{Options: []SnapshotOption{dirOpt}}
+	if ok, msg := s.Match(t, typedTestStruct{Name: "a", Items: []int{4}}, clearItems); !ok {
+		t.Errorf("expected transform to apply to Snapshot.Match: %v", msg)
+	}
+}
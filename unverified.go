@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// unverifiedSuffix is appended to the path of a snapshot file to name its
+// unverified sentinel file.
+const unverifiedSuffix = ".unverified"
+
+// unverifiedSentinelPath returns the path of the unverified sentinel file of
+// the snapshot file at p.
+func unverifiedSentinelPath(p string) string {
+	return p + unverifiedSuffix
+}
+
+// writeUnverifiedSentinel writes the unverified sentinel file of the snapshot
+// file at p in s, which was recorded by test at time now.
+func writeUnverifiedSentinel(s Store, p, test string, now time.Time) error {
+	content := fmt.Sprintf("recorded by %v at %v\nremove this file once the snapshot has been reviewed\n",
+		test, now.UTC().Format(time.RFC3339))
+	return writeStoreFile(s, unverifiedSentinelPath(p), []byte(content))
+}
+
+// checkVerified returns ok and msg unmodified unless the snapshot file at p in
+// s has an unverified sentinel file and ok is true, in which case the
+// comparison fails.
+func checkVerified(s Store, p string, ok bool, msg string) (bool, string) {
+	if !ok {
+		return ok, msg
+	}
+	sentinel := unverifiedSentinelPath(p)
+	if !s.Exists(sentinel) {
+		return ok, msg
+	}
+	return false, fmt.Sprintf("snapshot %v has not been verified: review it, then remove %v or run VerifySnapshots", p, sentinel)
+}
+
+// WithVerification gives newly created output snapshots a verification
+// lifecycle. When Match creates a snapshot, it also writes the sentinel file
+// <snapshot>.unverified beside it, e.g. "output.txt.unverified", and logs that
+// the snapshot is unverified. The recording run passes, but subsequent runs
+// fail while the sentinel exists, even if the snapshot matches, so that a
+// snapshot cannot be relied upon until a reviewer has accepted it by removing
+// the sentinel, either by hand or with VerifySnapshots.
+func WithVerification() MatchOption {
+	return MatchOptionFunc(func(o *MatchOptions) { o.RequireVerification = true })
+}
+
+// VerifySnapshots walks dir, typically a __snapshots__ directory, and removes
+// every unverified sentinel file written by WithVerification, accepting the
+// snapshots as reviewed. The paths of the verified snapshots are returned.
+func VerifySnapshots(dir string) (verified []string, err error) {
+	paths, err := findSnapshotFiles(dir, "*"+unverifiedSuffix)
+	if err != nil {
+		return
+	}
+	for _, p := range paths {
+		if err = os.Remove(p); err != nil {
+			return
+		}
+		verified = append(verified, strings.TrimSuffix(p, unverifiedSuffix))
+	}
+	return
+}
@@ -0,0 +1,51 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithVerification(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	if ok, msg := Match(t, strings.NewReader("hello"), WithVerification(), dirOpt); !ok {
+		t.Fatalf("expected recording run to pass: %v", msg)
+	}
+	sentinel := outputP + ".unverified"
+	if _, err := os.Stat(sentinel); err != nil {
+		t.Fatalf("expected sentinel to be written: %v", err)
+	}
+	expectedMsg := "snapshot " + outputP + " has not been verified: review it, then remove " + sentinel + " or run VerifySnapshots"
+	if ok, msg := Match(t, strings.NewReader("hello"), WithVerification(), dirOpt); ok || msg != expectedMsg {
+		t.Errorf("expected %v, %q, got %v, %q", false, expectedMsg, ok, msg)
+	}
+	if ok, msg := Match(t, strings.NewReader("world"), WithVerification(), dirOpt); ok || msg != `expected "hello", got "world"` {
+		t.Errorf("expected mismatch to be reported, got %v, %q", ok, msg)
+	}
+	verified, err := VerifySnapshots(filepath.Dir(filepath.Dir(outputP)))
+	if err != nil {
+		t.Fatalf("failed to verify snapshots: %v", err)
+	}
+	if diff := cmp.Diff([]string{outputP}, verified); diff != "" {
+		t.Errorf("unexpected verified snapshots: %v", diff)
+	}
+	if ok, msg := Match(t, strings.NewReader("hello"), WithVerification(), dirOpt); !ok {
+		t.Errorf("expected verified snapshot to match: %v", msg)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	now := func() time.Time { return time.Date(2021, 2, 3, 4, 5, 6, 0, time.UTC) }
+	if ok, msg := Match(t, strings.NewReader("hello"), WithVerification(), WithClock(now), dirOpt); !ok {
+		t.Fatalf("expected recording run to pass: %v", msg)
+	}
+	expected := "recorded by TestWithClock at 2021-02-03T04:05:06Z\nremove this file once the snapshot has been reviewed\n"
+	if str := readAllUnchecked(openUnchecked(t, outputP+".unverified")); str != expected {
+		t.Errorf("unexpected sentinel. expected %q, got %q", expected, str)
+	}
+}
@@ -0,0 +1,93 @@
+package snapshot
+
+import (
+	"flag"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+// The modes of the -update-snapshots flag.
+const (
+	// updateOff creates missing snapshots, unless automatic creation is
+	// disabled, and never overwrites existing snapshots.
+	updateOff = "off"
+	// updateNew creates missing snapshots, even if automatic creation is
+	// disabled, and never overwrites existing snapshots.
+	updateNew = "new"
+	// updateAll creates missing snapshots and overwrites existing snapshots.
+	updateAll = "all"
+)
+
+// updateSnapshots is the value of the -update-snapshots flag, which is parsed
+// by the test binary, e.g. `go test ./... -update-snapshots=new`. The modes
+// are:
+//
+//   - off, the default, creates missing snapshots unless automatic creation
+//     is disabled with WithNoAutoCreate or DefaultNoAutoCreate, and never
+//     overwrites existing snapshots.
+//   - new creates missing snapshots, even if automatic creation is disabled,
+//     and never overwrites existing snapshots, so that new snapshot
+//     assertions can be added to a suite without accepting regressions in
+//     existing ones.
+//   - all creates missing snapshots and overwrites every existing output
+//     snapshot with the actual data, and every existing input snapshot that
+//     has a SnapshotCreator with its data.
+var updateSnapshots = flag.String("update-snapshots", updateOff,
+	"update snapshots: off, new to create missing snapshots only, or all to also overwrite existing snapshots")
+
+// snapshotUpdateMode returns the mode of the -update-snapshots flag, or, if
+// the flag is not set, the update mode of the project config file. The test
+// fails if the mode is not recognised.
+func snapshotUpdateMode(t testing.TB) string {
+	mode := *updateSnapshots
+	if cfgMode := configDefaults().update; cfgMode != "" && !updateFlagSet() {
+		mode = cfgMode
+	}
+	switch mode {
+	case updateOff, updateNew, updateAll:
+		return mode
+	default:
+		t.Fatalf("invalid -update-snapshots mode %q, expected off, new or all", mode)
+		return updateOff
+	}
+}
+
+// autoCreateDisabled reports whether a missing snapshot should fail the test
+// rather than be created, given the NoAutoCreate option and the
+// -update-snapshots mode.
+func autoCreateDisabled(t testing.TB, noAutoCreate bool) bool {
+	return noAutoCreate && snapshotUpdateMode(t) == updateOff
+}
+
+// openSnapshotFileForUpdate opens the snapshot file at p in s as for
+// openSnapshotFile, unless the -update-snapshots mode is all and overwrite is
+// true, in which case an existing snapshot is reported as not existing so
+// that it is overwritten, unless forbidden by checkSnapshotChange.
+func openSnapshotFileForUpdate(t testing.TB, s Store, p, dir string, overwrite bool) (io.ReadCloser, error) {
+	file, err := openSnapshotFile(s, p, dir)
+	if err == nil && overwrite && snapshotUpdateMode(t) == updateAll {
+		_ = file.Close()
+		checkSnapshotChange(t, p, "overwritten")
+		return nil, os.ErrNotExist
+	}
+	return file, err
+}
+
+// statSnapshotDirForUpdate returns the fs.FileInfo of the snapshot directory
+// dir as for os.Stat, unless the -update-snapshots mode is all and overwrite
+// is true, in which case an existing directory is removed and reported as not
+// existing so that it is written again, unless forbidden by
+// checkSnapshotChange.
+func statSnapshotDirForUpdate(t testing.TB, dir string, overwrite bool) (fs.FileInfo, error) {
+	info, err := os.Stat(dir)
+	if err == nil && info.IsDir() && overwrite && snapshotUpdateMode(t) == updateAll {
+		checkSnapshotChange(t, dir, "overwritten")
+		if err = os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+		return nil, os.ErrNotExist
+	}
+	return info, err
+}
@@ -0,0 +1,56 @@
+package snapshot
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+func setUpdateSnapshots(t *testing.T, mode string) {
+	t.Helper()
+	old := *updateSnapshots
+	if err := flag.Set("update-snapshots", mode); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { *updateSnapshots = old })
+}
+
+func TestUpdateSnapshotsNew(t *testing.T) {
+	dirOpt, _, outputP := getInputOutputPaths(t)
+	setUpdateSnapshots(t, "new")
+	if ok, msg := Match(t, strings.NewReader("hello"), WithRequireExistingOutput(), dirOpt); !ok {
+		t.Fatalf("expected missing snapshot to be created: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader("world"), dirOpt); ok {
+		t.Errorf("expected existing snapshot not to be overwritten")
+	}
+	if str := readAllUnchecked(openUnchecked(t, outputP)); str != "hello" {
+		t.Errorf("unexpected output snapshot. expected %q, got %q", "hello", str)
+	}
+}
+
+func TestUpdateSnapshotsAll(t *testing.T) {
+	dirOpt, inputP, outputP := getInputOutputPaths(t)
+	writeSnapshotFile(t, inputP, "old input")
+	writeSnapshotFile(t, outputP, "old output")
+	setUpdateSnapshots(t, "all")
+	if ok, msg := Match(t, strings.NewReader("hello"), dirOpt); !ok {
+		t.Fatalf("expected existing snapshot to be overwritten: %v", msg)
+	}
+	if str := readAllUnchecked(openUnchecked(t, outputP)); str != "hello" {
+		t.Errorf("unexpected output snapshot. expected %q, got %q", "hello", str)
+	}
+	if str := readAllUnchecked(GetTestInput(t, dirOpt)); str != "old input" {
+		t.Errorf("expected input without a creator to be kept, got %q", str)
+	}
+	input := GetTestInput(t, WithCreateSnapshot(func() (io.Reader, error) {
+		return strings.NewReader("new input"), nil
+	}), dirOpt)
+	if str := readAllUnchecked(input); str != "new input" {
+		t.Errorf("expected %q, got %q", "new input", str)
+	}
+	if str := readAllUnchecked(openUnchecked(t, inputP)); str != "new input" {
+		t.Errorf("unexpected input snapshot. expected %q, got %q", "new input", str)
+	}
+}
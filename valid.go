@@ -0,0 +1,99 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// checkValid passes if validate accepts actual, reporting the error as
+// actual not being valid in format otherwise.
+func checkValid(format string, actual io.Reader, validate func(r io.Reader) error) (ok bool, msg string) {
+	if err := validate(actual); err != nil {
+		msg = "actual is not valid " + format + ": " + err.Error()
+		return
+	}
+	ok = true
+	return
+}
+
+// ValidJSONComparator is a Comparator which passes if actual is a single
+// valid JSON value, without reading the expected snapshot, so that Match
+// asserts only that volatile output is well-formed. On failure the parse
+// error is reported.
+func ValidJSONComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return checkValid("JSON", actual, validateJSON)
+}
+
+// validateJSON returns an error if r is not a single valid JSON value.
+func validateJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	var v json.RawMessage
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	return checkJSONEOF(dec)
+}
+
+// ValidYAMLComparator is a Comparator which passes if actual is a valid YAML
+// stream of any number of documents, without reading the expected snapshot,
+// as for ValidJSONComparator.
+func ValidYAMLComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return checkValid("YAML", actual, validateYAML)
+}
+
+// validateYAML returns an error if r is not a valid YAML stream.
+func validateYAML(r io.Reader) error {
+	dec := yaml.NewDecoder(r)
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// ValidXMLComparator is a Comparator which passes if actual is a well-formed
+// XML document with a single root element, without reading the expected
+// snapshot, as for ValidJSONComparator.
+func ValidXMLComparator(expected, actual io.Reader) (ok bool, msg string) {
+	return checkValid("XML", actual, validateXML)
+}
+
+// validateXML returns an error if r is not a well-formed XML document with a
+// single root element.
+func validateXML(r io.Reader) error {
+	dec := xml.NewDecoder(r)
+	roots, depth := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(tok)) > 0 {
+				return errors.New("text outside of the root element")
+			}
+		}
+	}
+	if roots != 1 {
+		return errors.New("expected a single root element")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidComparators(t *testing.T) {
+	tests := []struct {
+		name   string
+		cmp    Comparator
+		actual string
+		msg    string
+	}{
+		{"json", ValidJSONComparator, `{"id": 1, "tags": []}`, ""},
+		{"json syntax error", ValidJSONComparator, `{"id": 1,}`, "actual is not valid JSON: invalid character '}' looking for beginning of object key string"},
+		{"json trailing data", ValidJSONComparator, `{} {}`, "actual is not valid JSON: unexpected data after the JSON value"},
+		{"json empty", ValidJSONComparator, ``, "actual is not valid JSON: EOF"},
+		{"yaml", ValidYAMLComparator, "a: 1\n---\nb: [x, y]\n", ""},
+		{"yaml syntax error", ValidYAMLComparator, "a: [1\n", "actual is not valid YAML: yaml: line 1: did not find expected ',' or ']'"},
+		{"xml", ValidXMLComparator, `<?xml version="1.0"?><a><b x="1"/>text</a>`, ""},
+		{"xml unclosed", ValidXMLComparator, `<a><b></a>`, "actual is not valid XML: XML syntax error on line 1: element <b> closed by </a>"},
+		{"xml two roots", ValidXMLComparator, `<a/><b/>`, "actual is not valid XML: expected a single root element"},
+		{"xml text", ValidXMLComparator, `text`, "actual is not valid XML: text outside of the root element"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := tt.cmp(strings.NewReader("ignored"), strings.NewReader(tt.actual))
+			if ok != (tt.msg == "") || msg != tt.msg {
+				t.Errorf("expected %q, got %v, %q", tt.msg, ok, msg)
+			}
+		})
+	}
+}
+
+func TestMatchValidJSONComparator(t *testing.T) {
+	dirOpt, _, _ := getInputOutputPaths(t)
+	cmpOpt := WithComparator(ValidJSONComparator)
+	if ok, msg := Match(t, strings.NewReader(`{"time": 1}`), dirOpt, cmpOpt); !ok {
+		t.Fatalf("expected first match to succeed: %v", msg)
+	}
+	if ok, msg := Match(t, strings.NewReader(`{"time": 2}`), dirOpt, cmpOpt); !ok {
+		t.Errorf("expected different valid JSON to match: %v", msg)
+	}
+	if ok, _ := Match(t, strings.NewReader(`{"time": `), dirOpt, cmpOpt); ok {
+		t.Errorf("expected invalid JSON to fail")
+	}
+}
@@ -0,0 +1,76 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// versionLinePrefix is the prefix of the version line read by
+// VersionedComparator.
+const versionLinePrefix = "version:"
+
+// readVersionLine parses a leading "version: x.y.z" line from b, returning
+// the major version, the full version string and the remaining data. An
+// optional "v" prefix on the version is permitted.
+func readVersionLine(b []byte) (major int, version string, rest []byte, err error) {
+	line, rest := b, []byte(nil)
+	if i := bytes.IndexByte(b, '\n'); i != -1 {
+		line, rest = b[:i], b[i+1:]
+	}
+	s := strings.TrimSpace(string(line))
+	if !strings.HasPrefix(s, versionLinePrefix) {
+		err = fmt.Errorf("missing leading %q line", versionLinePrefix+" x.y.z")
+		return
+	}
+	version = strings.TrimSpace(strings.TrimPrefix(s, versionLinePrefix))
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	if len(parts) != 3 {
+		err = fmt.Errorf("invalid version %q, expected x.y.z", version)
+		return
+	}
+	for i, part := range parts {
+		n, convErr := strconv.Atoi(part)
+		if convErr != nil || n < 0 {
+			err = fmt.Errorf("invalid version %q, expected x.y.z", version)
+			return
+		}
+		if i == 0 {
+			major = n
+		}
+	}
+	return
+}
+
+// VersionedComparator creates a Comparator for data beginning with a
+// "version: x.y.z" line. The version line is parsed from both expected and
+// actual, and the comparison fails immediately if the major versions differ,
+// so that an intentional format change is reported distinctly rather than as
+// a confusing diff. Otherwise, the data following the version line is
+// compared using base.
+func VersionedComparator(base Comparator) Comparator {
+	return func(expected, actual io.Reader) (ok bool, msg string) {
+		eBytes, aBytes, err := readBoth(expected, actual)
+		if err != nil {
+			msg = err.Error()
+			return
+		}
+		eMajor, eVersion, eRest, err := readVersionLine(eBytes)
+		if err != nil {
+			msg = "failed to read version of expected: " + err.Error()
+			return
+		}
+		aMajor, aVersion, aRest, err := readVersionLine(aBytes)
+		if err != nil {
+			msg = "failed to read version of actual: " + err.Error()
+			return
+		}
+		if eMajor != aMajor {
+			msg = fmt.Sprintf("major version changed: expected %v, got %v", eVersion, aVersion)
+			return
+		}
+		return base(bytes.NewReader(eRest), bytes.NewReader(aRest))
+	}
+}
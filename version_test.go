@@ -0,0 +1,31 @@
+package snapshot
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersionedComparator(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		actual   string
+		ok       bool
+		msg      string
+	}{
+		{"same version", "version: 1.2.3\nhello", "version: 1.2.3\nhello", true, ""},
+		{"minor bump", "version: 1.2.3\nhello", "version: v1.3.0\nhello", true, ""},
+		{"body changed", "version: 1.2.3\nhello", "version: 1.2.3\nworld", false, `expected "hello", got "world"`},
+		{"major bump", "version: 1.2.3\nhello", "version: 2.0.0\nworld", false, "major version changed: expected 1.2.3, got 2.0.0"},
+		{"missing version", "version: 1.2.3\nhello", "hello", false, `failed to read version of actual: missing leading "version: x.y.z" line`},
+		{"invalid version", "version: 1.x\nhello", "version: 1.2.3\nhello", false, `failed to read version of expected: invalid version "1.x", expected x.y.z`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, msg := VersionedComparator(StringComparator)(strings.NewReader(tt.expected), strings.NewReader(tt.actual))
+			if ok != tt.ok || msg != tt.msg {
+				t.Errorf("expected %v, %q, got %v, %q", tt.ok, tt.msg, ok, msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AsYAML marshals i to the io.Reader as YAML, calling i first if it is a
+// function, as for AsJSON. The value is marshalled through JSON, so that its
+// json struct tags and MarshalJSON methods are respected and the snapshot has
+// the same structure as one created with AsJSON.
+func AsYAML(i interface{}) (out io.Reader, err error) {
+	i, err = callIfFunc(i, "AsYAML")
+	if err != nil {
+		return
+	}
+	return asYAMLWithTimeFormat(i, "")
+}
+
+// asYAMLWithTimeFormat behaves as AsYAML, but first formats any times within
+// the value as for asJSONWithTimeFormat.
+func asYAMLWithTimeFormat(i interface{}, layout string) (io.Reader, error) {
+	r, err := asJSONWithTimeFormat(i, layout)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err = yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot as YAML: %w", err)
+	}
+	clearYAMLStyle(&doc)
+	buf := new(bytes.Buffer)
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot as YAML: %w", err)
+	}
+	return buf, nil
+}
+
+// clearYAMLStyle resets the style of n and its descendants, so that a document
+// parsed from JSON is written in block style, with strings quoted only where
+// necessary.
+func clearYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearYAMLStyle(c)
+	}
+}
+
+// decodeYAML decodes the YAML document in r into v, which must be a pointer.
+// The document is decoded through JSON, as for AsYAML, so that v is decoded
+// using its json struct tags.
+func decodeYAML(r io.Reader, v interface{}) error {
+	var doc interface{}
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil && err != io.EOF {
+		return err
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}